@@ -1,15 +1,40 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/kipz/tufzy/internal/cli"
 )
 
 func main() {
-	if err := cli.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	// Ctrl-C cancels in-flight requests; a second Ctrl-C exits immediately
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if err := cli.ExecuteContext(ctx); err != nil {
+		if ctx.Err() != nil {
+			fmt.Fprintln(os.Stderr, "Interrupted")
+			os.Exit(130)
+		}
+
+		// Some commands report their outcome with a specific exit code
+		code := 1
+		var exitErr *cli.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.Code
+			err = exitErr.Err
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		os.Exit(code)
 	}
 }
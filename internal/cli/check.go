@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kipz/tufzy/internal/client"
+	"github.com/kipz/tufzy/internal/display"
+	"github.com/spf13/cobra"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+)
+
+// Exit codes of the check command; 1 means the check could not run
+const (
+	checkExitWarning      = 2
+	checkExitCritical     = 3
+	checkExitExpired      = 4
+	checkExitVerification = 5
+)
+
+var (
+	checkWarn          string
+	checkCritical      string
+	checkSigningPeriod bool
+	checkJSON          bool
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check [metadata-url]",
+	Short: "Check that no role expires soon, for CI and cron jobs",
+	Long: `Verify the repository and check the expiry of every role, including delegated
+roles, against warning and critical windows.
+
+With --signing-period, roles that set tuf-on-ci's x-tuf-on-ci-signing-period
+warn within that period instead of --warn.
+
+Exit codes:
+  0  every role is OK
+  1  the check could not run, e.g. the repository is unreachable
+  2  a role expires within the warning window
+  3  a role expires within the critical window
+  4  a role has expired
+  5  verification failed, e.g. a bad signature or rollback
+
+Examples:
+  tufzy check https://example.github.io/repo/metadata
+  tufzy check --warn 14d --critical 2d https://example.github.io/repo/metadata
+  tufzy check --signing-period --json https://example.github.io/repo/metadata`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCheck,
+}
+
+func init() {
+	checkCmd.Flags().StringVar(&checkWarn, "warn", "7d", "Warn when a role expires within this window, e.g. 14d or 36h")
+	checkCmd.Flags().StringVar(&checkCritical, "critical", "1d", "Fail when a role expires within this window")
+	checkCmd.Flags().BoolVar(&checkSigningPeriod, "signing-period", false, "Use each role's x-tuf-on-ci-signing-period as its warning window when set")
+	checkCmd.Flags().BoolVar(&checkJSON, "json", false, "Print the result as JSON")
+}
+
+// roleCheckJSON is one role in the JSON output
+type roleCheckJSON struct {
+	Name    string    `json:"name"`
+	Parent  string    `json:"parent,omitempty"`
+	Version int64     `json:"version"`
+	Expires time.Time `json:"expires"`
+	Status  string    `json:"status"`
+	// WarnSeconds is the warning window the role was checked against
+	WarnSeconds int64 `json:"warnSeconds"`
+}
+
+// checkJSONResult is the JSON output of the check command
+type checkJSONResult struct {
+	Repository string          `json:"repository"`
+	Status     string          `json:"status"`
+	Error      string          `json:"error,omitempty"`
+	Roles      []roleCheckJSON `json:"roles"`
+}
+
+func runCheck(cmd *cobra.Command, args []string) error {
+	metadataURL := args[0]
+
+	warn, err := parseWindow(checkWarn)
+	if err != nil {
+		return fmt.Errorf("invalid --warn: %w", err)
+	}
+	critical, err := parseWindow(checkCritical)
+	if err != nil {
+		return fmt.Errorf("invalid --critical: %w", err)
+	}
+
+	// Create TUF client with options
+	options := client.ClientOptions{
+		TargetsURL: targetsURL,
+		Limiter:    limiter,
+		Logger:     logger,
+	}
+	tufClient, err := client.NewClientWithOptionsContext(cmd.Context(), metadataURL, options)
+	if err != nil {
+		return checkFailure(metadataURL, fmt.Errorf("failed to create client: %w", err))
+	}
+
+	// Update metadata; go-tuf rejects expired top-level metadata here
+	if err := tufClient.UpdateContext(cmd.Context()); err != nil {
+		return checkFailure(metadataURL, fmt.Errorf("failed to update metadata: %w", err))
+	}
+
+	// Every role, loading and verifying the delegated roles
+	roles, err := tufClient.GetRolesContext(cmd.Context())
+	if err != nil {
+		return checkFailure(metadataURL, fmt.Errorf("failed to load roles: %w", err))
+	}
+
+	now := time.Now()
+	checks := make([]display.RoleCheck, 0, len(roles))
+	worst := display.CheckOK
+	for _, role := range roles {
+		window := warn
+		if checkSigningPeriod && role.SigningPeriod > 0 {
+			window = role.SigningPeriod
+		}
+		check := display.RoleCheck{RoleMetadata: role, Window: window, Status: roleStatus(role.Expires, now, window, critical)}
+		checks = append(checks, check)
+		if checkSeverity(check.Status) > checkSeverity(worst) {
+			worst = check.Status
+		}
+	}
+
+	if checkJSON {
+		result := checkJSONResult{Repository: metadataURL, Status: worst, Roles: []roleCheckJSON{}}
+		for _, check := range checks {
+			result.Roles = append(result.Roles, roleCheckJSON{
+				Name:        check.Name,
+				Parent:      check.Parent,
+				Version:     check.Version,
+				Expires:     check.Expires,
+				Status:      check.Status,
+				WarnSeconds: int64(check.Window.Seconds()),
+			})
+		}
+		if err := display.ShowJSON(result); err != nil {
+			return err
+		}
+	} else {
+		display.ShowRoleChecks(metadataURL, checks)
+	}
+
+	// The summary already explains a non-zero exit
+	if code := checkSeverity(worst); code != 0 {
+		return &ExitError{Code: code}
+	}
+	return nil
+}
+
+// checkFailure reports a repository that could not be checked, with an exit
+// code that tells expired metadata and failed verification from other errors
+func checkFailure(metadataURL string, err error) error {
+	code, status := 1, "error"
+	switch {
+	case errors.Is(err, &metadata.ErrExpiredMetadata{}):
+		code, status = checkExitExpired, display.CheckExpired
+	case errors.Is(err, &metadata.ErrRepository{}):
+		code, status = checkExitVerification, "verification_failure"
+	}
+
+	// The JSON result carries the error instead of stderr
+	if checkJSON {
+		if jsonErr := display.ShowJSON(checkJSONResult{Repository: metadataURL, Status: status, Error: err.Error(), Roles: []roleCheckJSON{}}); jsonErr != nil {
+			return jsonErr
+		}
+		return &ExitError{Code: code}
+	}
+	return &ExitError{Code: code, Err: err}
+}
+
+// roleStatus returns a role's status given its warning and critical windows
+func roleStatus(expires, now time.Time, warn, critical time.Duration) string {
+	remaining := expires.Sub(now)
+	switch {
+	case remaining <= 0:
+		return display.CheckExpired
+	case remaining <= critical:
+		return display.CheckCritical
+	case remaining <= warn:
+		return display.CheckWarning
+	default:
+		return display.CheckOK
+	}
+}
+
+// checkSeverity returns the exit code for a role status
+func checkSeverity(status string) int {
+	switch status {
+	case display.CheckWarning:
+		return checkExitWarning
+	case display.CheckCritical:
+		return checkExitCritical
+	case display.CheckExpired:
+		return checkExitExpired
+	default:
+		return 0
+	}
+}
+
+// parseWindow parses a duration such as 36h or 90m, also accepting whole or
+// fractional days such as 7d or 1.5d
+func parseWindow(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if days, found := strings.CutSuffix(s, "d"); found {
+		value, err := strconv.ParseFloat(days, 64)
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("expected a duration such as 7d or 36h")
+		}
+		return time.Duration(value * float64(24*time.Hour)), nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("expected a duration such as 7d or 36h")
+	}
+	return d, nil
+}
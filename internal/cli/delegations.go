@@ -25,14 +25,16 @@ func runDelegations(cmd *cobra.Command, args []string) error {
 	// Create TUF client with options
 	options := client.ClientOptions{
 		TargetsURL: targetsURL,
+		Limiter:    limiter,
+		Logger:     logger,
 	}
-	tufClient, err := client.NewClientWithOptions(metadataURL, options)
+	tufClient, err := client.NewClientWithOptionsContext(cmd.Context(), metadataURL, options)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 
 	// Update metadata
-	if err := tufClient.Update(); err != nil {
+	if err := tufClient.UpdateContext(cmd.Context()); err != nil {
 		return fmt.Errorf("failed to update metadata: %w", err)
 	}
 
@@ -0,0 +1,200 @@
+package cli
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/kipz/tufzy/internal/client"
+	"github.com/kipz/tufzy/internal/display"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffGit      string
+	diffJSON     bool
+	diffExitCode bool
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [old-metadata-url] [new-metadata-url]",
+	Short: "Show what changed between two repository states",
+	Long: `Verify two states of a repository and compare their roles, versions, keys,
+delegations and targets. Modified targets are shown with their old and new hashes.
+
+The states can come from any supported source, such as two URLs or two directories.
+With --git, the arguments are two git refs of a local checkout instead, so you can
+review what a tuf-on-ci signing event changed. Both states must verify, so their
+metadata must not have expired.
+
+Examples:
+  tufzy diff ./old/metadata ./new/metadata
+  tufzy diff https://example.github.io/repo/metadata ./metadata
+  tufzy diff --git ./metadata HEAD~1 HEAD
+  tufzy diff --git ./metadata main sign/targets --json`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDiff,
+}
+
+func init() {
+	diffCmd.Flags().StringVar(&diffGit, "git", "", "Compare two git refs of the metadata directory in this local checkout")
+	diffCmd.Flags().BoolVar(&diffJSON, "json", false, "Print the differences as JSON")
+	diffCmd.Flags().BoolVar(&diffExitCode, "exit-code", false, "Exit with status 1 when the states differ, like git diff")
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	before, err := repositoryState(cmd.Context(), args[0])
+	if err != nil {
+		return err
+	}
+	after, err := repositoryState(cmd.Context(), args[1])
+	if err != nil {
+		return err
+	}
+	diff := client.Diff(before, after)
+
+	if diffJSON {
+		if err := display.ShowJSON(diff); err != nil {
+			return err
+		}
+	} else {
+		display.ShowDiff(args[0], args[1], diff)
+	}
+
+	if diffExitCode && !diff.Empty() {
+		return &ExitError{Code: 1}
+	}
+	return nil
+}
+
+// repositoryState verifies a repository and returns its state. With --git,
+// source is a git ref of the checkout instead of a metadata URL.
+func repositoryState(ctx context.Context, source string) (*client.RepositoryState, error) {
+	metadataURL := source
+	options := client.ClientOptions{
+		TargetsURL: targetsURL,
+		Limiter:    limiter,
+		Logger:     logger,
+	}
+
+	if diffGit != "" {
+		dir, err := os.MkdirTemp("", "tufzy-diff-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+		}
+		defer os.RemoveAll(dir)
+
+		metadataURL, err = exportGitRef(ctx, diffGit, source, filepath.Join(dir, "tree"))
+		if err != nil {
+			return nil, err
+		}
+		// Trust in each exported ref starts from its own root
+		options.CacheDir = filepath.Join(dir, "cache")
+	}
+
+	tufClient, err := client.NewClientWithOptionsContext(ctx, metadataURL, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client for %s: %w", source, err)
+	}
+	if err := tufClient.UpdateContext(ctx); err != nil {
+		return nil, fmt.Errorf("failed to update metadata for %s: %w", source, err)
+	}
+	state, err := tufClient.GetStateContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read repository state for %s: %w", source, err)
+	}
+	return state, nil
+}
+
+// exportGitRef writes the tree of ref in the git checkout containing metadataDir
+// to dest and returns the path of metadataDir within it
+func exportGitRef(ctx context.Context, metadataDir, ref, dest string) (string, error) {
+	// Step 1: find the checkout and the metadata directory's place in it
+	output, err := exec.CommandContext(ctx, "git", "-C", metadataDir, "rev-parse", "--show-toplevel", "--show-prefix").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find git checkout of %s: %w", metadataDir, gitError(err))
+	}
+	lines := strings.Split(strings.TrimSuffix(string(output), "\n"), "\n")
+	topLevel, prefix := lines[0], ""
+	if len(lines) > 1 {
+		prefix = lines[1]
+	}
+
+	// Step 2: extract the whole tree, so ../targets resolves like in the checkout
+	archive := exec.CommandContext(ctx, "git", "-C", topLevel, "archive", "--format=tar", ref)
+	stdout, err := archive.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	var stderr strings.Builder
+	archive.Stderr = &stderr
+	if err := archive.Start(); err != nil {
+		return "", fmt.Errorf("failed to run git archive: %w", err)
+	}
+	extractErr := extractTar(stdout, dest)
+	// Drain the archive so git can exit if extraction stopped early
+	_, _ = io.Copy(io.Discard, stdout)
+	if err := archive.Wait(); err != nil {
+		return "", fmt.Errorf("failed to export %s: %s", ref, strings.TrimSpace(stderr.String()))
+	}
+	if extractErr != nil {
+		return "", fmt.Errorf("failed to extract %s: %w", ref, extractErr)
+	}
+	return filepath.Join(dest, filepath.FromSlash(prefix)), nil
+}
+
+// extractTar writes the directories and regular files of a tar stream under dest
+func extractTar(r io.Reader, dest string) error {
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !filepath.IsLocal(header.Name) {
+			return fmt.Errorf("unsafe path in archive: %s", header.Name)
+		}
+		path := filepath.Join(dest, filepath.FromSlash(header.Name))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(file, reader)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+		}
+		// Metadata is never a symlink, so links are skipped
+	}
+}
+
+// gitError adds git's stderr to a failed command's error
+func gitError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return errors.New(strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
@@ -0,0 +1,259 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kipz/tufzy/internal/client"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+)
+
+var (
+	exporterListen   string
+	exporterInterval time.Duration
+)
+
+var exporterCmd = &cobra.Command{
+	Use:   "exporter [metadata-url]...",
+	Short: "Export repository health as Prometheus metrics",
+	Long: `Periodically refresh one or more TUF repositories and expose their health as
+Prometheus metrics on /metrics, so alerts can fire before metadata expires.
+
+Each repository is labelled with its metadata URL as given. Metrics:
+  tufzy_metadata_version{repository,role}                     Trusted version of each top-level role
+  tufzy_metadata_expiry_timestamp_seconds{repository,role}    When each role expires
+  tufzy_metadata_expires_in_seconds{repository,role}          Seconds until each role expires (negative once expired)
+  tufzy_refresh_success{repository}                           1 if the last refresh succeeded, 0 otherwise
+  tufzy_last_successful_refresh_timestamp_seconds{repository} When the repository last refreshed successfully
+  tufzy_refresh_failures_total{repository}                    Refreshes that failed for any reason
+  tufzy_verification_failures_total{repository}               Refreshes rejected by TUF verification
+
+Role metrics keep the values of the last successful refresh when a refresh fails.
+
+Examples:
+  tufzy exporter https://example.github.io/repo/metadata
+  tufzy exporter --listen :9100 --interval 5m https://a.example.com/metadata https://b.example.com/metadata`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runExporter,
+}
+
+func init() {
+	exporterCmd.Flags().StringVar(&exporterListen, "listen", ":9100", "Address to serve /metrics on")
+	exporterCmd.Flags().DurationVar(&exporterInterval, "interval", 5*time.Minute, "How often to refresh each repository")
+}
+
+func runExporter(cmd *cobra.Command, args []string) error {
+	if exporterInterval <= 0 {
+		return fmt.Errorf("invalid --interval: must be positive")
+	}
+	// Each repository gets one refresh loop, however often it is given
+	var metadataURLs []string
+	for _, metadataURL := range args {
+		if !slices.Contains(metadataURLs, metadataURL) {
+			metadataURLs = append(metadataURLs, metadataURL)
+		}
+	}
+	if targetsURL != "" && len(metadataURLs) > 1 {
+		return fmt.Errorf("--targets-url can only be used with a single repository")
+	}
+
+	health := newHealthCollector(metadataURLs)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(health, collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	server := &http.Server{Addr: exporterListen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx := cmd.Context()
+	var wg sync.WaitGroup
+	for _, metadataURL := range metadataURLs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			health.run(ctx, metadataURL, exporterInterval)
+		}()
+	}
+
+	// Stop serving once interrupted, after the refreshes have stopped
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.ListenAndServe() }()
+	fmt.Fprintf(cmd.ErrOrStderr(), "📈 Exporting %d repositories on %s/metrics\n", len(metadataURLs), exporterListen)
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("failed to serve metrics: %w", err)
+	case <-ctx.Done():
+	}
+	wg.Wait()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
+
+// roleHealth is the trusted version and expiry of one role
+type roleHealth struct {
+	role    string
+	version int64
+	expires time.Time
+}
+
+// repositoryHealth is the refresh state of one repository
+type repositoryHealth struct {
+	roles                []roleHealth // From the last successful refresh
+	lastSuccess          time.Time
+	succeeded            bool
+	refreshFailures      int
+	verificationFailures int
+}
+
+// healthCollector refreshes repositories and exposes their health as Prometheus metrics
+type healthCollector struct {
+	mu    sync.Mutex
+	repos map[string]*repositoryHealth
+}
+
+var (
+	versionDesc = prometheus.NewDesc("tufzy_metadata_version",
+		"Trusted version of a top-level role", []string{"repository", "role"}, nil)
+	expiryDesc = prometheus.NewDesc("tufzy_metadata_expiry_timestamp_seconds",
+		"Unix time a top-level role expires", []string{"repository", "role"}, nil)
+	expiresInDesc = prometheus.NewDesc("tufzy_metadata_expires_in_seconds",
+		"Seconds until a top-level role expires, negative once it has expired", []string{"repository", "role"}, nil)
+	successDesc = prometheus.NewDesc("tufzy_refresh_success",
+		"Whether the last refresh of the repository succeeded", []string{"repository"}, nil)
+	lastSuccessDesc = prometheus.NewDesc("tufzy_last_successful_refresh_timestamp_seconds",
+		"Unix time of the last successful refresh", []string{"repository"}, nil)
+	refreshFailuresDesc = prometheus.NewDesc("tufzy_refresh_failures_total",
+		"Refreshes that failed for any reason", []string{"repository"}, nil)
+	verificationFailuresDesc = prometheus.NewDesc("tufzy_verification_failures_total",
+		"Refreshes rejected by TUF verification, e.g. expired metadata or bad signatures", []string{"repository"}, nil)
+)
+
+// newHealthCollector creates a collector for the given metadata URLs
+func newHealthCollector(metadataURLs []string) *healthCollector {
+	h := &healthCollector{repos: map[string]*repositoryHealth{}}
+	for _, metadataURL := range metadataURLs {
+		h.repos[metadataURL] = &repositoryHealth{}
+	}
+	return h
+}
+
+// run refreshes a repository every interval until ctx is done
+func (h *healthCollector) run(ctx context.Context, metadataURL string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// A refresh must not overlap the next one
+		refreshCtx, cancel := context.WithTimeout(ctx, interval)
+		h.refresh(refreshCtx, metadataURL)
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh updates a repository's metadata and records the outcome
+func (h *healthCollector) refresh(ctx context.Context, metadataURL string) {
+	roles, err := fetchRoleHealth(ctx, metadataURL)
+	// Interruptions are not the repository's fault
+	if err != nil && ctx.Err() != nil && errors.Is(err, context.Canceled) {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	repo := h.repos[metadataURL]
+	repo.succeeded = err == nil
+	if err != nil {
+		repo.refreshFailures++
+		if errors.Is(err, &metadata.ErrRepository{}) {
+			repo.verificationFailures++
+		}
+		logger.WarnContext(ctx, "repository refresh failed", "repository", metadataURL, "error", err)
+		return
+	}
+	repo.roles = roles
+	repo.lastSuccess = time.Now()
+	logger.InfoContext(ctx, "refreshed repository", "repository", metadataURL)
+}
+
+// fetchRoleHealth refreshes a repository with a new client, since an updater
+// only refreshes once. The trusted metadata is reused from the cache.
+func fetchRoleHealth(ctx context.Context, metadataURL string) ([]roleHealth, error) {
+	options := client.ClientOptions{
+		TargetsURL: targetsURL,
+		Limiter:    limiter,
+		Logger:     logger,
+	}
+	tufClient, err := client.NewClientWithOptionsContext(ctx, metadataURL, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	if err := tufClient.UpdateContext(ctx); err != nil {
+		return nil, fmt.Errorf("failed to update metadata: %w", err)
+	}
+
+	info, err := tufClient.GetRepositoryInfo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository info: %w", err)
+	}
+	return []roleHealth{
+		{role: metadata.ROOT, version: info.RootVersion, expires: info.RootExpires},
+		{role: metadata.TIMESTAMP, version: info.TimestampVersion, expires: info.TimestampExpires},
+		{role: metadata.SNAPSHOT, version: info.SnapshotVersion, expires: info.SnapshotExpires},
+		{role: metadata.TARGETS, version: info.TargetsVersion, expires: info.TargetsExpires},
+	}, nil
+}
+
+// Describe implements prometheus.Collector
+func (h *healthCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{versionDesc, expiryDesc, expiresInDesc, successDesc,
+		lastSuccessDesc, refreshFailuresDesc, verificationFailuresDesc} {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector. Time to expiry is computed at
+// scrape time so it keeps counting down between refreshes.
+func (h *healthCollector) Collect(ch chan<- prometheus.Metric) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	for metadataURL, repo := range h.repos {
+		for _, role := range repo.roles {
+			ch <- prometheus.MustNewConstMetric(versionDesc, prometheus.GaugeValue, float64(role.version), metadataURL, role.role)
+			ch <- prometheus.MustNewConstMetric(expiryDesc, prometheus.GaugeValue, float64(role.expires.Unix()), metadataURL, role.role)
+			ch <- prometheus.MustNewConstMetric(expiresInDesc, prometheus.GaugeValue, role.expires.Sub(now).Seconds(), metadataURL, role.role)
+		}
+
+		success := 0.0
+		if repo.succeeded {
+			success = 1
+		}
+		ch <- prometheus.MustNewConstMetric(successDesc, prometheus.GaugeValue, success, metadataURL)
+		// There is no sensible value before the first successful refresh
+		if !repo.lastSuccess.IsZero() {
+			ch <- prometheus.MustNewConstMetric(lastSuccessDesc, prometheus.GaugeValue, float64(repo.lastSuccess.Unix()), metadataURL)
+		}
+		ch <- prometheus.MustNewConstMetric(refreshFailuresDesc, prometheus.CounterValue, float64(repo.refreshFailures), metadataURL)
+		ch <- prometheus.MustNewConstMetric(verificationFailuresDesc, prometheus.CounterValue, float64(repo.verificationFailures), metadataURL)
+	}
+}
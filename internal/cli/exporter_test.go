@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kipz/tufzy/internal/testrepo"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+)
+
+// metricValue returns the value of the named metric with the given labels
+func metricValue(t *testing.T, collector prometheus.Collector, name string, labels map[string]string) float64 {
+	t.Helper()
+
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(collector))
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	metrics:
+		for _, m := range family.GetMetric() {
+			for _, pair := range m.GetLabel() {
+				if want, ok := labels[pair.GetName()]; ok && want != pair.GetValue() {
+					continue metrics
+				}
+			}
+			if m.GetCounter() != nil {
+				return m.GetCounter().GetValue()
+			}
+			return m.GetGauge().GetValue()
+		}
+	}
+	t.Fatalf("no %s metric with labels %v", name, labels)
+	return 0
+}
+
+func TestHealthCollector(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	previous := logger
+	logger = slog.New(slog.DiscardHandler)
+	t.Cleanup(func() { logger = previous })
+
+	// The timestamp expires shortly after the first refresh
+	timestampExpires := time.Now().Add(2 * time.Second)
+	repo := testrepo.New(t, t.TempDir())
+	repo.Timestamp.Signed.Expires = timestampExpires.UTC().Truncate(time.Second)
+	repo.Publish()
+	metadataURL := filepath.Join(repo.Dir, "metadata")
+	missing := filepath.Join(t.TempDir(), "metadata")
+	health := newHealthCollector([]string{metadataURL, missing})
+	ctx := context.Background()
+
+	// Failures count from zero
+	expected := fmt.Sprintf(`
+# HELP tufzy_refresh_failures_total Refreshes that failed for any reason
+# TYPE tufzy_refresh_failures_total counter
+tufzy_refresh_failures_total{repository=%[1]q} 0
+tufzy_refresh_failures_total{repository=%[2]q} 0
+# HELP tufzy_verification_failures_total Refreshes rejected by TUF verification, e.g. expired metadata or bad signatures
+# TYPE tufzy_verification_failures_total counter
+tufzy_verification_failures_total{repository=%[1]q} 0
+tufzy_verification_failures_total{repository=%[2]q} 0
+`, metadataURL, missing)
+	assert.NoError(t, testutil.CollectAndCompare(health, strings.NewReader(expected),
+		"tufzy_refresh_failures_total", "tufzy_verification_failures_total"))
+
+	// There is no last successful refresh before the first success
+	assert.Equal(t, 0, testutil.CollectAndCount(health, "tufzy_last_successful_refresh_timestamp_seconds"))
+	assert.Equal(t, 0, testutil.CollectAndCount(health, "tufzy_metadata_version"))
+
+	// A good refresh exports every top-level role
+	health.refresh(ctx, metadataURL)
+	health.refresh(ctx, missing)
+	labels := map[string]string{"repository": metadataURL}
+	success := metricValue(t, health, "tufzy_refresh_success", labels)
+	assert.Equal(t, 1.0, success)
+	assert.Equal(t, 1, testutil.CollectAndCount(health, "tufzy_last_successful_refresh_timestamp_seconds"))
+	assert.Equal(t, 4, testutil.CollectAndCount(health, "tufzy_metadata_version"))
+	expiresIn := metricValue(t, health, "tufzy_metadata_expires_in_seconds", map[string]string{"repository": metadataURL, "role": metadata.TIMESTAMP})
+	assert.Positive(t, expiresIn)
+
+	// A missing repository fails without being a verification failure
+	failures := metricValue(t, health, "tufzy_refresh_failures_total", map[string]string{"repository": missing})
+	assert.Equal(t, 1.0, failures)
+	verificationFailures := metricValue(t, health, "tufzy_verification_failures_total", map[string]string{"repository": missing})
+	assert.Zero(t, verificationFailures)
+
+	// Once the timestamp has expired, a refresh is rejected by verification
+	time.Sleep(time.Until(timestampExpires.Truncate(time.Second).Add(time.Second)))
+	health.refresh(ctx, metadataURL)
+	success = metricValue(t, health, "tufzy_refresh_success", labels)
+	assert.Zero(t, success)
+	failures = metricValue(t, health, "tufzy_refresh_failures_total", labels)
+	assert.Equal(t, 1.0, failures)
+	verificationFailures = metricValue(t, health, "tufzy_verification_failures_total", labels)
+	assert.Equal(t, 1.0, verificationFailures)
+
+	// Role metrics are kept from the good refresh, with the time to expiry
+	// computed at scrape time
+	assert.Equal(t, 1, testutil.CollectAndCount(health, "tufzy_last_successful_refresh_timestamp_seconds"))
+	assert.Equal(t, 4, testutil.CollectAndCount(health, "tufzy_metadata_version"))
+	version := metricValue(t, health, "tufzy_metadata_version", map[string]string{"repository": metadataURL, "role": metadata.TIMESTAMP})
+	assert.Equal(t, 1.0, version)
+	expiresIn = metricValue(t, health, "tufzy_metadata_expires_in_seconds", map[string]string{"repository": metadataURL, "role": metadata.TIMESTAMP})
+	assert.Negative(t, expiresIn)
+}
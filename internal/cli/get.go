@@ -9,7 +9,10 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var outputPath string
+var (
+	outputPath string
+	getJSON    bool
+)
 
 var getCmd = &cobra.Command{
 	Use:   "get [metadata-url] [target-file]",
@@ -18,13 +21,18 @@ var getCmd = &cobra.Command{
 
 Example:
   tufzy get https://example.github.io/repo/metadata myfile.txt
-  tufzy get https://example.github.io/repo/metadata myfile.txt -o /path/to/output`,
+  tufzy get https://example.github.io/repo/metadata myfile.txt -o /path/to/output
+  tufzy get https://example.github.io/repo/metadata myfile.txt --json
+
+A progress bar is shown while downloading when stdout is a terminal.
+Interrupted downloads are resumed the next time the same target is fetched.`,
 	Args: cobra.ExactArgs(2),
 	RunE: runGet,
 }
 
 func init() {
 	getCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Output path (default: current directory)")
+	getCmd.Flags().BoolVar(&getJSON, "json", false, "Print the downloaded target as JSON")
 }
 
 func runGet(cmd *cobra.Command, args []string) error {
@@ -40,26 +48,52 @@ func runGet(cmd *cobra.Command, args []string) error {
 	// Create TUF client with options
 	options := client.ClientOptions{
 		TargetsURL: targetsURL,
+		Limiter:    limiter,
+		Logger:     logger,
 	}
-	tufClient, err := client.NewClientWithOptions(metadataURL, options)
+
+	// Only draw progress for humans watching a terminal
+	var progress *display.ProgressBar
+	if !getJSON && display.ProgressEnabled() {
+		progress = display.NewProgressBar()
+		options.Progress = progress.Update
+	}
+
+	tufClient, err := client.NewClientWithOptionsContext(cmd.Context(), metadataURL, options)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 
 	// Update metadata
-	if err := tufClient.Update(); err != nil {
+	if err := tufClient.UpdateContext(cmd.Context()); err != nil {
 		return fmt.Errorf("failed to update metadata: %w", err)
 	}
 
 	// Download target
-	display.ShowDownloadStart(targetName, destPath)
+	if !getJSON {
+		display.ShowDownloadStart(targetName, destPath)
+	}
 
-	targetInfo, err := tufClient.DownloadTarget(targetName, destPath)
+	targetInfo, err := tufClient.DownloadTargetContext(cmd.Context(), targetName, destPath)
+	if progress != nil {
+		progress.Finish()
+	}
 	if err != nil {
-		display.ShowDownloadError(targetName, err)
+		// Interruptions are reported once, by main
+		if !getJSON && cmd.Context().Err() == nil {
+			display.ShowDownloadError(targetName, err)
+		}
 		return err
 	}
 
+	if getJSON {
+		return display.ShowJSON(struct {
+			*client.TargetInfo
+			Name string `json:"name"`
+			Path string `json:"path"`
+		}{targetInfo, targetName, destPath})
+	}
+
 	display.ShowDownloadSuccess(targetName, destPath, targetInfo)
 
 	return nil
@@ -14,26 +14,39 @@ var infoCmd = &cobra.Command{
 	Long: `Display detailed information about the TUF repository including metadata versions,
 expiry dates, and role information.
 
-Example:
-  tufzy info https://example.github.io/repo/metadata`,
+With --signatures, every role including delegated roles lists its authorised keys,
+whether each key has a valid signature, and whether the threshold is met with any
+margin, so key holders know when a role is one signer away from breaking.
+
+Examples:
+  tufzy info https://example.github.io/repo/metadata
+  tufzy info --signatures https://example.github.io/repo/metadata`,
 	Args: cobra.ExactArgs(1),
 	RunE: runInfo,
 }
 
+var infoSignatures bool
+
+func init() {
+	infoCmd.Flags().BoolVar(&infoSignatures, "signatures", false, "List each role's authorised keys, which of them signed and the threshold margin")
+}
+
 func runInfo(cmd *cobra.Command, args []string) error {
 	metadataURL := args[0]
 
 	// Create TUF client with options
 	options := client.ClientOptions{
 		TargetsURL: targetsURL,
+		Limiter:    limiter,
+		Logger:     logger,
 	}
-	tufClient, err := client.NewClientWithOptions(metadataURL, options)
+	tufClient, err := client.NewClientWithOptionsContext(cmd.Context(), metadataURL, options)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 
 	// Update metadata
-	if err := tufClient.Update(); err != nil {
+	if err := tufClient.UpdateContext(cmd.Context()); err != nil {
 		return fmt.Errorf("failed to update metadata: %w", err)
 	}
 
@@ -46,5 +59,13 @@ func runInfo(cmd *cobra.Command, args []string) error {
 	// Display repository information
 	display.ShowRepositoryInfo(repoInfo)
 
+	if infoSignatures {
+		report, err := tufClient.GetSignaturesContext(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to check signatures: %w", err)
+		}
+		display.ShowSignatures(report)
+	}
+
 	return nil
 }
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kipz/tufzy/internal/client"
+	"github.com/kipz/tufzy/internal/display"
+	"github.com/spf13/cobra"
+)
+
+var (
+	keysOwner string
+	keysRole  string
+	keysJSON  bool
+)
+
+var keysCmd = &cobra.Command{
+	Use:   "keys [metadata-url]",
+	Short: "List every key and the roles that trust it",
+	Long: `List every key defined by root and by delegating roles, with the roles that
+trust it, its type and scheme, the SHA-256 fingerprint of its public key, and its
+tuf-on-ci owner (x-tuf-on-ci-keyowner) or online key URI.
+
+Filter by owner to answer "what can this person sign?", or by role to see who can sign it.
+
+Examples:
+  tufzy keys https://example.github.io/repo/metadata
+  tufzy keys --owner @user https://example.github.io/repo/metadata
+  tufzy keys --role targets --json https://example.github.io/repo/metadata`,
+	Args: cobra.ExactArgs(1),
+	RunE: runKeys,
+}
+
+func init() {
+	keysCmd.Flags().StringVar(&keysOwner, "owner", "", "Only list keys owned by this tuf-on-ci key owner, e.g. @user")
+	keysCmd.Flags().StringVar(&keysRole, "role", "", "Only list keys trusted by this role")
+	keysCmd.Flags().BoolVar(&keysJSON, "json", false, "Print the keys as JSON")
+}
+
+func runKeys(cmd *cobra.Command, args []string) error {
+	metadataURL := args[0]
+
+	// Create TUF client with options
+	options := client.ClientOptions{
+		TargetsURL: targetsURL,
+		Limiter:    limiter,
+		Logger:     logger,
+	}
+	tufClient, err := client.NewClientWithOptionsContext(cmd.Context(), metadataURL, options)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	// Update metadata
+	if err := tufClient.UpdateContext(cmd.Context()); err != nil {
+		return fmt.Errorf("failed to update metadata: %w", err)
+	}
+
+	keys, err := tufClient.GetKeysContext(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to get keys: %w", err)
+	}
+
+	// Owners are GitHub handles, so the @ and case are optional
+	owner := strings.ToLower(strings.TrimPrefix(keysOwner, "@"))
+	matching := []client.KeyInfo{}
+	for _, key := range keys {
+		if owner != "" && strings.ToLower(strings.TrimPrefix(key.Owner, "@")) != owner {
+			continue
+		}
+		if keysRole != "" && !slices.Contains(key.Roles, keysRole) {
+			continue
+		}
+		matching = append(matching, key)
+	}
+
+	if keysJSON {
+		return display.ShowJSON(matching)
+	}
+	display.ShowKeys(matching)
+	return nil
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/kipz/tufzy/internal/client"
+	"github.com/kipz/tufzy/internal/display"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lintMaxExpiry map[string]string
+	lintStrict    bool
+	lintJSON      bool
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [metadata-url]",
+	Short: "Check the repository for common TUF misconfigurations",
+	Long: `Inspect the trusted metadata, including every delegated role, for common problems:
+
+  threshold          thresholds higher than the number of keys, or undefined keys
+  shared-key         keys shared between top-level roles
+  unused-key         keys that no role uses
+  overlapping-paths  non-terminating delegations whose paths may overlap a later delegation
+  uncovered-target   targets a role lists outside its delegated paths
+  weak-key           RSA keys under 2048 bits, ECDSA keys under 256 bits and unsupported keys
+  expiry             roles that expire further in the future than the policy allows
+  unverifiable-role  delegated roles that fail verification
+
+The default expiry policy allows a year for root, targets, snapshot and delegated
+roles and a week for timestamp. Override it per role name, or with "delegated"
+for every delegated role; 0 disables the check for a role.
+
+Exits with status 1 when errors are found, or warnings with --strict.
+
+Examples:
+  tufzy lint https://example.github.io/repo/metadata
+  tufzy lint --max-expiry timestamp=2d,delegated=90d https://example.github.io/repo/metadata
+  tufzy lint --strict --json https://example.github.io/repo/metadata`,
+	Args: cobra.ExactArgs(1),
+	RunE: runLint,
+}
+
+func init() {
+	lintCmd.Flags().StringToStringVar(&lintMaxExpiry, "max-expiry", nil, "Longest a role may stay valid, as role=window, e.g. timestamp=2d,delegated=90d")
+	lintCmd.Flags().BoolVar(&lintStrict, "strict", false, "Exit with status 1 on warnings too")
+	lintCmd.Flags().BoolVar(&lintJSON, "json", false, "Print the findings as JSON")
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	metadataURL := args[0]
+
+	policy := client.DefaultLintPolicy()
+	for role, value := range lintMaxExpiry {
+		window, err := parseWindow(value)
+		if err != nil {
+			return fmt.Errorf("invalid --max-expiry for %s: %w", role, err)
+		}
+		policy.MaxExpiry[role] = window
+	}
+
+	// Create TUF client with options
+	options := client.ClientOptions{
+		TargetsURL: targetsURL,
+		Limiter:    limiter,
+		Logger:     logger,
+	}
+	tufClient, err := client.NewClientWithOptionsContext(cmd.Context(), metadataURL, options)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	// Update metadata
+	if err := tufClient.UpdateContext(cmd.Context()); err != nil {
+		return fmt.Errorf("failed to update metadata: %w", err)
+	}
+
+	findings, err := tufClient.LintContext(cmd.Context(), policy)
+	if err != nil {
+		return fmt.Errorf("failed to lint repository: %w", err)
+	}
+
+	if lintJSON {
+		if findings == nil {
+			findings = []client.LintFinding{}
+		}
+		if err := display.ShowJSON(findings); err != nil {
+			return err
+		}
+	} else {
+		display.ShowLintFindings(metadataURL, findings)
+	}
+
+	// The findings already explain a non-zero exit
+	for _, finding := range findings {
+		if finding.Severity == client.LintError || (lintStrict && finding.Severity == client.LintWarning) {
+			return &ExitError{Code: 1}
+		}
+	}
+	return nil
+}
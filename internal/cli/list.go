@@ -13,14 +13,15 @@ var listCmd = &cobra.Command{
 	Short: "List all available targets in the repository",
 	Long: `List all available target files in the TUF repository.
 
-The metadata-url can be an HTTP(S) URL, local filesystem path, or OCI registry.
+The metadata-url can be an HTTP(S) URL, local filesystem path, OCI registry or OCI image layout.
 Hash prefixes and tuf-on-ci git layout are auto-detected.
 
 Examples:
   tufzy list https://example.github.io/repo/metadata
   tufzy list /path/to/local/repo/metadata
   tufzy list ./metadata
-  tufzy list oci://registry.example.com/repo/metadata:latest --targets-url oci://registry.example.com/repo/targets:latest`,
+  tufzy list oci://registry.example.com/repo/metadata:latest --targets-url oci://registry.example.com/repo/targets:latest
+  tufzy list oci-layout:///path/to/layout:latest --targets-url oci-layout:///path/to/layout`,
 	Args: cobra.ExactArgs(1),
 	RunE: runList,
 }
@@ -31,19 +32,21 @@ func runList(cmd *cobra.Command, args []string) error {
 	// Create TUF client with options
 	options := client.ClientOptions{
 		TargetsURL: targetsURL,
+		Limiter:    limiter,
+		Logger:     logger,
 	}
-	tufClient, err := client.NewClientWithOptions(metadataURL, options)
+	tufClient, err := client.NewClientWithOptionsContext(cmd.Context(), metadataURL, options)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 
 	// Update metadata
-	if err := tufClient.Update(); err != nil {
+	if err := tufClient.UpdateContext(cmd.Context()); err != nil {
 		return fmt.Errorf("failed to update metadata: %w", err)
 	}
 
 	// Get targets
-	targets, err := tufClient.GetTargets()
+	targets, err := tufClient.GetTargetsContext(cmd.Context())
 	if err != nil {
 		return fmt.Errorf("failed to get targets: %w", err)
 	}
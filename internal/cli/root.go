@@ -1,11 +1,34 @@
 package cli
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/kipz/tufzy/internal/client"
 	"github.com/spf13/cobra"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
 )
 
 var (
-	targetsURL string
+	targetsURL     string
+	maxConcurrency int
+	rateLimit      string
+	s3Endpoint     string
+	s3Region       string
+	s3PathStyle    bool
+	verbosity      int
+	logFormat      string
+
+	// limiter is shared by every client a command creates
+	limiter *client.Limiter
+	// logger writes diagnostics to stderr, and discards them unless -v is given
+	logger *slog.Logger
 )
 
 var rootCmd = &cobra.Command{
@@ -15,19 +38,147 @@ var rootCmd = &cobra.Command{
 It provides an easy-to-use interface for verifying and downloading files from TUF repositories,
 with colorful output and helpful emojis!
 
-Supports HTTP(S), local filesystem, and OCI registry sources.`,
+Supports HTTP(S), local filesystem, S3, and OCI registry sources.`,
+	// main reports errors, including interruptions, exactly once
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Arguments are valid by now, so later failures don't need the usage
+		cmd.SilenceUsage = true
+
+		bytesPerSecond, err := parseByteRate(rateLimit)
+		if err != nil {
+			return fmt.Errorf("invalid --rate-limit: %w", err)
+		}
+		if maxConcurrency < 0 {
+			return fmt.Errorf("invalid --max-concurrency: must not be negative")
+		}
+		limiter = client.NewLimiter(maxConcurrency, bytesPerSecond)
+
+		logger, err = newLogger(os.Stderr, verbosity, logFormat)
+		if err != nil {
+			return err
+		}
+		// go-tuf's own verification steps are only worth the noise at -vv
+		if verbosity >= 2 {
+			metadata.SetLogger(logr.FromSlogHandler(logger.With("component", "go-tuf").Handler()))
+		}
+
+		// Anything not set falls back to the standard AWS environment and config files
+		client.RegisterFetcher("s3", client.NewS3FetcherFactory(client.S3FetcherOptions{
+			Endpoint:     s3Endpoint,
+			Region:       s3Region,
+			UsePathStyle: s3PathStyle,
+			Logger:       logger,
+		}))
+		return nil
+	},
 }
 
 // Execute runs the root command
 func Execute() error {
-	return rootCmd.Execute()
+	return ExecuteContext(context.Background())
+}
+
+// ExecuteContext runs the root command, cancelling in-flight requests when ctx is done
+func ExecuteContext(ctx context.Context) error {
+	return rootCmd.ExecuteContext(ctx)
+}
+
+// ExitError is returned by commands that report their outcome with an exit code.
+// Err is printed like any other error unless it is nil.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVar(&targetsURL, "targets-url", "", "Targets repository URL (required for OCI registries)")
+	rootCmd.PersistentFlags().StringVar(&targetsURL, "targets-url", "", "Targets repository URL (required for OCI registries and layouts)")
+	rootCmd.PersistentFlags().IntVar(&maxConcurrency, "max-concurrency", 0, "Maximum concurrent requests (0 = unlimited)")
+	rootCmd.PersistentFlags().StringVar(&rateLimit, "rate-limit", "", "Maximum download rate in bytes/sec, e.g. 512K or 10M (default: unlimited)")
+	rootCmd.PersistentFlags().StringVar(&s3Endpoint, "s3-endpoint", "", "S3 endpoint for s3:// URLs, e.g. http://localhost:9000 (default: $AWS_ENDPOINT_URL_S3 or AWS)")
+	rootCmd.PersistentFlags().StringVar(&s3Region, "s3-region", "", "S3 signing region (default: from the AWS config, then us-east-1)")
+	rootCmd.PersistentFlags().BoolVar(&s3PathStyle, "s3-path-style", false, "Use path-style S3 addressing (endpoint/bucket/key), as MinIO usually needs")
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Log layout detection and verified roles to stderr (-vv adds every request and cache lookup)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "Log format for -v: text or json")
 
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(getCmd)
 	rootCmd.AddCommand(infoCmd)
 	rootCmd.AddCommand(delegationsCmd)
+	rootCmd.AddCommand(exporterCmd)
+	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(lintCmd)
+	rootCmd.AddCommand(keysCmd)
+	rootCmd.AddCommand(rootsCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(serveRepoCmd)
+}
+
+// newLogger returns a logger writing to w at the level chosen by the number
+// of -v flags: nothing, info, then debug
+func newLogger(w io.Writer, verbosity int, format string) (*slog.Logger, error) {
+	options := &slog.HandlerOptions{Level: slog.LevelInfo}
+	if verbosity >= 2 {
+		options.Level = slog.LevelDebug
+	}
+
+	var handler slog.Handler
+	switch format {
+	case "text":
+		handler = slog.NewTextHandler(w, options)
+	case "json":
+		handler = slog.NewJSONHandler(w, options)
+	default:
+		return nil, fmt.Errorf("invalid --log-format %q: expected text or json", format)
+	}
+	if verbosity <= 0 {
+		handler = slog.DiscardHandler
+	}
+	return slog.New(handler), nil
+}
+
+// parseByteRate parses a byte rate such as 1048576, 512K, 10M or 1G (binary units).
+// An empty string means unlimited.
+func parseByteRate(s string) (int64, error) {
+	s = strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(s)), "/S")
+	if s == "" {
+		return 0, nil
+	}
+
+	multiplier := int64(1)
+	s = strings.TrimSuffix(s, "B")
+	s = strings.TrimSuffix(s, "I")
+	if len(s) > 0 {
+		switch s[len(s)-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		}
+		if multiplier > 1 {
+			s = s[:len(s)-1]
+		}
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("expected a byte rate such as 512K or 10M")
+	}
+	return int64(value * float64(multiplier)), nil
 }
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/kipz/tufzy/internal/client"
+	"github.com/kipz/tufzy/internal/display"
+	"github.com/spf13/cobra"
+)
+
+var rootsJSON bool
+
+var rootsCmd = &cobra.Command{
+	Use:   "roots [metadata-url]",
+	Short: "Show the verified history of root",
+	Long: `Walk every version of root from 1.root.json to the current root, verifying each
+version against the one before it, and show what changed in each: keys added and
+removed per role, threshold changes, consistent_snapshot toggles and expiry.
+
+Use it to audit key ceremonies. The walk fails if any version is missing, is not
+signed by the previous root's threshold, or does not lead to the trusted root.
+
+Examples:
+  tufzy roots https://example.github.io/repo/metadata
+  tufzy roots --json https://example.github.io/repo/metadata`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRoots,
+}
+
+func init() {
+	rootsCmd.Flags().BoolVar(&rootsJSON, "json", false, "Print the root history as JSON")
+}
+
+func runRoots(cmd *cobra.Command, args []string) error {
+	metadataURL := args[0]
+
+	// Create TUF client with options
+	options := client.ClientOptions{
+		TargetsURL: targetsURL,
+		Limiter:    limiter,
+		Logger:     logger,
+	}
+	tufClient, err := client.NewClientWithOptionsContext(cmd.Context(), metadataURL, options)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	// Update metadata
+	if err := tufClient.UpdateContext(cmd.Context()); err != nil {
+		return fmt.Errorf("failed to update metadata: %w", err)
+	}
+
+	history, err := tufClient.GetRootHistoryContext(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to get root history: %w", err)
+	}
+
+	if rootsJSON {
+		return display.ShowJSON(history)
+	}
+	display.ShowRootHistory(history)
+	return nil
+}
@@ -0,0 +1,240 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/kipz/tufzy/internal/client"
+	"github.com/spf13/cobra"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+)
+
+var (
+	serveListen   string
+	serveInterval time.Duration
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve [metadata-url]",
+	Short: "Serve a repository's verified targets over plain HTTP",
+	Long: `Serve GET /targets/<name> for tools that speak HTTP but not TUF. Each request
+resolves the target through the trusted metadata, downloads and verifies it into
+the cache if needed, and returns it with its length and hashes:
+  X-Tuf-Length          Trusted length of the target
+  X-Tuf-Hash-<Alg>      Trusted hash in hex, e.g. X-Tuf-Hash-Sha256
+  ETag                  The sha256 hash, so clients can make conditional requests
+
+Unknown targets return 404. Targets that fail to download or verify, and all
+requests once the trusted metadata has expired, return 502.
+
+The metadata is refreshed every interval. A refresh that fails, for example on
+a bad signature or rollback, is logged and serving continues from the last
+trusted state until it expires.
+
+Examples:
+  tufzy serve https://example.github.io/repo/metadata
+  tufzy serve --listen 127.0.0.1:8080 --interval 1m https://example.github.io/repo/metadata
+  curl -fsS http://127.0.0.1:8080/targets/app/config.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runServe,
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveListen, "listen", "127.0.0.1:8080", "Address to serve targets on")
+	serveCmd.Flags().DurationVar(&serveInterval, "interval", 5*time.Minute, "How often to refresh the metadata")
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	metadataURL := args[0]
+	if serveInterval <= 0 {
+		return fmt.Errorf("invalid --interval: must be positive")
+	}
+
+	// The first update must succeed, later ones fall back to the last trusted state
+	ctx := cmd.Context()
+	repo, err := loadServedRepository(ctx, metadataURL)
+	if err != nil {
+		return err
+	}
+	server := &targetServer{}
+	server.repo.Store(repo)
+	httpServer := &http.Server{Addr: serveListen, Handler: server.routes(), ReadHeaderTimeout: 10 * time.Second}
+
+	refreshDone := make(chan struct{})
+	go func() {
+		defer close(refreshDone)
+		server.run(ctx, metadataURL, serveInterval)
+	}()
+
+	// Stop serving once interrupted, after the refreshes have stopped
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- httpServer.ListenAndServe() }()
+	fmt.Fprintf(cmd.ErrOrStderr(), "📦 Serving targets of %s on http://%s/targets/\n", metadataURL, serveListen)
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("failed to serve targets: %w", err)
+	case <-ctx.Done():
+	}
+	<-refreshDone
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return httpServer.Shutdown(shutdownCtx)
+}
+
+// servedRepository is a refreshed client and the expiry of its top-level metadata
+type servedRepository struct {
+	client   *client.Client
+	expiries []roleExpiry
+}
+
+// roleExpiry is when a top-level role's trusted metadata expires
+type roleExpiry struct {
+	role    string
+	expires time.Time
+}
+
+// loadServedRepository creates a client and updates its metadata. A new client
+// is used for every refresh, since an updater only refreshes once; the trusted
+// metadata is reused from the cache.
+func loadServedRepository(ctx context.Context, metadataURL string) (*servedRepository, error) {
+	options := client.ClientOptions{
+		TargetsURL: targetsURL,
+		Limiter:    limiter,
+		Logger:     logger,
+	}
+	tufClient, err := client.NewClientWithOptionsContext(ctx, metadataURL, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	if err := tufClient.UpdateContext(ctx); err != nil {
+		return nil, fmt.Errorf("failed to update metadata: %w", err)
+	}
+
+	// Read once, since a client's top-level metadata does not change after its update
+	info, err := tufClient.GetRepositoryInfo()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository info: %w", err)
+	}
+	return &servedRepository{client: tufClient, expiries: []roleExpiry{
+		{metadata.TIMESTAMP, info.TimestampExpires},
+		{metadata.SNAPSHOT, info.SnapshotExpires},
+		{metadata.TARGETS, info.TargetsExpires},
+		{metadata.ROOT, info.RootExpires},
+	}}, nil
+}
+
+// expiredRole returns the first top-level role whose trusted metadata has
+// expired, or "" if none has
+func (r *servedRepository) expiredRole(now time.Time) string {
+	for _, expiry := range r.expiries {
+		if now.After(expiry.expires) {
+			return expiry.role
+		}
+	}
+	return ""
+}
+
+// targetServer serves verified targets from the most recently refreshed repository
+type targetServer struct {
+	repo atomic.Pointer[servedRepository]
+}
+
+// routes serves GET and HEAD /targets/<name>
+func (s *targetServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /targets/{name...}", s)
+	return mux
+}
+
+// run refreshes the metadata every interval until ctx is done
+func (s *targetServer) run(ctx context.Context, metadataURL string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// A refresh must not overlap the next one
+		refreshCtx, cancel := context.WithTimeout(ctx, interval)
+		repo, err := loadServedRepository(refreshCtx, metadataURL)
+		cancel()
+		switch {
+		case err != nil && ctx.Err() != nil:
+			return
+		case err != nil:
+			logger.WarnContext(ctx, "metadata refresh failed, serving the last trusted state", "repository", metadataURL, "error", err)
+		default:
+			s.repo.Store(repo)
+			logger.InfoContext(ctx, "refreshed metadata", "repository", metadataURL)
+		}
+	}
+}
+
+func (s *targetServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := r.PathValue("name")
+	repo := s.repo.Load()
+
+	if role := repo.expiredRole(time.Now()); role != "" {
+		http.Error(w, fmt.Sprintf("trusted %s metadata has expired", role), http.StatusBadGateway)
+		return
+	}
+
+	info, file, err := repo.client.OpenTargetContext(ctx, name)
+	switch {
+	case errors.Is(err, client.ErrTargetNotFound):
+		http.Error(w, fmt.Sprintf("target %s not found", name), http.StatusNotFound)
+		return
+	case err != nil && ctx.Err() != nil:
+		// The client went away
+		return
+	case err != nil:
+		logger.WarnContext(ctx, "failed to serve target", "target", name, "error", err)
+		reason := "could not be downloaded"
+		if errors.Is(err, &metadata.ErrRepository{}) {
+			reason = "failed verification"
+		}
+		http.Error(w, fmt.Sprintf("target %s %s: %v", name, reason, err), http.StatusBadGateway)
+		return
+	}
+	defer func() { _ = file.Close() }()
+
+	header := w.Header()
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header.Set("Content-Type", contentType)
+	header.Set("X-Tuf-Length", fmt.Sprintf("%d", info.Length))
+	algs := make([]string, 0, len(info.Hashes))
+	for alg := range info.Hashes {
+		algs = append(algs, alg)
+	}
+	sort.Strings(algs)
+	for _, alg := range algs {
+		header.Set("X-Tuf-Hash-"+alg, info.Hashes[alg])
+	}
+	if len(algs) > 0 {
+		alg := algs[0]
+		if _, ok := info.Hashes["sha256"]; ok {
+			alg = "sha256"
+		}
+		header.Set("ETag", fmt.Sprintf("%q", alg+"-"+info.Hashes[alg]))
+	}
+
+	// Handles HEAD, ranges and conditional requests
+	http.ServeContent(w, r, name, time.Time{}, file)
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kipz/tufzy/internal/client"
+	"github.com/kipz/tufzy/internal/repository"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveRepoListen  string
+	serveRepoTUFOnCI bool
+)
+
+var serveRepoCmd = &cobra.Command{
+	Use:   "serve-repo [directory]",
+	Short: "Serve a local repository over HTTP for any TUF client",
+	Long: `Serve a repository directory with metadata/ and targets/ over HTTP, for local
+testing without an ad-hoc web server. Only regular files inside those two
+directories are served.
+
+A standard TUF layout is served as it is. A tuf-on-ci git checkout, detected by
+its unversioned snapshot.json and targets.json or forced with --tuf-on-ci, is
+translated on the fly into the standard layout TUF clients expect:
+N.root.json is served from root_history, N.snapshot.json, N.targets.json and
+delegated roles from the current files when they hold version N, and
+hash-prefixed targets from the unprefixed files when the hash matches.
+
+Examples:
+  tufzy serve-repo ./my-tuf-on-ci-repo
+  tufzy serve-repo --listen 127.0.0.1:9000 ./published-repo
+  tufzy list http://127.0.0.1:8000/metadata`,
+	Args: cobra.ExactArgs(1),
+	RunE: runServeRepo,
+}
+
+func init() {
+	serveRepoCmd.Flags().StringVar(&serveRepoListen, "listen", "127.0.0.1:8000", "Address to serve the repository on")
+	serveRepoCmd.Flags().BoolVar(&serveRepoTUFOnCI, "tuf-on-ci", false, "Translate a tuf-on-ci git layout even if it is not detected")
+}
+
+func runServeRepo(cmd *cobra.Command, args []string) error {
+	dir := args[0]
+	metadataDir := filepath.Join(dir, "metadata")
+	if info, err := os.Stat(metadataDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s is not a repository: no metadata directory", dir)
+	}
+
+	tufOnCI := serveRepoTUFOnCI || client.IsTufOnCiGit(metadataDir)
+	handler := repository.NewHandler(dir, repository.HandlerOptions{TUFOnCI: tufOnCI})
+	server := &http.Server{Addr: serveRepoListen, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+
+	// Stop serving once interrupted
+	ctx := cmd.Context()
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.ListenAndServe() }()
+	layout := "standard layout"
+	if tufOnCI {
+		layout = "tuf-on-ci git layout, translated"
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "🌐 Serving %s (%s) on http://%s/metadata\n", dir, layout, serveRepoListen)
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("failed to serve repository: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kipz/tufzy/internal/testrepo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveTargets serves the targets of the repository in dir like tufzy serve
+func serveTargets(t *testing.T, dir string) *httptest.Server {
+	t.Helper()
+
+	repo, err := loadServedRepository(context.Background(), filepath.Join(dir, "metadata"))
+	require.NoError(t, err)
+	server := &targetServer{}
+	server.repo.Store(repo)
+
+	httpServer := httptest.NewServer(server.routes())
+	t.Cleanup(httpServer.Close)
+	return httpServer
+}
+
+// request sends a request for a target and returns the response with its body read
+func request(t *testing.T, method, url string, header http.Header) (*http.Response, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestTargetServer(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	previous := logger
+	logger = slog.New(slog.DiscardHandler)
+	t.Cleanup(func() { logger = previous })
+
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddTarget("targets", "app/config.json", []byte(`{"debug":false}`))
+	repo.AddTarget("targets", "tampered.txt", []byte("original"))
+	repo.Publish()
+	server := serveTargets(t, repo.Dir)
+
+	t.Run("known target", func(t *testing.T) {
+		hash := repo.TargetHash("app/config.json")
+		resp, body := request(t, http.MethodGet, server.URL+"/targets/app/config.json", nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, `{"debug":false}`, body)
+		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		assert.Equal(t, "15", resp.Header.Get("Content-Length"))
+		assert.Equal(t, "15", resp.Header.Get("X-Tuf-Length"))
+		assert.Equal(t, hash, resp.Header.Get("X-Tuf-Hash-Sha256"))
+		assert.Equal(t, `"sha256-`+hash+`"`, resp.Header.Get("ETag"))
+
+		// HEAD returns the headers alone
+		resp, body = request(t, http.MethodHead, server.URL+"/targets/app/config.json", nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, body)
+		assert.Equal(t, "15", resp.Header.Get("Content-Length"))
+		assert.Equal(t, hash, resp.Header.Get("X-Tuf-Hash-Sha256"))
+
+		// A client holding the current version is told it has not changed
+		resp, _ = request(t, http.MethodGet, server.URL+"/targets/app/config.json", http.Header{"If-None-Match": {`"sha256-` + hash + `"`}})
+		assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	})
+
+	t.Run("unknown target", func(t *testing.T) {
+		resp, _ := request(t, http.MethodGet, server.URL+"/targets/missing.txt", nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("tampered target", func(t *testing.T) {
+		// Same length, different content
+		path := filepath.Join(repo.Dir, "targets", repo.TargetHash("tampered.txt")+".tampered.txt")
+		require.NoError(t, os.WriteFile(path, []byte("modified"), 0644))
+
+		resp, body := request(t, http.MethodGet, server.URL+"/targets/tampered.txt", nil)
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+		assert.Contains(t, body, "failed verification")
+	})
+
+	t.Run("expired metadata", func(t *testing.T) {
+		expiring := testrepo.New(t, t.TempDir())
+		expiring.AddTarget("targets", "app.txt", []byte("app"))
+		timestampExpires := time.Now().Add(2 * time.Second).UTC().Truncate(time.Second)
+		expiring.Timestamp.Signed.Expires = timestampExpires
+		expiring.Publish()
+		server := serveTargets(t, expiring.Dir)
+
+		resp, _ := request(t, http.MethodGet, server.URL+"/targets/app.txt", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// Once the timestamp expires, nothing is served from the stale state
+		time.Sleep(time.Until(timestampExpires.Add(time.Second)))
+		resp, body := request(t, http.MethodGet, server.URL+"/targets/app.txt", nil)
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+		assert.Contains(t, body, "trusted timestamp metadata has expired")
+	})
+}
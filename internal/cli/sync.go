@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kipz/tufzy/internal/client"
+	"github.com/kipz/tufzy/internal/display"
+	"github.com/spf13/cobra"
+)
+
+var (
+	syncPatterns []string
+	syncForce    bool
+	syncJSON     bool
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync [metadata-url] [directory]",
+	Short: "Keep a directory in sync with the repository's targets",
+	Long: `Make a directory contain exactly the verified targets matching --pattern, named
+by their target paths. New and changed targets are downloaded and verified before
+they are renamed into place, so files are never partially written, and files that
+are no longer targets are deleted.
+
+The synced snapshot version is recorded in ` + client.SyncStateFile + ` in the directory, so
+running sync again on an unchanged repository only checks the files still exist.
+Patterns match like shell globs where * does not match /.
+
+Examples:
+  tufzy sync https://example.github.io/repo/metadata ./policies --pattern 'policies/*'
+  tufzy sync --pattern 'bin/*' --pattern 'etc/*' https://example.github.io/repo/metadata /opt/app`,
+	Args: cobra.ExactArgs(2),
+	RunE: runSync,
+}
+
+func init() {
+	syncCmd.Flags().StringArrayVar(&syncPatterns, "pattern", nil, "Only sync targets matching this pattern (repeatable, default: all targets)")
+	syncCmd.Flags().BoolVar(&syncForce, "force", false, "Sync into a non-empty directory that was not synced from this repository, deleting its other files")
+	syncCmd.Flags().BoolVar(&syncJSON, "json", false, "Print the result as JSON")
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	metadataURL := args[0]
+	dir := args[1]
+
+	// Create TUF client with options
+	options := client.ClientOptions{
+		TargetsURL: targetsURL,
+		Limiter:    limiter,
+		Logger:     logger,
+	}
+	tufClient, err := client.NewClientWithOptionsContext(cmd.Context(), metadataURL, options)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	// Update metadata
+	if err := tufClient.UpdateContext(cmd.Context()); err != nil {
+		return fmt.Errorf("failed to update metadata: %w", err)
+	}
+
+	result, err := tufClient.SyncContext(cmd.Context(), dir, client.SyncOptions{Patterns: syncPatterns, Force: syncForce})
+	if errors.Is(err, client.ErrSyncDirInUse) {
+		return fmt.Errorf("%w (use --force to replace its contents)", err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sync: %w", err)
+	}
+
+	if syncJSON {
+		return display.ShowJSON(result)
+	}
+	display.ShowSyncResult(dir, result)
+	return nil
+}
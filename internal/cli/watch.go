@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/kipz/tufzy/internal/client"
+	"github.com/kipz/tufzy/internal/display"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchInterval time.Duration
+	watchExec     string
+	watchJSON     bool
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [metadata-url]",
+	Short: "Watch a repository and report changes as they are published",
+	Long: `Refresh the repository every interval and report each role whose metadata
+changed and each target added, removed or modified. Refreshes that fail, for
+example on a bad signature or rollback, are reported and watching continues from
+the last trusted state.
+
+With --exec, a shell command runs for every event with the event as JSON on its
+stdin and TUFZY_EVENT_TYPE, TUFZY_EVENT_NAME and TUFZY_EVENT_ROLE set. Event types:
+  role-added, role-removed, role-updated
+  target-added, target-removed, target-modified
+  refresh-failed
+
+Examples:
+  tufzy watch https://example.github.io/repo/metadata
+  tufzy watch --interval 30s --json https://example.github.io/repo/metadata >> events.jsonl
+  tufzy watch --exec './deploy.sh' https://example.github.io/repo/metadata`,
+	Args: cobra.ExactArgs(1),
+	RunE: runWatch,
+}
+
+func init() {
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", time.Minute, "How often to refresh the repository")
+	watchCmd.Flags().StringVar(&watchExec, "exec", "", "Shell command to run for each event, with the event as JSON on stdin")
+	watchCmd.Flags().BoolVar(&watchJSON, "json", false, "Print events as JSON lines")
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	metadataURL := args[0]
+	if watchInterval <= 0 {
+		return fmt.Errorf("invalid --interval: must be positive")
+	}
+
+	// Create TUF client with options
+	options := client.ClientOptions{
+		TargetsURL: targetsURL,
+		Limiter:    limiter,
+		Logger:     logger,
+	}
+	tufClient, err := client.NewClientWithOptionsContext(cmd.Context(), metadataURL, options)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx := cmd.Context()
+	encoder := json.NewEncoder(cmd.OutOrStdout())
+	fmt.Fprintf(cmd.ErrOrStderr(), "👀 Watching %s every %s\n", metadataURL, watchInterval)
+
+	err = tufClient.Watch(ctx, client.WatchOptions{Interval: watchInterval}, func(event client.WatchEvent) error {
+		if watchJSON {
+			if err := encoder.Encode(event); err != nil {
+				return err
+			}
+		} else {
+			display.ShowWatchEvent(event)
+		}
+
+		if watchExec != "" {
+			if err := runWatchHook(ctx, watchExec, event); err != nil && ctx.Err() == nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: hook failed for %s %s: %v\n", event.Type, event.Name, err)
+			}
+		}
+		return nil
+	})
+
+	// Interrupting is how watching normally ends
+	if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// runWatchHook runs the hook command for an event, passing the event as JSON on
+// stdin and its type, name and role in the environment
+func runWatchHook(ctx context.Context, command string, event client.WatchEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	hook := exec.CommandContext(ctx, "sh", "-c", command)
+	hook.Stdin = bytes.NewReader(data)
+	// Stdout is kept for events
+	hook.Stdout = os.Stderr
+	hook.Stderr = os.Stderr
+	hook.Env = append(os.Environ(),
+		"TUFZY_EVENT_TYPE="+string(event.Type),
+		"TUFZY_EVENT_NAME="+event.Name,
+		"TUFZY_EVENT_ROLE="+event.Role,
+	)
+	return hook.Run()
+}
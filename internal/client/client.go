@@ -5,36 +5,53 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
-	"net/url"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/theupdateframework/go-tuf/v2/metadata"
 	"github.com/theupdateframework/go-tuf/v2/metadata/config"
+	"github.com/theupdateframework/go-tuf/v2/metadata/fetcher"
 	"github.com/theupdateframework/go-tuf/v2/metadata/updater"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Client wraps the TUF updater with convenience methods
 type Client struct {
+	mu                 sync.Mutex // Serializes use of the updater and calls, and guards what refresh replaces
 	updater            *updater.Updater
+	calls              *callFetcher // The updater's fetcher, bound to the current call's context
 	metadataURL        string
 	targetsURL         string
 	cacheDir           string
 	tufOnCiGit         bool
 	consistentSnapshot bool
 	hashPrefixes       bool
+	targetsMaxLength   int64
+	registry           *RegistryFetcher // Set for OCI repositories
+	fetcher            fetcher.Fetcher
+	progress           ProgressFunc
+	logger             *slog.Logger
+	telemetry          *telemetry
+	sourceURL          string        // The URL the client was created with, for refresh
+	options            ClientOptions // The options the client was created with, for refresh
 }
 
 // TargetInfo contains information about a target file
 type TargetInfo struct {
-	Name        string
-	Length      int64
-	Hashes      map[string]string
-	Custom      *json.RawMessage
-	DelegatedBy string
+	Name        string            `json:"name"`
+	Length      int64             `json:"length"`
+	Hashes      map[string]string `json:"hashes"`
+	Custom      *json.RawMessage  `json:"custom,omitempty"`
+	DelegatedBy string            `json:"delegatedBy,omitempty"`
 }
 
 // RepositoryInfo contains metadata about the repository
@@ -52,20 +69,33 @@ type RepositoryInfo struct {
 	TufOnCiGit         bool
 	ConsistentSnapshot bool
 	HashPrefixes       bool
+	// ResolvedDigests maps each OCI tag reference fetched in this session to the manifest digest it resolved to
+	ResolvedDigests map[string]string
 }
 
 // Delegation represents a delegated role
 type Delegation struct {
-	Name      string
-	Threshold int
-	KeyIDs    []string
-	Paths     []string
-	Children  []Delegation
+	Name             string
+	Threshold        int
+	KeyIDs           []string
+	Paths            []string
+	PathHashPrefixes []string
+	Terminating      bool
+	Children         []Delegation
 }
 
+// ErrTargetNotFound is returned when no trusted role lists the requested target
+var ErrTargetNotFound = errors.New("target not found")
+
 // NewClient creates a new TUF client with default options
 func NewClient(metadataURL string) (*Client, error) {
-	return NewClientWithOptions(metadataURL, ClientOptions{})
+	return NewClientContext(context.Background(), metadataURL)
+}
+
+// NewClientContext creates a new TUF client with default options, cancelling
+// the initial root download when ctx is done
+func NewClientContext(ctx context.Context, metadataURL string) (*Client, error) {
+	return NewClientWithOptionsContext(ctx, metadataURL, ClientOptions{})
 }
 
 // ClientOptions contains optional configuration for the TUF client
@@ -76,59 +106,74 @@ type ClientOptions struct {
 	TufOnCiGit bool
 	// TargetsURL specifies the targets repository URL (required for OCI)
 	TargetsURL string
+	// Progress is called as target downloads make progress
+	Progress ProgressFunc
+	// Limiter bounds concurrent requests and bandwidth, and may be shared between clients
+	Limiter *Limiter
+	// CacheDir holds the trusted metadata and partial downloads (default: ~/.tufzy/cache/<url hash>)
+	CacheDir string
+	// Logger receives records of requests, cache use, layout detection and
+	// verified roles (default: discard)
+	Logger *slog.Logger
+	// TracerProvider records spans for updates, metadata fetches, downloads and
+	// HTTP requests (default: the global provider)
+	TracerProvider trace.TracerProvider
+	// MeterProvider records downloaded bytes, cache hits and verification
+	// failures (default: the global provider)
+	MeterProvider metric.MeterProvider
 }
 
 // NewClientWithOptions creates a new TUF client with custom options
 func NewClientWithOptions(metadataURL string, options ClientOptions) (*Client, error) {
-	// Determine cache directory (unique per repository URL)
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get home directory: %w", err)
-	}
+	return NewClientWithOptionsContext(context.Background(), metadataURL, options)
+}
 
-	// Create unique cache directory based on metadata URL hash
-	urlHash := sha256.Sum256([]byte(metadataURL))
-	cacheID := hex.EncodeToString(urlHash[:8]) // Use first 8 bytes for shorter path
-	cacheDir := filepath.Join(homeDir, ".tufzy", "cache", cacheID)
+// NewClientWithOptionsContext creates a new TUF client with custom options,
+// cancelling the initial root download when ctx is done
+func NewClientWithOptionsContext(ctx context.Context, metadataURL string, options ClientOptions) (*Client, error) {
+	cacheDir := options.CacheDir
+	if cacheDir == "" {
+		// Determine cache directory (unique per repository URL)
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+
+		// Create unique cache directory based on metadata URL hash
+		urlHash := sha256.Sum256([]byte(metadataURL))
+		cacheID := hex.EncodeToString(urlHash[:8]) // Use first 8 bytes for shorter path
+		cacheDir = filepath.Join(homeDir, ".tufzy", "cache", cacheID)
+	}
 
 	// Create cache directory
 	if err := os.MkdirAll(cacheDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create cache directory: %w", err)
 	}
 
-	// Check if this is an OCI registry URL
-	if isOCI, _, _ := detectOCI(metadataURL, options.TargetsURL); isOCI {
-		return newOCIClient(metadataURL, options.TargetsURL, cacheDir)
-	}
+	return newClient(ctx, metadataURL, cacheDir, options)
+}
 
-	// Determine if this is a local filesystem path or HTTP URL
-	isLocal := false
-	var targetsURL string
-	var absPath string
+// newClient creates a TUF client using the fetcher registered for the URL scheme
+func newClient(ctx context.Context, metadataURL, cacheDir string, options ClientOptions) (*Client, error) {
+	logger := loggerOrDiscard(options.Logger)
+	telemetry := newTelemetry(options.TracerProvider, options.MeterProvider)
+	factory, err := fetcherFor(metadataURL)
+	if err != nil {
+		return nil, err
+	}
 
-	if filepath.IsAbs(metadataURL) || metadataURL == "." || metadataURL == ".." ||
-		(len(metadataURL) >= 2 && metadataURL[:2] == "./") ||
-		(len(metadataURL) >= 3 && metadataURL[:3] == "../") {
-		// Local filesystem path
-		isLocal = true
-		var err error
-		absPath, err = filepath.Abs(metadataURL)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get absolute path: %w", err)
-		}
-		metadataURL = "file://" + absPath
-		// Targets are in ../targets relative to metadata
-		targetsURL = "file://" + filepath.Join(filepath.Dir(absPath), "targets")
-	} else {
-		// HTTP(S) URL
-		parsedURL, err := url.Parse(metadataURL)
-		if err != nil {
-			return nil, fmt.Errorf("invalid metadata URL: %w", err)
-		}
-		// Assume targets are at ../targets relative to metadata
-		parsedURL.Path = filepath.Dir(parsedURL.Path) + "/targets"
-		targetsURL = parsedURL.String()
+	// Normalize URLs and detect the repository layout
+	layout, err := factory.DetectLayout(metadataURL, options.TargetsURL)
+	if err != nil {
+		return nil, err
+	}
+	// User can still force tuf-on-ci git layout via options
+	if options.TufOnCiGit {
+		layout.TufOnCiGit = true
 	}
+	logger.InfoContext(ctx, "detected repository layout",
+		"scheme", urlScheme(metadataURL), "metadataURL", layout.MetadataURL, "targetsURL", layout.TargetsURL,
+		"tufOnCiGit", layout.TufOnCiGit, "cacheDir", cacheDir)
 
 	// Create metadata directory in cache
 	metadataDir := filepath.Join(cacheDir, "metadata")
@@ -136,27 +181,32 @@ func NewClientWithOptions(metadataURL string, options ClientOptions) (*Client, e
 		return nil, fmt.Errorf("failed to create metadata directory: %w", err)
 	}
 
-	// Download or copy root.json if not present (TOFU)
+	// Create the fetcher, shared by the TOFU download and the updater so
+	// OCI tags resolve to the same digests for the whole session
+	repoFetcher, err := factory.NewFetcher(ctx, layout, FetcherOptions{
+		CacheDir:       cacheDir,
+		MetadataDir:    metadataDir,
+		Limiter:        options.Limiter,
+		Logger:         options.Logger,
+		TracerProvider: options.TracerProvider,
+		MeterProvider:  options.MeterProvider,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Download initial root.json if not present (TOFU)
 	rootPath := filepath.Join(metadataDir, "root.json")
 	if _, err := os.Stat(rootPath); os.IsNotExist(err) {
-		if isLocal {
-			// For local paths, copy from source (metadata directory itself)
-			sourceRootPath := filepath.Join(absPath, "1.root.json")
-			if _, err := os.Stat(sourceRootPath); os.IsNotExist(err) {
-				// Try root.json if 1.root.json doesn't exist
-				sourceRootPath = filepath.Join(absPath, "root.json")
-			}
-			data, err := os.ReadFile(sourceRootPath)
-			if err != nil {
-				return nil, fmt.Errorf("failed to read initial root: %w", err)
-			}
-			if err := os.WriteFile(rootPath, data, 0644); err != nil {
-				return nil, fmt.Errorf("failed to write initial root: %w", err)
-			}
-		} else {
-			if err := downloadFile(metadataURL+"/1.root.json", rootPath); err != nil {
-				return nil, fmt.Errorf("failed to download initial root: %w", err)
-			}
+		logger.InfoContext(ctx, "no trusted root cached, trusting the repository's initial root", "path", rootPath)
+		tofu := &callFetcher{fetcher: repoFetcher, ctx: ctx, metadataURL: layout.MetadataURL, logger: logger, telemetry: telemetry}
+		rootData, err := downloadInitialRoot(ctx, tofu, layout.MetadataURL, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download initial root: %w", err)
+		}
+
+		if err := os.WriteFile(rootPath, rootData, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write initial root: %w", err)
 		}
 	}
 
@@ -176,40 +226,15 @@ func NewClientWithOptions(metadataURL string, options ClientOptions) (*Client, e
 		return nil, fmt.Errorf("failed to parse root metadata: %w", err)
 	}
 
-	// Auto-detect tuf-on-ci git layout for local repositories
-	// Check if unversioned metadata files exist (timestamp.json, snapshot.json, targets.json)
-	// This indicates tuf-on-ci git layout vs standard TUF (which has N.snapshot.json, etc)
-	tufOnCiGit := false
-	if isLocal {
-		timestampPath := filepath.Join(absPath, "timestamp.json")
-		snapshotPath := filepath.Join(absPath, "snapshot.json")
-		targetsPath := filepath.Join(absPath, "targets.json")
-
-		// If all three unversioned files exist, it's tuf-on-ci git layout
-		_, tsErr := os.Stat(timestampPath)
-		_, snapErr := os.Stat(snapshotPath)
-		_, tgtErr := os.Stat(targetsPath)
-
-		if tsErr == nil && snapErr == nil && tgtErr == nil {
-			tufOnCiGit = true
-		}
-	}
-	// User can still override via options
-	if options.TufOnCiGit {
-		tufOnCiGit = true
-	}
-
 	// Auto-detect hash prefix from consistent_snapshot
 	// BUT: tuf-on-ci git repos don't use hash prefixes even when consistent_snapshot=true
 	// Only published tuf-on-ci repos use hash prefixes
-	prefixTargetsWithHash := rootData.Signed.ConsistentSnapshot
-	if tufOnCiGit {
-		// tuf-on-ci git layout never uses hash prefixes
-		prefixTargetsWithHash = false
-	}
+	prefixTargetsWithHash := rootData.Signed.ConsistentSnapshot && !layout.TufOnCiGit
+	logger.DebugContext(ctx, "read trusted root",
+		"consistentSnapshot", rootData.Signed.ConsistentSnapshot, "hashPrefixes", prefixTargetsWithHash)
 
 	// Create updater configuration
-	cfg, err := config.New(metadataURL, rootBytes)
+	cfg, err := config.New(layout.MetadataURL, rootBytes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create config: %w", err)
 	}
@@ -217,16 +242,11 @@ func NewClientWithOptions(metadataURL string, options ClientOptions) (*Client, e
 	// Configure optional settings
 	cfg.LocalMetadataDir = metadataDir
 	cfg.LocalTargetsDir = filepath.Join(cacheDir, "targets")
-	cfg.RemoteTargetsURL = targetsURL
+	cfg.RemoteTargetsURL = layout.TargetsURL
 	cfg.MaxRootRotations = 32
 	cfg.PrefixTargetsWithHash = prefixTargetsWithHash
-
-	// Use custom fetcher that supports file:// URLs and optionally tuf-on-ci git layout
-	if tufOnCiGit {
-		cfg.Fetcher = NewTufOnCiFetcher(metadataURL)
-	} else {
-		cfg.Fetcher = NewFilesystemFetcher()
-	}
+	calls := &callFetcher{fetcher: repoFetcher, ctx: context.Background(), metadataURL: layout.MetadataURL, logger: logger, telemetry: telemetry}
+	cfg.Fetcher = calls
 
 	// Create updater
 	tufUpdater, err := updater.New(cfg)
@@ -234,39 +254,135 @@ func NewClientWithOptions(metadataURL string, options ClientOptions) (*Client, e
 		return nil, fmt.Errorf("failed to create updater: %w", err)
 	}
 
-	return &Client{
+	client := &Client{
 		updater:            tufUpdater,
-		metadataURL:        metadataURL,
-		targetsURL:         targetsURL,
+		calls:              calls,
+		metadataURL:        layout.MetadataURL,
+		targetsURL:         layout.TargetsURL,
 		cacheDir:           cacheDir,
-		tufOnCiGit:         tufOnCiGit,
+		tufOnCiGit:         layout.TufOnCiGit,
 		consistentSnapshot: rootData.Signed.ConsistentSnapshot,
 		hashPrefixes:       prefixTargetsWithHash,
-	}, nil
+		targetsMaxLength:   cfg.TargetsMaxLength,
+		fetcher:            repoFetcher,
+		progress:           options.Progress,
+		logger:             logger,
+		telemetry:          telemetry,
+		sourceURL:          metadataURL,
+		options:            options,
+	}
+	// OCI digests pinned during the session are reported in the repository info
+	if registry, ok := repoFetcher.(*RegistryFetcher); ok {
+		client.registry = registry
+	}
+	return client, nil
+}
+
+// initialRootFallback is implemented by fetchers for repositories that may
+// only hold the current root, such as local checkouts
+type initialRootFallback interface {
+	// missingInitialRoot reports whether err, from downloading the initial
+	// root at urlPath, means the repository does not publish it
+	missingInitialRoot(urlPath string, err error) bool
+}
+
+// downloadInitialRoot fetches 1.root.json, falling back to root.json when the
+// fetcher reports that its repository does not publish the initial root. The
+// fallback trusts the current root without walking the root history, so it is
+// logged as a warning.
+func downloadInitialRoot(ctx context.Context, repoFetcher fetcher.Fetcher, metadataURL string, logger *slog.Logger) ([]byte, error) {
+	metadataURL = strings.TrimSuffix(metadataURL, "/")
+	initialURL := metadataURL + "/1.root.json"
+	data, err := downloadFile(ctx, repoFetcher, initialURL, 512000, 30*time.Second)
+	if fallback, ok := repoFetcher.(initialRootFallback); ok && err != nil && fallback.missingInitialRoot(initialURL, err) {
+		logger.WarnContext(ctx, "repository has no 1.root.json, trusting its current root.json instead", "url", initialURL, "error", err)
+		return downloadFile(ctx, repoFetcher, metadataURL+"/root.json", 512000, 30*time.Second)
+	}
+	return data, err
+}
+
+// isHTTPStatus reports whether err is a download failure with one of the given statuses
+func isHTTPStatus(err error, statuses ...int) bool {
+	var httpErr *metadata.ErrDownloadHTTP
+	return errors.As(err, &httpErr) && slices.Contains(statuses, httpErr.StatusCode)
+}
+
+// clientSession is the part of a client that refresh replaces
+type clientSession struct {
+	fetcher      fetcher.Fetcher
+	hashPrefixed bool // Targets are fetched by hash-prefixed names
+}
+
+// session returns the current fetcher and target naming, for use outside withUpdater
+func (c *Client) session() clientSession {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return clientSession{fetcher: c.fetcher, hashPrefixed: c.consistentSnapshot && c.hashPrefixes}
+}
+
+// withUpdater runs fn with exclusive use of the updater, whose downloads use ctx
+func (c *Client) withUpdater(ctx context.Context, fn func(*updater.Updater) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.calls.ctx = ctx
+	defer func() { c.calls.ctx = context.Background() }()
+	return fn(c.updater)
 }
 
 // Update refreshes the metadata from the remote repository
 func (c *Client) Update() error {
-	return c.updater.Refresh()
+	return c.UpdateContext(context.Background())
+}
+
+// UpdateContext refreshes the metadata from the remote repository, stopping
+// when ctx is done. Metadata verified before cancellation stays trusted.
+func (c *Client) UpdateContext(ctx context.Context) (err error) {
+	ctx, span := c.telemetry.start(ctx, "tufzy.Update", attrURL.String(c.metadataURL))
+	defer func() { c.telemetry.end(ctx, span, "metadata", err) }()
+
+	return c.withUpdater(ctx, func(u *updater.Updater) error {
+		if err := u.Refresh(); err != nil {
+			return err
+		}
+
+		trusted := u.GetTrustedMetadataSet()
+		c.logVerified(ctx, metadata.ROOT, trusted.Root.Signed.Version, trusted.Root.Signed.Expires)
+		c.logVerified(ctx, metadata.TIMESTAMP, trusted.Timestamp.Signed.Version, trusted.Timestamp.Signed.Expires)
+		c.logVerified(ctx, metadata.SNAPSHOT, trusted.Snapshot.Signed.Version, trusted.Snapshot.Signed.Expires)
+		targets := trusted.Targets[metadata.TARGETS]
+		c.logVerified(ctx, metadata.TARGETS, targets.Signed.Version, targets.Signed.Expires)
+		return nil
+	})
+}
+
+// logVerified records a role whose metadata is trusted
+func (c *Client) logVerified(ctx context.Context, role string, version int64, expires time.Time) {
+	c.logger.InfoContext(ctx, "verified role", "role", role, "version", version, "expires", expires)
 }
 
 // GetTargets returns all available targets
 func (c *Client) GetTargets() ([]TargetInfo, error) {
-	targetFiles := c.updater.GetTopLevelTargets()
+	return c.GetTargetsContext(context.Background())
+}
+
+// GetTargetsContext returns all available targets, or ctx's error if it is done
+func (c *Client) GetTargetsContext(ctx context.Context) ([]TargetInfo, error) {
+	var targetFiles map[string]*metadata.TargetFiles
+	err := c.withUpdater(ctx, func(u *updater.Updater) error {
+		targetFiles = u.GetTopLevelTargets()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	var targets []TargetInfo
 	for name, targetFile := range targetFiles {
-		hashes := make(map[string]string)
-		for alg, hash := range targetFile.Hashes {
-			hashes[alg] = fmt.Sprintf("%x", hash)
-		}
-
-		targets = append(targets, TargetInfo{
-			Name:   name,
-			Length: targetFile.Length,
-			Hashes: hashes,
-			Custom: targetFile.Custom,
-		})
+		targets = append(targets, *newTargetInfo(name, targetFile))
 	}
 
 	return targets, nil
@@ -274,119 +390,215 @@ func (c *Client) GetTargets() ([]TargetInfo, error) {
 
 // GetRepositoryInfo returns metadata about the repository
 func (c *Client) GetRepositoryInfo() (*RepositoryInfo, error) {
-	// Get trusted metadata
-	trusted := c.updater.GetTrustedMetadataSet()
-
 	info := &RepositoryInfo{
-		MetadataURL:        c.metadataURL,
-		TargetsURL:         c.targetsURL,
-		TufOnCiGit:         c.tufOnCiGit,
-		ConsistentSnapshot: c.consistentSnapshot,
-		HashPrefixes:       c.hashPrefixes,
+		MetadataURL: c.metadataURL,
+		TargetsURL:  c.targetsURL,
+		TufOnCiGit:  c.tufOnCiGit,
 	}
 
-	// Root info
-	if root := trusted.Root; root != nil {
-		info.RootVersion = root.Signed.Version
-		info.RootExpires = root.Signed.Expires
-	}
+	err := c.withUpdater(context.Background(), func(u *updater.Updater) error {
+		// Get trusted metadata
+		trusted := u.GetTrustedMetadataSet()
+		info.ConsistentSnapshot = c.consistentSnapshot
+		info.HashPrefixes = c.hashPrefixes
 
-	// Targets info
-	if targets := trusted.Targets["targets"]; targets != nil {
-		info.TargetsVersion = targets.Signed.Version
-		info.TargetsExpires = targets.Signed.Expires
-	}
+		// OCI digests pinned during this session
+		if c.registry != nil {
+			info.ResolvedDigests = c.registry.ResolvedDigests()
+		}
 
-	// Snapshot info
-	if snapshot := trusted.Snapshot; snapshot != nil {
-		info.SnapshotVersion = snapshot.Signed.Version
-		info.SnapshotExpires = snapshot.Signed.Expires
-	}
+		// Root info
+		if root := trusted.Root; root != nil {
+			info.RootVersion = root.Signed.Version
+			info.RootExpires = root.Signed.Expires
+		}
 
-	// Timestamp info
-	if timestamp := trusted.Timestamp; timestamp != nil {
-		info.TimestampVersion = timestamp.Signed.Version
-		info.TimestampExpires = timestamp.Signed.Expires
-	}
+		// Targets info
+		if targets := trusted.Targets["targets"]; targets != nil {
+			info.TargetsVersion = targets.Signed.Version
+			info.TargetsExpires = targets.Signed.Expires
+		}
+
+		// Snapshot info
+		if snapshot := trusted.Snapshot; snapshot != nil {
+			info.SnapshotVersion = snapshot.Signed.Version
+			info.SnapshotExpires = snapshot.Signed.Expires
+		}
 
+		// Timestamp info
+		if timestamp := trusted.Timestamp; timestamp != nil {
+			info.TimestampVersion = timestamp.Signed.Version
+			info.TimestampExpires = timestamp.Signed.Expires
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 	return info, nil
 }
 
 // GetDelegations returns the delegation tree
 func (c *Client) GetDelegations() ([]Delegation, error) {
-	trusted := c.updater.GetTrustedMetadataSet()
-
 	var delegations []Delegation
-
-	// Get targets metadata
-	if targetsMetadata := trusted.Targets["targets"]; targetsMetadata != nil {
-		if targetsMetadata.Signed.Delegations != nil {
-			for _, role := range targetsMetadata.Signed.Delegations.Roles {
-				delegations = append(delegations, Delegation{
-					Name:      role.Name,
-					Threshold: role.Threshold,
-					KeyIDs:    role.KeyIDs,
-					Paths:     role.Paths,
-				})
+	err := c.withUpdater(context.Background(), func(u *updater.Updater) error {
+		trusted := u.GetTrustedMetadataSet()
+
+		// Get targets metadata
+		if targetsMetadata := trusted.Targets["targets"]; targetsMetadata != nil {
+			if targetsMetadata.Signed.Delegations != nil {
+				for _, role := range targetsMetadata.Signed.Delegations.Roles {
+					delegations = append(delegations, Delegation{
+						Name:      role.Name,
+						Threshold: role.Threshold,
+						KeyIDs:    role.KeyIDs,
+						Paths:     role.Paths,
+					})
+				}
 			}
 		}
-	}
-
-	return delegations, nil
+		return nil
+	})
+	return delegations, err
 }
 
 // DownloadTarget downloads and verifies a specific target file
 func (c *Client) DownloadTarget(name string, destPath string) (*TargetInfo, error) {
-	// Get target info
-	targetFile, err := c.updater.GetTargetInfo(name)
+	return c.DownloadTargetContext(context.Background(), name, destPath)
+}
+
+// DownloadTargetContext downloads and verifies a specific target file,
+// stopping when ctx is done. A cancelled streaming download is resumed by
+// the next call for the same target.
+func (c *Client) DownloadTargetContext(ctx context.Context, name string, destPath string) (_ *TargetInfo, err error) {
+	ctx, span := c.telemetry.start(ctx, "tufzy.DownloadTarget", attrTarget.String(name))
+	defer func() { c.telemetry.end(ctx, span, "target", err, attrTarget.String(name)) }()
+
+	targetFile, err := c.lookupTarget(ctx, name)
 	if err != nil {
-		return nil, fmt.Errorf("target not found: %w", err)
+		return nil, err
+	}
+
+	// Download and verify, streaming to disk when the fetcher supports it
+	path := destPath
+	session := c.session()
+	if streamer, ok := session.fetcher.(TargetStreamer); ok {
+		if path == "" {
+			path = c.defaultTargetPath(targetFile.Path)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return nil, fmt.Errorf("failed to create targets directory: %w", err)
+			}
+		}
+		if err := c.streamTarget(ctx, streamer, targetFile, path, session.hashPrefixed); err != nil {
+			return nil, fmt.Errorf("failed to download target: %w", err)
+		}
+	} else {
+		err = c.withUpdater(ctx, func(u *updater.Updater) error {
+			var err error
+			path, _, err = u.DownloadTarget(targetFile, destPath, "")
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to download target: %w", err)
+		}
 	}
 
-	// Download and verify
-	path, _, err := c.updater.DownloadTarget(targetFile, destPath, "")
+	return newTargetInfo(path, targetFile), nil
+}
+
+// GetTargetInfo returns a target's trusted metadata, searching delegated roles as needed
+func (c *Client) GetTargetInfo(name string) (*TargetInfo, error) {
+	return c.GetTargetInfoContext(context.Background(), name)
+}
+
+// GetTargetInfoContext returns a target's trusted metadata, searching delegated
+// roles as needed and stopping when ctx is done
+func (c *Client) GetTargetInfoContext(ctx context.Context, name string) (*TargetInfo, error) {
+	targetFile, err := c.lookupTarget(ctx, name)
 	if err != nil {
-		return nil, fmt.Errorf("failed to download target: %w", err)
+		return nil, err
 	}
+	return newTargetInfo(name, targetFile), nil
+}
 
-	hashes := make(map[string]string)
-	for alg, hash := range targetFile.Hashes {
-		hashes[alg] = fmt.Sprintf("%x", hash)
+// lookupTarget returns a target's trusted metadata, which may fetch delegated metadata
+func (c *Client) lookupTarget(ctx context.Context, name string) (*metadata.TargetFiles, error) {
+	var targetFile *metadata.TargetFiles
+	err := c.withUpdater(ctx, func(u *updater.Updater) error {
+		var err error
+		targetFile, err = u.GetTargetInfo(name)
+		return err
+	})
+
+	// The updater reports a target no role lists with an unwrapped error,
+	// so it can only be recognised by its message
+	switch {
+	case err == nil:
+		return targetFile, nil
+	case ctx.Err() != nil:
+		return nil, err
+	case err.Error() == fmt.Sprintf("target %s not found", name):
+		return nil, fmt.Errorf("%w: %s", ErrTargetNotFound, name)
+	default:
+		return nil, fmt.Errorf("failed to look up target: %w", err)
 	}
+}
 
-	return &TargetInfo{
-		Name:   path,
-		Length: targetFile.Length,
-		Hashes: hashes,
-		Custom: targetFile.Custom,
-	}, nil
+// VerifyTarget checks that the file at path matches a target's trusted length and hashes
+func (c *Client) VerifyTarget(name string, path string) (*TargetInfo, error) {
+	return c.VerifyTargetContext(context.Background(), name, path)
 }
 
-// downloadFile downloads a file from a URL
-func downloadFile(url, destPath string) error {
-	resp, err := http.Get(url)
+// VerifyTargetContext checks that the file at path matches a target's trusted
+// length and hashes, stopping when ctx is done
+func (c *Client) VerifyTargetContext(ctx context.Context, name string, path string) (_ *TargetInfo, err error) {
+	ctx, span := c.telemetry.start(ctx, "tufzy.VerifyTarget", attrTarget.String(name))
+	defer func() { c.telemetry.end(ctx, span, "target", err, attrTarget.String(name)) }()
+
+	targetFile, err := c.lookupTarget(ctx, name)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
+	defer func() { _ = file.Close() }()
 
-	out, err := os.Create(destPath)
+	verifier, err := newTargetVerifier(targetFile)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	// Read one byte past the length so oversized files fail verification
+	if _, err := io.Copy(verifier, io.LimitReader(&contextReader{ctx: ctx, r: file}, targetFile.Length+1)); err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if err := verifier.verify(); err != nil {
+		return nil, err
 	}
-	defer func() { _ = out.Close() }()
 
-	_, err = io.Copy(out, resp.Body)
-	return err
+	return newTargetInfo(name, targetFile), nil
 }
 
-// detectOCI checks if the metadata URL uses the OCI scheme and validates targets URL
+// newTargetInfo converts trusted target metadata to a TargetInfo
+func newTargetInfo(name string, targetFile *metadata.TargetFiles) *TargetInfo {
+	hashes := make(map[string]string)
+	for alg, hash := range targetFile.Hashes {
+		hashes[alg] = hash.String()
+	}
+	return &TargetInfo{
+		Name:   name,
+		Length: targetFile.Length,
+		Hashes: hashes,
+		Custom: targetFile.Custom,
+	}
+}
+
+// detectOCI checks if the metadata URL uses an OCI scheme and validates targets URL
 func detectOCI(metadataURL, targetsURL string) (isOCI bool, metadata string, targets string) {
-	if !hasOCIScheme(metadataURL) {
+	scheme := ociSchemeOf(metadataURL)
+	if scheme == "" {
 		return false, "", ""
 	}
 
@@ -394,8 +606,8 @@ func detectOCI(metadataURL, targetsURL string) (isOCI bool, metadata string, tar
 		return true, "", "" // OCI but no targets URL provided (error case)
 	}
 
-	if !hasOCIScheme(targetsURL) {
-		return true, "", "" // OCI but targets not OCI (error case)
+	if ociSchemeOf(targetsURL) != scheme {
+		return true, "", "" // OCI but targets not using the same OCI scheme (error case)
 	}
 
 	return true, metadataURL, targetsURL
@@ -406,97 +618,19 @@ func hasOCIScheme(url string) bool {
 	return len(url) >= len(OCIScheme) && url[:len(OCIScheme)] == OCIScheme
 }
 
-// newOCIClient creates a TUF client for OCI registries
-func newOCIClient(metadataURL, targetsURL, cacheDir string) (*Client, error) {
-	if targetsURL == "" {
-		return nil, fmt.Errorf("targets URL is required for OCI repositories")
-	}
-
-	// Create metadata directory in cache
-	metadataDir := filepath.Join(cacheDir, "metadata")
-	if err := os.MkdirAll(metadataDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create metadata directory: %w", err)
-	}
-
-	// Download initial root.json if not present (TOFU)
-	rootPath := filepath.Join(metadataDir, "root.json")
-	if _, err := os.Stat(rootPath); os.IsNotExist(err) {
-		// Create a temporary RegistryFetcher just to download the initial root
-		ctx, cancel := contextWithTimeout(30 * time.Second)
-		defer cancel()
-		fetcher, err := NewRegistryFetcher(ctx, metadataURL, targetsURL)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create registry fetcher: %w", err)
-		}
-
-		// Try to download 1.root.json
-		rootData, err := fetcher.DownloadFile(metadataURL+"/1.root.json", 512000, 30*time.Second)
-		if err != nil {
-			return nil, fmt.Errorf("failed to download initial root: %w", err)
-		}
-
-		if err := os.WriteFile(rootPath, rootData, 0644); err != nil {
-			return nil, fmt.Errorf("failed to write initial root: %w", err)
-		}
-	}
-
-	// Read trusted root
-	rootBytes, err := os.ReadFile(rootPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read trusted root: %w", err)
-	}
-
-	// Parse root to detect consistent_snapshot setting
-	var rootData struct {
-		Signed struct {
-			ConsistentSnapshot bool `json:"consistent_snapshot"`
-		} `json:"signed"`
-	}
-	if err := json.Unmarshal(rootBytes, &rootData); err != nil {
-		return nil, fmt.Errorf("failed to parse root metadata: %w", err)
-	}
-
-	// Create updater configuration
-	cfg, err := config.New(metadataURL, rootBytes)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create config: %w", err)
-	}
-
-	// Configure settings
-	cfg.LocalMetadataDir = metadataDir
-	cfg.LocalTargetsDir = filepath.Join(cacheDir, "targets")
-	cfg.RemoteTargetsURL = targetsURL
-	cfg.MaxRootRotations = 32
-	cfg.PrefixTargetsWithHash = rootData.Signed.ConsistentSnapshot
-
-	// Create OCI registry fetcher
-	ctx, cancel := contextWithTimeout(30 * time.Second)
-	defer cancel()
-	fetcher, err := NewRegistryFetcher(ctx, metadataURL, targetsURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create registry fetcher: %w", err)
-	}
-	cfg.Fetcher = fetcher
-
-	// Create updater
-	tufUpdater, err := updater.New(cfg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create updater: %w", err)
-	}
-
-	return &Client{
-		updater:            tufUpdater,
-		metadataURL:        metadataURL,
-		targetsURL:         targetsURL,
-		cacheDir:           cacheDir,
-		tufOnCiGit:         false,
-		consistentSnapshot: rootData.Signed.ConsistentSnapshot,
-		hashPrefixes:       rootData.Signed.ConsistentSnapshot,
-	}, nil
+// hasOCILayoutScheme checks if a URL has the OCI image layout scheme prefix
+func hasOCILayoutScheme(url string) bool {
+	return len(url) >= len(OCILayoutScheme) && url[:len(OCILayoutScheme)] == OCILayoutScheme
 }
 
-// contextWithTimeout creates a context with timeout
-func contextWithTimeout(timeout time.Duration) (context.Context, context.CancelFunc) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	return ctx, cancel
+// ociSchemeOf returns the OCI scheme prefix of a URL, or "" if it is not an OCI URL
+func ociSchemeOf(url string) string {
+	switch {
+	case hasOCIScheme(url):
+		return OCIScheme
+	case hasOCILayoutScheme(url):
+		return OCILayoutScheme
+	default:
+		return ""
+	}
 }
@@ -1,7 +1,16 @@
 package client
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/kipz/tufzy/internal/testrepo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
 )
 
 const (
@@ -140,3 +149,39 @@ func TestGetDelegations_Remote(t *testing.T) {
 		t.Error("Expected to find 'jku' or 'rdimitrov' delegation")
 	}
 }
+
+func TestNewClient_InitialRootFallback(t *testing.T) {
+	// The repository only publishes its current root
+	repo := testrepo.New(t, t.TempDir())
+	repo.Publish()
+	metadataDir := filepath.Join(repo.Dir, "metadata")
+	require.NoError(t, os.Rename(filepath.Join(metadataDir, "1.root.json"), filepath.Join(metadataDir, "root.json")))
+
+	t.Run("local repositories fall back with a warning", func(t *testing.T) {
+		recorder := &logRecorder{}
+		client, err := NewClientWithOptions(metadataDir, ClientOptions{CacheDir: t.TempDir(), Logger: recorder.logger()})
+		require.NoError(t, err)
+		require.NoError(t, client.Update())
+		assert.Equal(t, []any{"WARN"}, recorder.values(t, "repository has no 1.root.json, trusting its current root.json instead", "level"))
+	})
+
+	// Over HTTP(S) a missing or forbidden initial root is an error
+	for _, status := range []int{http.StatusNotFound, http.StatusForbidden} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			files := http.FileServer(http.Dir(repo.Dir))
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/metadata/1.root.json" {
+					http.Error(w, http.StatusText(status), status)
+					return
+				}
+				files.ServeHTTP(w, r)
+			}))
+			defer server.Close()
+
+			_, err := NewClientWithOptions(server.URL+"/metadata", ClientOptions{CacheDir: t.TempDir()})
+			var httpErr *metadata.ErrDownloadHTTP
+			require.ErrorAs(t, err, &httpErr)
+			assert.Equal(t, status, httpErr.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/theupdateframework/go-tuf/v2/metadata/fetcher"
+)
+
+// ContextFetcher is implemented by fetchers whose downloads can be cancelled.
+// go-tuf's fetcher.Fetcher has no context parameter, so the client binds the
+// context of each call to the fetcher through DownloadFileContext.
+type ContextFetcher interface {
+	fetcher.Fetcher
+	// DownloadFileContext is DownloadFile with a context that cancels the request
+	DownloadFileContext(ctx context.Context, urlPath string, maxLength int64, timeout time.Duration) ([]byte, error)
+}
+
+// downloadFile downloads with ctx, checking it before the download when the
+// fetcher can't be cancelled
+func downloadFile(ctx context.Context, f fetcher.Fetcher, urlPath string, maxLength int64, timeout time.Duration) ([]byte, error) {
+	if cf, ok := f.(ContextFetcher); ok {
+		return cf.DownloadFileContext(ctx, urlPath, maxLength, timeout)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return f.DownloadFile(urlPath, maxLength, timeout)
+}
+
+// callFetcher is the fetcher handed to the updater. Its downloads use the
+// context of the client call in progress, which the client sets while it
+// holds exclusive use of the updater.
+type callFetcher struct {
+	fetcher     fetcher.Fetcher
+	ctx         context.Context
+	metadataURL string // Downloads from elsewhere are targets
+	logger      *slog.Logger
+	telemetry   *telemetry
+}
+
+// missingInitialRoot defers to the wrapped fetcher, so the initial root
+// fallback is the same with or without the wrapper
+func (f *callFetcher) missingInitialRoot(urlPath string, err error) bool {
+	fallback, ok := f.fetcher.(initialRootFallback)
+	return ok && fallback.missingInitialRoot(urlPath, err)
+}
+
+func (f *callFetcher) DownloadFile(urlPath string, maxLength int64, timeout time.Duration) ([]byte, error) {
+	// Targets downloaded by the updater are traced by the client's download span
+	if !strings.HasPrefix(urlPath, f.metadataURL) {
+		data, err := downloadFile(f.ctx, f.fetcher, urlPath, maxLength, timeout)
+		f.telemetry.addBytes(f.ctx, "target", int64(len(data)))
+		return data, err
+	}
+
+	role := roleFromURL(urlPath)
+	ctx, span := f.telemetry.start(f.ctx, "tufzy.FetchMetadata", attrRole.String(role), attrURL.String(urlPath))
+	defer span.End()
+
+	start := time.Now()
+	data, err := downloadFile(ctx, f.fetcher, urlPath, maxLength, timeout)
+	if err != nil {
+		// Missing files are expected, e.g. when probing for the next root version
+		f.logger.DebugContext(ctx, "metadata fetch failed", "url", urlPath, "duration", time.Since(start), "error", err)
+		span.RecordError(err)
+		return nil, err
+	}
+	f.logger.DebugContext(ctx, "fetched metadata", "url", urlPath, "bytes", len(data), "duration", time.Since(start))
+	f.telemetry.addBytes(ctx, "metadata", int64(len(data)))
+	return data, nil
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kipz/tufzy/internal/testrepo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stallingHandler serves a directory but stalls requests for one path after
+// writing the first stallAfter bytes, until the client goes away
+type stallingHandler struct {
+	dir        string
+	stallPath  string
+	stallAfter int
+	stalled    chan struct{}
+	once       sync.Once
+}
+
+func (h *stallingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasSuffix(r.URL.Path, h.stallPath) || r.Header.Get("Range") != "" {
+		http.FileServer(http.Dir(h.dir)).ServeHTTP(w, r)
+		return
+	}
+
+	data, err := os.ReadFile(filepath.Join(h.dir, filepath.FromSlash(r.URL.Path)))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	_, _ = w.Write(data[:h.stallAfter])
+	w.(http.Flusher).Flush()
+	h.once.Do(func() { close(h.stalled) })
+	<-r.Context().Done()
+}
+
+func TestClient_UpdateContext_Cancel(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	repo := testrepo.New(t, t.TempDir())
+	repo.Publish()
+
+	handler := &stallingHandler{dir: repo.Dir, stallPath: "/timestamp.json", stallAfter: 10, stalled: make(chan struct{})}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client, err := NewClient(server.URL + "/metadata")
+	require.NoError(t, err)
+
+	// A context that is already done fails before any request
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, client.UpdateContext(ctx), context.Canceled)
+	_, err = client.GetTargetsContext(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// Cancelling while timestamp.json is downloading stops the update
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		<-handler.stalled
+		cancel()
+	}()
+
+	done := make(chan error, 1)
+	go func() { done <- client.UpdateContext(ctx) }()
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, context.Canceled)
+	case <-time.After(10 * time.Second):
+		t.Fatal("update was not cancelled")
+	}
+}
+
+func TestClient_DownloadTargetContext_CancelAndResume(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	content := bytes.Repeat([]byte("cancelled target "), 5000)
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddTarget("targets", "large.bin", content)
+	repo.Publish()
+
+	handler := &stallingHandler{dir: repo.Dir, stallPath: ".large.bin", stallAfter: 30000, stalled: make(chan struct{})}
+	recorder := &rangeRecorder{}
+	server := httptest.NewServer(recorder.wrap(handler))
+	defer server.Close()
+
+	// Cancel once the bytes sent before the stall are on disk
+	ctx, cancel := context.WithCancel(context.Background())
+	client, err := NewClientWithOptions(server.URL+"/metadata", ClientOptions{
+		Progress: func(downloaded, total int64) {
+			if downloaded >= 30000 {
+				cancel()
+			}
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+
+	destPath := filepath.Join(t.TempDir(), "large.bin")
+	_, err = client.DownloadTargetContext(ctx, "large.bin", destPath)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.NoFileExists(t, destPath)
+
+	// The partial download is kept and resumed from where it stopped
+	partialPath := filepath.Join(client.cacheDir, "partial", "sha256-"+repo.TargetHash("large.bin"))
+	info, err := os.Stat(partialPath)
+	require.NoError(t, err)
+	assert.Equal(t, int64(30000), info.Size())
+
+	_, err = client.DownloadTargetContext(context.Background(), "large.bin", destPath)
+	require.NoError(t, err)
+	data, err := os.ReadFile(destPath)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+	assert.Equal(t, []string{"", "bytes=30000-"}, recorder.ranges)
+}
+
+func TestDownloadFile_Context(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "root.json"), []byte("{}"), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Fetchers with and without context support both stop on a done context
+	_, err := downloadFile(ctx, NewFilesystemFetcher(), "file://"+filepath.Join(dir, "root.json"), 1024, 0)
+	require.ErrorIs(t, err, context.Canceled)
+	_, err = downloadFile(ctx, dirFetcher{dir: dir}, "mem://repo/root.json", 1024, 0)
+	require.ErrorIs(t, err, context.Canceled)
+
+	data, err := downloadFile(context.Background(), dirFetcher{dir: dir}, "mem://repo/root.json", 1024, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("{}"), data)
+
+	// Local streams stop too
+	var buf bytes.Buffer
+	_, err = NewFilesystemFetcher().StreamFile(ctx, "file://"+filepath.Join(dir, "root.json"), &buf, 0, 1024)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, buf.Len())
+}
@@ -0,0 +1,156 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+	"github.com/theupdateframework/go-tuf/v2/metadata/trustedmetadata"
+	"github.com/theupdateframework/go-tuf/v2/metadata/updater"
+)
+
+// GetDelegationTree returns every delegated role, with the roles each one
+// delegates to as its children
+func (c *Client) GetDelegationTree() ([]Delegation, error) {
+	return c.GetDelegationTreeContext(context.Background())
+}
+
+// GetDelegationTreeContext returns every delegated role, with the roles each one
+// delegates to as its children. Delegated metadata is loaded and verified the
+// same way the updater does when searching for a target, stopping when ctx is done.
+func (c *Client) GetDelegationTreeContext(ctx context.Context) (tree []Delegation, err error) {
+	ctx, span := c.telemetry.start(ctx, "tufzy.GetDelegationTree")
+	defer func() { c.telemetry.end(ctx, span, "metadata", err) }()
+
+	err = c.withUpdater(ctx, func(u *updater.Updater) error {
+		// The trusted set is a copy, but its roles map is shared with the updater,
+		// so roles verified here are reused by later target lookups
+		trusted := u.GetTrustedMetadataSet()
+		var err error
+		tree, err = c.delegationsOf(ctx, &trusted, metadata.TARGETS, map[string]bool{metadata.TARGETS: true})
+		return err
+	})
+	return tree, err
+}
+
+// delegationsOf loads the roles delegated by parent and, recursively, their delegations.
+// Roles already on the current path are listed but not descended into again.
+func (c *Client) delegationsOf(ctx context.Context, trusted *trustedmetadata.TrustedMetadata, parent string, path map[string]bool) ([]Delegation, error) {
+	parentMetadata := trusted.Targets[parent]
+	if parentMetadata == nil || parentMetadata.Signed.Delegations == nil {
+		return nil, nil
+	}
+
+	var delegations []Delegation
+	for _, role := range parentMetadata.Signed.Delegations.Roles {
+		delegation := Delegation{
+			Name:             role.Name,
+			Threshold:        role.Threshold,
+			KeyIDs:           role.KeyIDs,
+			Paths:            role.Paths,
+			PathHashPrefixes: role.PathHashPrefixes,
+			Terminating:      role.Terminating,
+		}
+
+		if !path[role.Name] {
+			if _, err := c.loadDelegatedTargets(ctx, trusted, role.Name, parent); err != nil {
+				return nil, fmt.Errorf("failed to load delegated role %s: %w", role.Name, err)
+			}
+
+			path[role.Name] = true
+			children, err := c.delegationsOf(ctx, trusted, role.Name, path)
+			delete(path, role.Name)
+			if err != nil {
+				return nil, err
+			}
+			delegation.Children = children
+		}
+		delegations = append(delegations, delegation)
+	}
+	return delegations, nil
+}
+
+// loadDelegatedTargets returns a delegated role's verified metadata, preferring the
+// local cache and otherwise downloading and persisting it like the updater does
+func (c *Client) loadDelegatedTargets(ctx context.Context, trusted *trustedmetadata.TrustedMetadata, role, parent string) (*metadata.Metadata[metadata.TargetsType], error) {
+	if loaded, found := trusted.Targets[role]; found {
+		return loaded, nil
+	}
+
+	// Step 1: a valid local copy is used as is
+	localPath := filepath.Join(c.cacheDir, "metadata", url.PathEscape(role)+".json")
+	if data, err := os.ReadFile(localPath); err == nil {
+		loaded, err := trusted.UpdateDelegatedTargets(data, role, parent)
+		if err == nil {
+			c.logger.DebugContext(ctx, "using cached delegated role", "role", role, "parent", parent, "version", loaded.Signed.Version)
+			c.telemetry.cacheHit(ctx, "delegated_metadata", attrRole.String(role))
+			return loaded, nil
+		}
+		if !errors.Is(err, &metadata.ErrRepository{}) {
+			return nil, err
+		}
+	}
+
+	// Step 2: download the version listed in the trusted snapshot
+	c.telemetry.cacheMiss(ctx, "delegated_metadata")
+	if trusted.Snapshot == nil {
+		return nil, fmt.Errorf("trusted snapshot not set")
+	}
+	meta, found := trusted.Snapshot.Signed.Meta[role+".json"]
+	if !found {
+		return nil, fmt.Errorf("role %s not found in snapshot", role)
+	}
+	length := meta.Length
+	if length == 0 {
+		length = c.targetsMaxLength
+	}
+	remoteName := url.PathEscape(role) + ".json"
+	if trusted.Root.Signed.ConsistentSnapshot {
+		remoteName = fmt.Sprintf("%d.%s", meta.Version, remoteName)
+	}
+	// The updater's fetcher is bound to ctx while the updater is held
+	data, err := c.calls.DownloadFile(strings.TrimSuffix(c.metadataURL, "/")+"/"+remoteName, length, 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 3: verify against the delegating role, then cache it
+	loaded, err := trusted.UpdateDelegatedTargets(data, role, parent)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(localPath, data); err != nil {
+		return nil, fmt.Errorf("failed to persist metadata: %w", err)
+	}
+	c.logger.InfoContext(ctx, "verified delegated role", "role", role, "parent", parent,
+		"version", loaded.Signed.Version, "expires", loaded.Signed.Expires)
+	return loaded, nil
+}
+
+// writeFileAtomic writes data to a temporary file and renames it into place
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
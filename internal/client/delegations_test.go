@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kipz/tufzy/internal/testrepo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetDelegationTree(t *testing.T) {
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddTarget("targets", "app.txt", []byte("app content"))
+	repo.AddDelegation("team", []string{"team/*"})
+	repo.AddDelegationFrom("team", "team-nightly", []string{"team/nightly-*"})
+	repo.AddTarget("team-nightly", "team/nightly-1.txt", []byte("nightly content"))
+	repo.AddDelegation("ops", []string{"ops/*"})
+	repo.Publish()
+
+	var delegatedRequests atomic.Int32
+	files := http.FileServer(http.Dir(repo.Dir))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch filepath.Base(r.URL.Path) {
+		case "1.team.json", "1.team-nightly.json", "1.ops.json":
+			delegatedRequests.Add(1)
+		}
+		files.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	cacheDir := t.TempDir()
+	client, err := NewClientWithOptions(server.URL+"/metadata", ClientOptions{CacheDir: cacheDir})
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+
+	tree, err := client.GetDelegationTree()
+	require.NoError(t, err)
+	require.Len(t, tree, 2)
+	assert.Equal(t, "team", tree[0].Name)
+	assert.Equal(t, []string{"team/*"}, tree[0].Paths)
+	require.Len(t, tree[0].Children, 1)
+	assert.Equal(t, "team-nightly", tree[0].Children[0].Name)
+	assert.Equal(t, []string{"team/nightly-*"}, tree[0].Children[0].Paths)
+	assert.Equal(t, "ops", tree[1].Name)
+	assert.Empty(t, tree[1].Children)
+
+	// Every delegated role was verified and cached
+	for _, role := range []string{"team", "team-nightly", "ops"} {
+		assert.FileExists(t, filepath.Join(cacheDir, "metadata", role+".json"))
+	}
+
+	// Lookups reuse the verified roles, and a new client reads them from the cache
+	info, err := client.GetTargetInfo("team/nightly-1.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("nightly content")), info.Length)
+	_, err = client.GetTargetInfo("team/nightly-2.txt")
+	require.ErrorIs(t, err, ErrTargetNotFound)
+	assert.Equal(t, int32(3), delegatedRequests.Load())
+
+	client, err = NewClientWithOptions(server.URL+"/metadata", ClientOptions{CacheDir: cacheDir})
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+	_, err = client.GetDelegationTree()
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), delegatedRequests.Load(), "delegated metadata was downloaded again")
+}
+
+func TestClient_GetDelegationTree_Tampered(t *testing.T) {
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddDelegation("team", []string{"team/*"})
+	repo.Publish()
+
+	// Re-signing the delegated role with another key breaks the chain of trust
+	repo.NewKey("team")
+	repo.Sign(repo.Targets["team"], "team")
+	require.NoError(t, repo.Targets["team"].ToFile(filepath.Join(repo.Dir, "metadata", "1.team.json"), true))
+
+	client, err := NewClientWithOptions(filepath.Join(repo.Dir, "metadata"), ClientOptions{CacheDir: t.TempDir()})
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+
+	_, err = client.GetDelegationTree()
+	require.ErrorContains(t, err, "failed to load delegated role team")
+	_, err = os.Stat(filepath.Join(client.cacheDir, "metadata", "team.json"))
+	assert.True(t, errors.Is(err, os.ErrNotExist), "unverified metadata was cached")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.GetDelegationTreeContext(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
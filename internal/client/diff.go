@@ -0,0 +1,235 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+	"github.com/theupdateframework/go-tuf/v2/metadata/updater"
+)
+
+// ChangeKind says how an entry differs between two repository states
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+)
+
+// RepositoryState is everything a repository's verified metadata says, for comparing
+// two states of a repository
+type RepositoryState struct {
+	Roles       []RoleMetadata
+	Keys        []KeyInfo
+	Delegations []Delegation
+	// Targets lists the targets of every role, with DelegatedBy set to the
+	// delegated role that lists them
+	Targets []TargetInfo
+}
+
+// RoleState is the part of a role's metadata compared by Diff
+type RoleState struct {
+	Version int64     `json:"version"`
+	Expires time.Time `json:"expires"`
+}
+
+// DelegationState is a delegation compared by Diff, without its children
+type DelegationState struct {
+	Threshold        int      `json:"threshold"`
+	KeyIDs           []string `json:"keyids"`
+	Paths            []string `json:"paths,omitempty"`
+	PathHashPrefixes []string `json:"pathHashPrefixes,omitempty"`
+	Terminating      bool     `json:"terminating"`
+}
+
+// DiffEntry is a role, key, delegation or target that differs between two states
+type DiffEntry[T any] struct {
+	// Name is the role name, key ID, delegated role name or target path
+	Name string `json:"name"`
+	// Role is the parent of a delegated role, the delegating role of a
+	// delegation, or the role that lists a target
+	Role   string     `json:"role,omitempty"`
+	Change ChangeKind `json:"change"`
+	Old    *T         `json:"old,omitempty"`
+	New    *T         `json:"new,omitempty"`
+}
+
+// RepositoryDiff lists what changed between two repository states
+type RepositoryDiff struct {
+	Roles       []DiffEntry[RoleState]       `json:"roles"`
+	Keys        []DiffEntry[KeyInfo]         `json:"keys"`
+	Delegations []DiffEntry[DelegationState] `json:"delegations"`
+	Targets     []DiffEntry[TargetInfo]      `json:"targets"`
+}
+
+// Empty reports whether the two states are the same
+func (d *RepositoryDiff) Empty() bool {
+	return len(d.Roles) == 0 && len(d.Keys) == 0 && len(d.Delegations) == 0 && len(d.Targets) == 0
+}
+
+// GetState returns the repository's roles, keys, delegations and targets
+func (c *Client) GetState() (*RepositoryState, error) {
+	return c.GetStateContext(context.Background())
+}
+
+// GetStateContext returns the repository's roles, keys, delegations and the
+// targets of every role. Delegated metadata is loaded and verified like
+// GetDelegationTreeContext does.
+func (c *Client) GetStateContext(ctx context.Context) (state *RepositoryState, err error) {
+	ctx, span := c.telemetry.start(ctx, "tufzy.GetState")
+	defer func() { c.telemetry.end(ctx, span, "metadata", err) }()
+
+	state = &RepositoryState{}
+	if state.Roles, err = c.GetRolesContext(ctx); err != nil {
+		return nil, err
+	}
+	if state.Keys, err = c.GetKeysContext(ctx); err != nil {
+		return nil, err
+	}
+	if state.Delegations, err = c.GetDelegationTreeContext(ctx); err != nil {
+		return nil, err
+	}
+	if state.Targets, err = c.targetsOf(ctx, state.Delegations); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// targetsOf returns the targets listed by the top-level targets role and every
+// role in tree, sorted by name, with DelegatedBy set for delegated roles. The
+// roles in tree must already be verified, as GetDelegationTreeContext does.
+func (c *Client) targetsOf(ctx context.Context, tree []Delegation) (targets []TargetInfo, err error) {
+	err = c.withUpdater(ctx, func(u *updater.Updater) error {
+		trusted := u.GetTrustedMetadataSet()
+		if trusted.Targets[metadata.TARGETS] == nil {
+			return fmt.Errorf("top-level metadata not loaded, update first")
+		}
+		addTargets := func(role string) {
+			for name, targetFile := range trusted.Targets[role].Signed.Targets {
+				target := newTargetInfo(name, targetFile)
+				if role != metadata.TARGETS {
+					target.DelegatedBy = role
+				}
+				targets = append(targets, *target)
+			}
+		}
+		addTargets(metadata.TARGETS)
+		walkDelegatedRoles(metadata.TARGETS, tree, map[string]bool{}, func(_ string, delegation Delegation) {
+			addTargets(delegation.Name)
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Name != targets[j].Name {
+			return targets[i].Name < targets[j].Name
+		}
+		return targets[i].DelegatedBy < targets[j].DelegatedBy
+	})
+	return targets, nil
+}
+
+// Diff compares two repository states. Entries are listed in the order of the
+// after state, followed by the entries only in the before state.
+func Diff(before, after *RepositoryState) *RepositoryDiff {
+	roleEntries := func(state *RepositoryState) []keyedEntry[RoleState] {
+		entries := make([]keyedEntry[RoleState], 0, len(state.Roles))
+		for _, role := range state.Roles {
+			entries = append(entries, keyedEntry[RoleState]{role.Name, role.Parent, RoleState{role.Version, role.Expires}})
+		}
+		return entries
+	}
+	keyEntries := func(state *RepositoryState) []keyedEntry[KeyInfo] {
+		entries := make([]keyedEntry[KeyInfo], 0, len(state.Keys))
+		for _, key := range state.Keys {
+			entries = append(entries, keyedEntry[KeyInfo]{key.KeyID, "", key})
+		}
+		return entries
+	}
+	delegationEntries := func(state *RepositoryState) []keyedEntry[DelegationState] {
+		var entries []keyedEntry[DelegationState]
+		var walk func(parent string, tree []Delegation)
+		walk = func(parent string, tree []Delegation) {
+			for _, delegation := range tree {
+				entries = append(entries, keyedEntry[DelegationState]{delegation.Name, parent, DelegationState{
+					Threshold:        delegation.Threshold,
+					KeyIDs:           delegation.KeyIDs,
+					Paths:            delegation.Paths,
+					PathHashPrefixes: delegation.PathHashPrefixes,
+					Terminating:      delegation.Terminating,
+				}})
+				walk(delegation.Name, delegation.Children)
+			}
+		}
+		walk(metadata.TARGETS, state.Delegations)
+		return entries
+	}
+	targetEntries := func(state *RepositoryState) []keyedEntry[TargetInfo] {
+		entries := make([]keyedEntry[TargetInfo], 0, len(state.Targets))
+		for _, target := range state.Targets {
+			role := target.DelegatedBy
+			if role == "" {
+				role = metadata.TARGETS
+			}
+			entries = append(entries, keyedEntry[TargetInfo]{target.Name, role, target})
+		}
+		return entries
+	}
+
+	return &RepositoryDiff{
+		Roles:       diffEntries(roleEntries(before), roleEntries(after)),
+		Keys:        diffEntries(keyEntries(before), keyEntries(after)),
+		Delegations: diffEntries(delegationEntries(before), delegationEntries(after)),
+		Targets:     diffEntries(targetEntries(before), targetEntries(after)),
+	}
+}
+
+// keyedEntry is a value identified by its name and role
+type keyedEntry[T any] struct {
+	name  string
+	role  string
+	value T
+}
+
+// diffEntries compares entries with the same name and role
+func diffEntries[T any](before, after []keyedEntry[T]) []DiffEntry[T] {
+	type key struct{ name, role string }
+	beforeByKey := make(map[key]*T, len(before))
+	for i := range before {
+		beforeByKey[key{before[i].name, before[i].role}] = &before[i].value
+	}
+
+	changes := []DiffEntry[T]{}
+	seen := make(map[key]bool, len(after))
+	for i := range after {
+		entry := &after[i]
+		k := key{entry.name, entry.role}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		previous, found := beforeByKey[k]
+		switch {
+		case !found:
+			changes = append(changes, DiffEntry[T]{Name: entry.name, Role: entry.role, Change: ChangeAdded, New: &entry.value})
+		case !reflect.DeepEqual(*previous, entry.value):
+			changes = append(changes, DiffEntry[T]{Name: entry.name, Role: entry.role, Change: ChangeModified, Old: previous, New: &entry.value})
+		}
+	}
+	for i := range before {
+		entry := &before[i]
+		k := key{entry.name, entry.role}
+		if !seen[k] {
+			seen[k] = true
+			changes = append(changes, DiffEntry[T]{Name: entry.name, Role: entry.role, Change: ChangeRemoved, Old: &entry.value})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kipz/tufzy/internal/testrepo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+)
+
+func TestDiff(t *testing.T) {
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddTarget(metadata.TARGETS, "app.txt", []byte("v1"))
+	repo.AddTarget(metadata.TARGETS, "old.txt", []byte("old"))
+	repo.AddDelegation("team", []string{"team/*"})
+	repo.AddTarget("team", "team/tool.txt", []byte("tool"))
+	repo.Publish()
+
+	// Keep the first state before publishing the second over it
+	beforeDir := t.TempDir()
+	require.NoError(t, os.CopyFS(beforeDir, os.DirFS(repo.Dir)))
+
+	repo.AddTarget(metadata.TARGETS, "app.txt", []byte("v2"))
+	delete(repo.Targets[metadata.TARGETS].Signed.Targets, "old.txt")
+	delete(repo.Files, "old.txt")
+	repo.AddTarget(metadata.TARGETS, "new.txt", []byte("new"))
+	repo.Targets[metadata.TARGETS].Signed.Delegations.Roles[0].Paths = []string{"team/*", "shared/*"}
+	repo.AddDelegation("ops", []string{"ops/*"})
+	repo.Targets[metadata.TARGETS].Signed.Version++
+	repo.Snapshot.Signed.Version++
+	repo.Timestamp.Signed.Version++
+	repo.Publish()
+
+	stateOf := func(dir string) *RepositoryState {
+		client, err := NewClientWithOptions(filepath.Join(dir, "metadata"), ClientOptions{CacheDir: t.TempDir()})
+		require.NoError(t, err)
+		require.NoError(t, client.Update())
+		state, err := client.GetState()
+		require.NoError(t, err)
+		return state
+	}
+	before := stateOf(beforeDir)
+	after := stateOf(repo.Dir)
+
+	require.Len(t, before.Targets, 3)
+	assert.Equal(t, "team/tool.txt", before.Targets[2].Name)
+	assert.Equal(t, "team", before.Targets[2].DelegatedBy)
+	assert.True(t, Diff(before, before).Empty())
+
+	diff := Diff(before, after)
+	assert.False(t, diff.Empty())
+
+	changed := func(entries []DiffEntry[RoleState]) map[string]ChangeKind {
+		kinds := map[string]ChangeKind{}
+		for _, entry := range entries {
+			kinds[entry.Name] = entry.Change
+		}
+		return kinds
+	}
+	assert.Equal(t, map[string]ChangeKind{
+		metadata.TIMESTAMP: ChangeModified,
+		metadata.SNAPSHOT:  ChangeModified,
+		metadata.TARGETS:   ChangeModified,
+		"ops":              ChangeAdded,
+	}, changed(diff.Roles))
+	for _, entry := range diff.Roles {
+		if entry.Name == metadata.TARGETS {
+			assert.Equal(t, int64(1), entry.Old.Version)
+			assert.Equal(t, int64(2), entry.New.Version)
+		}
+	}
+
+	// Only the new delegation's key is new
+	require.Len(t, diff.Keys, 1)
+	assert.Equal(t, ChangeAdded, diff.Keys[0].Change)
+	assert.Equal(t, []string{"ops"}, diff.Keys[0].New.Roles)
+
+	require.Len(t, diff.Delegations, 2)
+	assert.Equal(t, DiffEntry[DelegationState]{
+		Name:   "team",
+		Role:   metadata.TARGETS,
+		Change: ChangeModified,
+		Old:    &DelegationState{Threshold: 1, KeyIDs: diff.Delegations[0].Old.KeyIDs, Paths: []string{"team/*"}},
+		New:    &DelegationState{Threshold: 1, KeyIDs: diff.Delegations[0].Old.KeyIDs, Paths: []string{"team/*", "shared/*"}},
+	}, diff.Delegations[0])
+	assert.Equal(t, "ops", diff.Delegations[1].Name)
+	assert.Equal(t, ChangeAdded, diff.Delegations[1].Change)
+
+	// Targets are listed in the after state's order, then removed targets
+	require.Len(t, diff.Targets, 3)
+	app := diff.Targets[0]
+	assert.Equal(t, "app.txt", app.Name)
+	assert.Equal(t, metadata.TARGETS, app.Role)
+	assert.Equal(t, ChangeModified, app.Change)
+	assert.NotEqual(t, app.Old.Hashes["sha256"], app.New.Hashes["sha256"])
+	assert.Equal(t, "new.txt", diff.Targets[1].Name)
+	assert.Equal(t, ChangeAdded, diff.Targets[1].Change)
+	assert.Nil(t, diff.Targets[1].Old)
+	assert.Equal(t, "old.txt", diff.Targets[2].Name)
+	assert.Equal(t, ChangeRemoved, diff.Targets[2].Change)
+	assert.Nil(t, diff.Targets[2].New)
+}
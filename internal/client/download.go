@@ -0,0 +1,284 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+)
+
+// TargetStreamer is implemented by fetchers that can write a target to a
+// writer instead of returning it in memory
+type TargetStreamer interface {
+	// StreamFile writes the file at urlPath to w, skipping its first offset
+	// bytes, and errors if the file is larger than maxLength or ctx is done.
+	// It returns the number of bytes written.
+	StreamFile(ctx context.Context, urlPath string, w io.Writer, offset, maxLength int64) (int64, error)
+}
+
+// ProgressFunc reports the bytes of a target downloaded so far out of its total length
+type ProgressFunc func(downloaded, total int64)
+
+// streamTarget downloads a target to destPath without holding it in memory.
+//
+// The target is hashed while it is written to a partial file in the cache,
+// keyed by its expected hash. An interrupted download leaves the partial file
+// behind so the next attempt resumes from where it stopped. The file is only
+// moved to destPath once its length and hashes verify. Cancelling ctx also
+// leaves the partial file to resume from. Downloads of the same content wait
+// for each other, since they share the partial file.
+func (c *Client) streamTarget(ctx context.Context, streamer TargetStreamer, targetFile *metadata.TargetFiles, destPath string, hashPrefixed bool) error {
+	alg, digest, err := primaryHash(targetFile)
+	if err != nil {
+		return err
+	}
+
+	partialDir := filepath.Join(c.cacheDir, "partial")
+	if err := os.MkdirAll(partialDir, 0755); err != nil {
+		return fmt.Errorf("failed to create partial download directory: %w", err)
+	}
+	partialPath := filepath.Join(partialDir, alg+"-"+digest)
+
+	partial, unlock, err := openPartial(ctx, partialPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	defer func() { _ = partial.Close() }()
+
+	verifier, err := newTargetVerifier(targetFile)
+	if err != nil {
+		return err
+	}
+
+	// Hash what was already downloaded so the whole file is verified
+	offset, err := io.Copy(verifier, partial)
+	if err != nil {
+		return fmt.Errorf("failed to read partial download: %w", err)
+	}
+
+	// A partial file longer than the target can't be resumed
+	if offset > targetFile.Length {
+		if err := partial.Truncate(0); err != nil {
+			return fmt.Errorf("failed to reset partial download: %w", err)
+		}
+		if _, err := partial.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to reset partial download: %w", err)
+		}
+		verifier.reset()
+		offset = 0
+	}
+
+	var w io.Writer = io.MultiWriter(partial, verifier)
+	if c.progress != nil {
+		c.progress(offset, targetFile.Length)
+		w = &progressWriter{w: w, downloaded: offset, total: targetFile.Length, progress: c.progress}
+	}
+
+	if offset < targetFile.Length {
+		targetURL := c.targetURL(targetFile.Path, digest, hashPrefixed)
+		c.logger.InfoContext(ctx, "downloading target", "target", targetFile.Path, "url", targetURL,
+			"length", targetFile.Length, "resumeFrom", offset)
+		if offset > 0 {
+			c.telemetry.cacheHit(ctx, "partial_target")
+		}
+		n, err := streamer.StreamFile(ctx, targetURL, w, offset, targetFile.Length)
+		c.telemetry.addBytes(ctx, "target", n)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Corrupted downloads are discarded so the next attempt starts over
+	if err := verifier.verify(); err != nil {
+		c.logger.WarnContext(ctx, "discarding target that failed verification", "target", targetFile.Path, "error", err)
+		_ = os.Remove(partialPath)
+		return err
+	}
+
+	// The partial file stays open, and so locked, until it has been moved
+	if err := partial.Sync(); err != nil {
+		return fmt.Errorf("failed to write target: %w", err)
+	}
+	return moveFile(partialPath, destPath)
+}
+
+// targetURL returns the remote URL of a target, adding the hash prefix for
+// consistent snapshots the same way the updater does
+func (c *Client) targetURL(targetPath, digest string, hashPrefixed bool) string {
+	remotePath := targetPath
+	if hashPrefixed {
+		dir, base := "", targetPath
+		if i := strings.LastIndex(targetPath, "/"); i >= 0 {
+			dir, base = targetPath[:i+1], targetPath[i+1:]
+		}
+		remotePath = dir + digest + "." + base
+	}
+	return strings.TrimSuffix(c.targetsURL, "/") + "/" + remotePath
+}
+
+// defaultTargetPath returns the cache location used when no destination is given
+func (c *Client) defaultTargetPath(targetPath string) string {
+	return filepath.Join(c.cacheDir, "targets", url.PathEscape(targetPath))
+}
+
+// primaryHash returns the hash used to name partial downloads and hash-prefixed
+// targets, preferring sha256
+func primaryHash(targetFile *metadata.TargetFiles) (string, string, error) {
+	if digest, ok := targetFile.Hashes["sha256"]; ok {
+		return "sha256", digest.String(), nil
+	}
+
+	algs := make([]string, 0, len(targetFile.Hashes))
+	for alg := range targetFile.Hashes {
+		algs = append(algs, alg)
+	}
+	if len(algs) == 0 {
+		return "", "", &metadata.ErrLengthOrHashMismatch{Msg: fmt.Sprintf("target %s has no hashes", targetFile.Path)}
+	}
+	sort.Strings(algs)
+	return algs[0], targetFile.Hashes[algs[0]].String(), nil
+}
+
+// targetVerifier computes the length and hashes of a target as it is written
+type targetVerifier struct {
+	target  *metadata.TargetFiles
+	hashers map[string]hash.Hash
+	length  int64
+}
+
+// newTargetVerifier creates a verifier for every hash listed for the target
+func newTargetVerifier(targetFile *metadata.TargetFiles) (*targetVerifier, error) {
+	hashers := make(map[string]hash.Hash, len(targetFile.Hashes))
+	for alg := range targetFile.Hashes {
+		switch alg {
+		case "sha256":
+			hashers[alg] = sha256.New()
+		case "sha512":
+			hashers[alg] = sha512.New()
+		default:
+			return nil, &metadata.ErrLengthOrHashMismatch{Msg: fmt.Sprintf("hash verification failed - unknown hashing algorithm - %s", alg)}
+		}
+	}
+	return &targetVerifier{target: targetFile, hashers: hashers}, nil
+}
+
+func (v *targetVerifier) Write(p []byte) (int, error) {
+	for _, hasher := range v.hashers {
+		hasher.Write(p)
+	}
+	v.length += int64(len(p))
+	return len(p), nil
+}
+
+// reset discards everything written so far
+func (v *targetVerifier) reset() {
+	for _, hasher := range v.hashers {
+		hasher.Reset()
+	}
+	v.length = 0
+}
+
+// verify checks the written content against the target's length and hashes
+func (v *targetVerifier) verify() error {
+	if v.length != v.target.Length {
+		return &metadata.ErrLengthOrHashMismatch{Msg: fmt.Sprintf("length verification failed - expected %d, got %d", v.target.Length, v.length)}
+	}
+	for alg, hasher := range v.hashers {
+		if hex.EncodeToString(hasher.Sum(nil)) != v.target.Hashes[alg].String() {
+			return &metadata.ErrLengthOrHashMismatch{Msg: fmt.Sprintf("hash verification failed - mismatch for algorithm %s", alg)}
+		}
+	}
+	return nil
+}
+
+// progressWriter reports the running total of bytes written
+type progressWriter struct {
+	w          io.Writer
+	downloaded int64
+	total      int64
+	progress   ProgressFunc
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.downloaded += int64(n)
+	p.progress(p.downloaded, p.total)
+	return n, err
+}
+
+// contextReader stops reading once its context is done, so copies from
+// local files and layers can be cancelled like HTTP bodies
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// copyWithLimit copies up to limit bytes from r to w and errors if r holds more
+func copyWithLimit(w io.Writer, r io.Reader, limit int64) (int64, error) {
+	n, err := io.CopyN(w, r, limit)
+	if err != nil && err != io.EOF {
+		return n, err
+	}
+	if err == nil {
+		// Probe for content past the limit without writing it
+		var extra [1]byte
+		if m, _ := io.ReadFull(r, extra[:]); m > 0 {
+			return n, &metadata.ErrDownloadLengthMismatch{Msg: fmt.Sprintf("download failed, length is larger than expected %d", limit)}
+		}
+	}
+	return n, nil
+}
+
+// moveFile renames src to dst, copying through a temporary file next to dst
+// when they are on different filesystems
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to write target: %w", err)
+	}
+	defer func() { _ = in.Close() }()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".tufzy-*")
+	if err != nil {
+		return fmt.Errorf("failed to write target: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write target: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write target: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write target: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return fmt.Errorf("failed to write target: %w", err)
+	}
+	_ = os.Remove(src)
+	return nil
+}
@@ -0,0 +1,267 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/kipz/tufzy/internal/testrepo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+)
+
+func TestFilesystemFetcher_StreamFile(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 100))
+
+	// Serve content with Range support, or ignoring ranges under /norange
+	mux := http.NewServeMux()
+	mux.HandleFunc("/file", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(content))
+	})
+	mux.HandleFunc("/norange", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(content)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	localPath := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(localPath, content, 0644))
+
+	tests := []struct {
+		name      string
+		url       string
+		offset    int64
+		maxLength int64
+		wantErr   string
+	}{
+		{"http full", server.URL + "/file", 0, int64(len(content)), ""},
+		{"http resume", server.URL + "/file", 420, int64(len(content)), ""},
+		{"http server ignores range", server.URL + "/norange", 420, int64(len(content)), ""},
+		{"http too large", server.URL + "/file", 0, 999, "larger than expected"},
+		{"http not found", server.URL + "/missing", 0, int64(len(content)), "status code: 404"},
+		{"file full", "file://" + localPath, 0, int64(len(content)), ""},
+		{"file resume", "file://" + localPath, 999, int64(len(content)), ""},
+		{"file too large", "file://" + localPath, 10, 999, "larger than expected"},
+		{"file not found", "file://" + localPath + ".missing", 0, int64(len(content)), "status code: 404"},
+	}
+
+	fetcher := NewFilesystemFetcher()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := fetcher.StreamFile(context.Background(), tt.url, &buf, tt.offset, tt.maxLength)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(content))-tt.offset, n)
+			assert.Equal(t, content[tt.offset:], buf.Bytes())
+		})
+	}
+}
+
+// rangeRecorder serves a directory and records the Range header of every target request
+type rangeRecorder struct {
+	mu     sync.Mutex
+	ranges []string
+}
+
+func (rr *rangeRecorder) wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/targets/") {
+			rr.mu.Lock()
+			rr.ranges = append(rr.ranges, r.Header.Get("Range"))
+			rr.mu.Unlock()
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func TestClient_DownloadTarget_Streaming(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	content := bytes.Repeat([]byte("large target "), 10000)
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddTarget("targets", "large.bin", content)
+	repo.Publish()
+
+	recorder := &rangeRecorder{}
+	server := httptest.NewServer(http.StripPrefix("/repo", recorder.wrap(http.FileServer(http.Dir(repo.Dir)))))
+	defer server.Close()
+
+	client, err := NewClient(server.URL + "/repo/metadata")
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+
+	partialPath := filepath.Join(client.cacheDir, "partial", "sha256-"+repo.TargetHash("large.bin"))
+
+	t.Run("downloads and verifies", func(t *testing.T) {
+		recorder.ranges = nil
+		destPath := filepath.Join(t.TempDir(), "large.bin")
+
+		info, err := client.DownloadTarget("large.bin", destPath)
+		require.NoError(t, err)
+		assert.Equal(t, destPath, info.Name)
+		assert.Equal(t, int64(len(content)), info.Length)
+
+		data, err := os.ReadFile(destPath)
+		require.NoError(t, err)
+		assert.Equal(t, content, data)
+		assert.Equal(t, []string{""}, recorder.ranges)
+		assert.NoFileExists(t, partialPath)
+	})
+
+	t.Run("resumes partial download", func(t *testing.T) {
+		recorder.ranges = nil
+		destPath := filepath.Join(t.TempDir(), "large.bin")
+		require.NoError(t, os.MkdirAll(filepath.Dir(partialPath), 0755))
+		require.NoError(t, os.WriteFile(partialPath, content[:50000], 0644))
+
+		_, err := client.DownloadTarget("large.bin", destPath)
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(destPath)
+		require.NoError(t, err)
+		assert.Equal(t, content, data)
+		assert.Equal(t, []string{"bytes=50000-"}, recorder.ranges)
+		assert.NoFileExists(t, partialPath)
+	})
+
+	t.Run("complete partial download is not fetched again", func(t *testing.T) {
+		recorder.ranges = nil
+		destPath := filepath.Join(t.TempDir(), "large.bin")
+		require.NoError(t, os.WriteFile(partialPath, content, 0644))
+
+		_, err := client.DownloadTarget("large.bin", destPath)
+		require.NoError(t, err)
+		assert.Empty(t, recorder.ranges)
+		assert.FileExists(t, destPath)
+	})
+
+	t.Run("corrupted partial download is discarded", func(t *testing.T) {
+		destPath := filepath.Join(t.TempDir(), "large.bin")
+		require.NoError(t, os.WriteFile(partialPath, bytes.Repeat([]byte("x"), 50000), 0644))
+
+		_, err := client.DownloadTarget("large.bin", destPath)
+		var mismatch *metadata.ErrLengthOrHashMismatch
+		require.ErrorAs(t, err, &mismatch)
+		assert.NoFileExists(t, destPath)
+		assert.NoFileExists(t, partialPath)
+
+		// The next attempt starts over
+		_, err = client.DownloadTarget("large.bin", destPath)
+		require.NoError(t, err)
+		data, err := os.ReadFile(destPath)
+		require.NoError(t, err)
+		assert.Equal(t, content, data)
+	})
+
+	t.Run("reports progress", func(t *testing.T) {
+		var updates []int64
+		client.progress = func(downloaded, total int64) {
+			assert.Equal(t, int64(len(content)), total)
+			updates = append(updates, downloaded)
+		}
+		defer func() { client.progress = nil }()
+
+		require.NoError(t, os.WriteFile(partialPath, content[:50000], 0644))
+		_, err := client.DownloadTarget("large.bin", filepath.Join(t.TempDir(), "large.bin"))
+		require.NoError(t, err)
+
+		require.NotEmpty(t, updates)
+		assert.Equal(t, int64(50000), updates[0])
+		assert.Equal(t, int64(len(content)), updates[len(updates)-1])
+		assert.IsNonDecreasing(t, updates)
+	})
+
+	t.Run("oversized partial download is restarted", func(t *testing.T) {
+		recorder.ranges = nil
+		destPath := filepath.Join(t.TempDir(), "large.bin")
+		require.NoError(t, os.WriteFile(partialPath, append(content, 'x'), 0644))
+
+		_, err := client.DownloadTarget("large.bin", destPath)
+		require.NoError(t, err)
+		assert.Equal(t, []string{""}, recorder.ranges)
+	})
+}
+
+func TestRegistryFetcher_StreamFile(t *testing.T) {
+	content := bytes.Repeat([]byte("layer content "), 1000)
+	img, err := newTargetImage("file.txt", content)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeLayout(t, dir, map[string]v1.Image{"file.txt": img}, nil)
+	fetcher, err := NewLayoutFetcher(context.Background(), OCILayoutScheme+dir+":latest", OCILayoutScheme+dir)
+	require.NoError(t, err)
+	targetURL := OCILayoutScheme + dir + "/file.txt"
+
+	tests := []struct {
+		name      string
+		offset    int64
+		maxLength int64
+		wantErr   bool
+	}{
+		{"full", 0, int64(len(content)), false},
+		{"resume", 1234, int64(len(content)), false},
+		{"too large", 0, 100, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			n, err := fetcher.StreamFile(context.Background(), targetURL, &buf, tt.offset, tt.maxLength)
+			if tt.wantErr {
+				var mismatch *metadata.ErrDownloadLengthMismatch
+				require.ErrorAs(t, err, &mismatch)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(content))-tt.offset, n)
+			assert.Equal(t, content[tt.offset:], buf.Bytes())
+		})
+	}
+}
+
+func TestClient_DownloadTarget_ConcurrentSameTarget(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 256*1024)
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddTarget("targets", "big.bin", content)
+	repo.Publish()
+
+	client, err := NewClientWithOptions(filepath.Join(repo.Dir, "metadata"), ClientOptions{CacheDir: t.TempDir()})
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+
+	// Every download shares one partial file
+	destDir := t.TempDir()
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = client.DownloadTarget("big.bin", filepath.Join(destDir, fmt.Sprintf("%d.bin", i)))
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		require.NoError(t, err)
+		data, err := os.ReadFile(filepath.Join(destDir, fmt.Sprintf("%d.bin", i)))
+		require.NoError(t, err)
+		assert.Equal(t, content, data)
+	}
+	assert.Empty(t, partialLocks.locks)
+}
@@ -1,39 +1,104 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/theupdateframework/go-tuf/v2/metadata"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // FilesystemFetcher implements fetcher.Fetcher for both HTTP and file:// URLs
 type FilesystemFetcher struct {
-	httpClient *http.Client
+	httpClient   *http.Client
+	streamClient *http.Client // Used for targets, which may take longer than any fixed timeout
+	validators   *ValidatorStore
+	metadataDir  string
+	logger       *slog.Logger
+	telemetry    *telemetry
+}
+
+// FilesystemFetcherOptions contains optional configuration for the filesystem fetcher
+type FilesystemFetcherOptions struct {
+	// Validators enables conditional requests for timestamp.json
+	Validators *ValidatorStore
+	// MetadataDir is the local metadata cache served when the timestamp is not modified
+	MetadataDir string
+	// Limiter bounds concurrent HTTP requests and bandwidth (default: unlimited)
+	Limiter *Limiter
+	// WrapTransport wraps the HTTP transport inside the limiter, e.g. to sign requests
+	WrapTransport func(http.RoundTripper) http.RoundTripper
+	// Logger receives a debug record for every HTTP request (default: discard)
+	Logger *slog.Logger
+	// TracerProvider records a span for every HTTP request (default: the global provider)
+	TracerProvider trace.TracerProvider
+	// MeterProvider counts timestamp revalidations (default: the global provider)
+	MeterProvider metric.MeterProvider
 }
 
 // NewFilesystemFetcher creates a new fetcher that supports file:// and http(s)://
 func NewFilesystemFetcher() *FilesystemFetcher {
+	return NewFilesystemFetcherWithOptions(FilesystemFetcherOptions{})
+}
+
+// NewFilesystemFetcherWithOptions creates a new filesystem fetcher with custom options
+func NewFilesystemFetcherWithOptions(options FilesystemFetcherOptions) *FilesystemFetcher {
+	// Only bound the wait for response headers when streaming targets
+	streamTransport := http.DefaultTransport.(*http.Transport).Clone()
+	streamTransport.ResponseHeaderTimeout = 30 * time.Second
+
+	telemetry := newTelemetry(options.TracerProvider, options.MeterProvider)
+	var transport, streamBase http.RoundTripper = http.DefaultTransport, streamTransport
+	transport = traceTransport(logTransport(transport, options.Logger), telemetry)
+	streamBase = traceTransport(logTransport(streamBase, options.Logger), telemetry)
+	if options.WrapTransport != nil {
+		transport = options.WrapTransport(transport)
+		streamBase = options.WrapTransport(streamBase)
+	}
+
 	return &FilesystemFetcher{
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Transport: options.Limiter.Transport(transport),
+			Timeout:   30 * time.Second,
 		},
+		streamClient: &http.Client{
+			Transport: options.Limiter.Transport(streamBase),
+		},
+		validators:  options.Validators,
+		metadataDir: options.MetadataDir,
+		logger:      loggerOrDiscard(options.Logger),
+		telemetry:   telemetry,
 	}
 }
 
 // DownloadFile downloads a file from the provided URL, supporting both HTTP and file:// schemes
-func (f *FilesystemFetcher) DownloadFile(urlPath string, maxLength int64, _ time.Duration) ([]byte, error) {
+func (f *FilesystemFetcher) DownloadFile(urlPath string, maxLength int64, timeout time.Duration) ([]byte, error) {
+	return f.DownloadFileContext(context.Background(), urlPath, maxLength, timeout)
+}
+
+// DownloadFileContext is DownloadFile with a context that cancels the request
+func (f *FilesystemFetcher) DownloadFileContext(ctx context.Context, urlPath string, maxLength int64, _ time.Duration) ([]byte, error) {
 	parsedURL, err := url.Parse(urlPath)
 	if err != nil {
 		return nil, fmt.Errorf("invalid URL: %w", err)
 	}
 
 	if parsedURL.Scheme == "file" {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		// Local filesystem access
 		filePath := parsedURL.Path
 		data, err := os.ReadFile(filePath)
@@ -53,19 +118,35 @@ func (f *FilesystemFetcher) DownloadFile(urlPath string, maxLength int64, _ time
 	}
 
 	// HTTP(S) access
-	req, err := http.NewRequest("GET", urlPath, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", urlPath, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("User-Agent", "tufzy/1.0")
 
+	// Revalidate the cached timestamp instead of downloading it again
+	cached, conditional := f.cachedTimestamp(urlPath)
+	if conditional {
+		cached.validator.setConditionalHeaders(req)
+	}
+
 	resp, err := f.httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
+	// The current timestamp is still valid; the updater still checks its expiry
+	if resp.StatusCode == http.StatusNotModified && conditional {
+		f.logger.DebugContext(ctx, "timestamp not modified, using cached copy", "url", urlPath)
+		f.telemetry.cacheHit(ctx, "timestamp")
+		return cached.data, nil
+	}
+	if f.revalidates(urlPath) {
+		f.telemetry.cacheMiss(ctx, "timestamp")
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		return nil, &metadata.ErrDownloadHTTP{StatusCode: resp.StatusCode, URL: urlPath}
 	}
@@ -84,5 +165,119 @@ func (f *FilesystemFetcher) DownloadFile(urlPath string, maxLength int64, _ time
 		return nil, fmt.Errorf("response size exceeds max length %d", maxLength)
 	}
 
+	f.storeValidator(urlPath, resp, data)
 	return data, nil
 }
+
+// missingInitialRoot lets local repositories, which may only hold the current
+// root, fall back to root.json. Over HTTP(S) the initial root is required.
+func (f *FilesystemFetcher) missingInitialRoot(urlPath string, err error) bool {
+	return strings.HasPrefix(urlPath, "file://") && isHTTPStatus(err, http.StatusNotFound)
+}
+
+// revalidates reports whether urlPath is fetched with conditional requests.
+// Only timestamp.json is polled; every other file is versioned or hash-addressed.
+func (f *FilesystemFetcher) revalidates(urlPath string) bool {
+	return f.validators != nil && f.metadataDir != "" && path.Base(urlPath) == "timestamp.json"
+}
+
+// cachedMetadata is a locally cached metadata file with the validators it was served with
+type cachedMetadata struct {
+	data      []byte
+	validator Validator
+}
+
+// cachedTimestamp returns the cached timestamp.json for a conditional request.
+// The validators are only used while the local copy is the body they were returned with.
+func (f *FilesystemFetcher) cachedTimestamp(urlPath string) (cachedMetadata, bool) {
+	if !f.revalidates(urlPath) {
+		return cachedMetadata{}, false
+	}
+
+	validator, found := f.validators.Get(urlPath)
+	if !found {
+		return cachedMetadata{}, false
+	}
+
+	data, err := os.ReadFile(filepath.Join(f.metadataDir, "timestamp.json"))
+	if err != nil || sha256Hex(data) != validator.SHA256 {
+		return cachedMetadata{}, false
+	}
+	return cachedMetadata{data: data, validator: validator}, true
+}
+
+// storeValidator records the validators of a downloaded timestamp.json
+func (f *FilesystemFetcher) storeValidator(urlPath string, resp *http.Response, data []byte) {
+	if !f.revalidates(urlPath) {
+		return
+	}
+
+	// A failed write only costs a full download next time
+	if validator, ok := validatorFor(resp, data); ok {
+		_ = f.validators.Put(urlPath, validator)
+	} else {
+		_ = f.validators.Delete(urlPath)
+	}
+}
+
+// StreamFile writes the file at urlPath to w starting at offset, resuming HTTP
+// downloads with a Range request, and errors if the file is larger than maxLength.
+func (f *FilesystemFetcher) StreamFile(ctx context.Context, urlPath string, w io.Writer, offset, maxLength int64) (int64, error) {
+	parsedURL, err := url.Parse(urlPath)
+	if err != nil {
+		return 0, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	if parsedURL.Scheme == "file" {
+		// Local filesystem access
+		file, err := os.Open(parsedURL.Path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return 0, &metadata.ErrDownloadHTTP{StatusCode: 404, URL: urlPath}
+			}
+			return 0, fmt.Errorf("failed to read file: %w", err)
+		}
+		defer func() { _ = file.Close() }()
+
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			return 0, fmt.Errorf("failed to read file: %w", err)
+		}
+		return copyWithLimit(w, &contextReader{ctx: ctx, r: file}, maxLength-offset)
+	}
+
+	// HTTP(S) access
+	req, err := http.NewRequestWithContext(ctx, "GET", urlPath, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("User-Agent", "tufzy/1.0")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := f.streamClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		var start int64
+		if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-", &start); err != nil || start != offset {
+			return 0, fmt.Errorf("unexpected Content-Range %q for offset %d", resp.Header.Get("Content-Range"), offset)
+		}
+	case http.StatusOK:
+		// The server ignored the range, so skip the part already downloaded
+		if offset > 0 {
+			if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil {
+				return 0, err
+			}
+		}
+	default:
+		return 0, &metadata.ErrDownloadHTTP{StatusCode: resp.StatusCode, URL: urlPath}
+	}
+
+	return copyWithLimit(w, resp.Body, maxLength-offset)
+}
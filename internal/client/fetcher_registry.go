@@ -0,0 +1,253 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/theupdateframework/go-tuf/v2/metadata/fetcher"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Layout describes where a repository keeps its metadata and targets
+type Layout struct {
+	// MetadataURL is the normalized metadata URL passed to the updater
+	MetadataURL string
+	// TargetsURL is the base URL targets are downloaded from
+	TargetsURL string
+	// TufOnCiGit is set for tuf-on-ci git checkouts (unversioned metadata, no hash prefixes)
+	TufOnCiGit bool
+}
+
+// LayoutDetector normalizes a metadata URL and detects the repository layout.
+// targetsURL is the targets URL given by the user, or empty to use the default.
+type LayoutDetector func(metadataURL, targetsURL string) (Layout, error)
+
+// FetcherConstructor creates the fetcher for a repository layout
+type FetcherConstructor func(ctx context.Context, layout Layout, options FetcherOptions) (fetcher.Fetcher, error)
+
+// FetcherOptions contains the client settings passed to fetcher constructors
+type FetcherOptions struct {
+	// CacheDir is the repository's cache directory
+	CacheDir string
+	// MetadataDir is the directory holding the trusted metadata
+	MetadataDir string
+	// Limiter bounds concurrent requests and bandwidth (nil means unlimited)
+	Limiter *Limiter
+	// Logger receives the fetcher's request and cache records (nil means discard)
+	Logger *slog.Logger
+	// TracerProvider records the fetcher's request spans (nil means the global provider)
+	TracerProvider trace.TracerProvider
+	// MeterProvider records the fetcher's cache metrics (nil means the global provider)
+	MeterProvider metric.MeterProvider
+}
+
+// FetcherFactory handles repositories for one URL scheme
+type FetcherFactory struct {
+	// DetectLayout normalizes URLs and detects the repository layout
+	DetectLayout LayoutDetector
+	// NewFetcher creates the fetcher for a detected layout
+	NewFetcher FetcherConstructor
+}
+
+var (
+	fetchersMu sync.RWMutex
+	fetchers   = map[string]FetcherFactory{}
+)
+
+func init() {
+	httpFactory := FetcherFactory{DetectLayout: detectHTTPLayout, NewFetcher: newFilesystemFetcher}
+	RegisterFetcher("http", httpFactory)
+	RegisterFetcher("https", httpFactory)
+	RegisterFetcher("file", FetcherFactory{DetectLayout: detectFileLayout, NewFetcher: newFilesystemFetcher})
+	RegisterFetcher("oci", FetcherFactory{DetectLayout: detectOCILayout, NewFetcher: newRegistryFetcher})
+	RegisterFetcher("oci-layout", FetcherFactory{DetectLayout: detectOCILayout, NewFetcher: newLayoutFetcher})
+	RegisterFetcher("s3", NewS3FetcherFactory(S3FetcherOptions{}))
+}
+
+// RegisterFetcher registers the factory for URLs with the given scheme (without "://"),
+// replacing any existing registration. Local paths without a scheme use "file".
+func RegisterFetcher(scheme string, factory FetcherFactory) {
+	if factory.DetectLayout == nil || factory.NewFetcher == nil {
+		panic(fmt.Sprintf("client: fetcher for scheme %q must set DetectLayout and NewFetcher", scheme))
+	}
+
+	fetchersMu.Lock()
+	defer fetchersMu.Unlock()
+	fetchers[strings.ToLower(scheme)] = factory
+}
+
+// RegisteredSchemes returns the schemes with a registered fetcher, sorted
+func RegisteredSchemes() []string {
+	fetchersMu.RLock()
+	defer fetchersMu.RUnlock()
+
+	schemes := make([]string, 0, len(fetchers))
+	for scheme := range fetchers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// fetcherFor returns the factory registered for a URL's scheme
+func fetcherFor(rawURL string) (FetcherFactory, error) {
+	scheme := urlScheme(rawURL)
+
+	fetchersMu.RLock()
+	defer fetchersMu.RUnlock()
+	factory, found := fetchers[scheme]
+	if !found {
+		return FetcherFactory{}, fmt.Errorf("unsupported URL scheme %q (supported: %s)", scheme, strings.Join(sortedKeys(fetchers), ", "))
+	}
+	return factory, nil
+}
+
+// urlScheme returns the lowercased scheme of a URL, or "file" for a local path
+func urlScheme(rawURL string) string {
+	scheme, _, found := strings.Cut(rawURL, "://")
+	if !found || scheme == "" || strings.ContainsAny(scheme, `/\`) {
+		return "file"
+	}
+	return strings.ToLower(scheme)
+}
+
+func sortedKeys(m map[string]FetcherFactory) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// detectHTTPLayout assumes targets live in ../targets relative to the metadata
+func detectHTTPLayout(metadataURL, targetsURL string) (Layout, error) {
+	parsedURL, err := url.Parse(metadataURL)
+	if err != nil {
+		return Layout{}, fmt.Errorf("invalid metadata URL: %w", err)
+	}
+
+	if targetsURL == "" {
+		parsedURL.Path = path.Join(path.Dir(parsedURL.Path), "targets")
+		targetsURL = parsedURL.String()
+	}
+	return Layout{MetadataURL: metadataURL, TargetsURL: targetsURL}, nil
+}
+
+// detectFileLayout converts local paths to file:// URLs, assumes targets live in
+// ../targets and detects tuf-on-ci git checkouts
+func detectFileLayout(metadataURL, targetsURL string) (Layout, error) {
+	absPath, err := filepath.Abs(strings.TrimPrefix(metadataURL, "file://"))
+	if err != nil {
+		return Layout{}, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	if targetsURL == "" {
+		// Targets are in ../targets relative to metadata
+		targetsURL = "file://" + filepath.Join(filepath.Dir(absPath), "targets")
+	}
+
+	return Layout{
+		MetadataURL: "file://" + absPath,
+		TargetsURL:  targetsURL,
+		TufOnCiGit:  IsTufOnCiGit(absPath),
+	}, nil
+}
+
+// IsTufOnCiGit reports whether a local metadata directory is a tuf-on-ci git
+// checkout. Unversioned timestamp.json, snapshot.json and targets.json indicate
+// a tuf-on-ci git layout rather than standard TUF (N.snapshot.json, etc).
+func IsTufOnCiGit(metadataDir string) bool {
+	for _, name := range []string{"timestamp.json", "snapshot.json", "targets.json"} {
+		if _, err := os.Stat(filepath.Join(metadataDir, name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// detectOCILayout requires a targets URL with the same OCI scheme as the metadata
+func detectOCILayout(metadataURL, targetsURL string) (Layout, error) {
+	if targetsURL == "" {
+		return Layout{}, fmt.Errorf("targets URL is required for OCI repositories")
+	}
+	if _, _, targets := detectOCI(metadataURL, targetsURL); targets == "" {
+		return Layout{}, fmt.Errorf("targets URL must use the same scheme as the metadata URL (%s)", ociSchemeOf(metadataURL))
+	}
+	return Layout{MetadataURL: metadataURL, TargetsURL: targetsURL}, nil
+}
+
+// newFilesystemFetcher creates the fetcher for HTTP(S) and local repositories
+func newFilesystemFetcher(_ context.Context, layout Layout, options FetcherOptions) (fetcher.Fetcher, error) {
+	// Poll timestamp.json with conditional requests
+	fsOptions := FilesystemFetcherOptions{
+		Validators:     NewValidatorStore(filepath.Join(options.CacheDir, "validators.json")),
+		MetadataDir:    options.MetadataDir,
+		Limiter:        options.Limiter,
+		Logger:         options.Logger,
+		TracerProvider: options.TracerProvider,
+		MeterProvider:  options.MeterProvider,
+	}
+
+	// tuf-on-ci git checkouts map versioned names to unversioned files
+	if layout.TufOnCiGit {
+		return NewTufOnCiFetcherWithOptions(layout.MetadataURL, fsOptions), nil
+	}
+	return NewFilesystemFetcherWithOptions(fsOptions), nil
+}
+
+// newRegistryFetcher creates the fetcher for OCI registries
+func newRegistryFetcher(ctx context.Context, layout Layout, options FetcherOptions) (fetcher.Fetcher, error) {
+	// Persist manifests and layers by digest so they are reused across runs
+	cache, err := NewImageCacheWithOptions(ImageCacheOptions{
+		Dir:           filepath.Join(options.CacheDir, "oci"),
+		Logger:        options.Logger,
+		MeterProvider: options.MeterProvider,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	registry, err := NewRegistryFetcherWithOptions(ctx, layout.MetadataURL, layout.TargetsURL, RegistryFetcherOptions{
+		Cache:          cache,
+		Limiter:        options.Limiter,
+		Logger:         options.Logger,
+		TracerProvider: options.TracerProvider,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create registry fetcher: %w", err)
+	}
+	return registry, nil
+}
+
+// newLayoutFetcher creates the fetcher for OCI image layouts on disk
+func newLayoutFetcher(ctx context.Context, layout Layout, options FetcherOptions) (fetcher.Fetcher, error) {
+	// Layouts are already on disk, so there is nothing worth persisting
+	cache, err := NewImageCacheWithOptions(ImageCacheOptions{
+		Logger:        options.Logger,
+		MeterProvider: options.MeterProvider,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	registry, err := NewLayoutFetcherWithOptions(ctx, layout.MetadataURL, layout.TargetsURL, LayoutFetcherOptions{
+		Cache:          cache,
+		Limiter:        options.Limiter,
+		Logger:         options.Logger,
+		TracerProvider: options.TracerProvider,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create layout fetcher: %w", err)
+	}
+	return registry, nil
+}
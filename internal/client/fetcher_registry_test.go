@@ -0,0 +1,222 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kipz/tufzy/internal/testrepo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+	"github.com/theupdateframework/go-tuf/v2/metadata/fetcher"
+)
+
+func TestURLScheme(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/metadata", "https"},
+		{"HTTP://example.com/metadata", "http"},
+		{"file:///repo/metadata", "file"},
+		{"oci://registry.example.com/repo:tag", "oci"},
+		{"oci-layout:///path/to/layout:tag", "oci-layout"},
+		{"/abs/path/metadata", "file"},
+		{"./metadata", "file"},
+		{"metadata", "file"},
+		{"./dir/with://colon", "file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			assert.Equal(t, tt.want, urlScheme(tt.url))
+		})
+	}
+}
+
+func TestDetectLayout(t *testing.T) {
+	tufOnCiDir := filepath.Join(t.TempDir(), "metadata")
+	require.NoError(t, os.MkdirAll(tufOnCiDir, 0755))
+	for _, name := range []string{"timestamp.json", "snapshot.json", "targets.json"} {
+		require.NoError(t, os.WriteFile(filepath.Join(tufOnCiDir, name), []byte("{}"), 0644))
+	}
+	standardDir := filepath.Join(t.TempDir(), "metadata")
+
+	tests := []struct {
+		name        string
+		metadataURL string
+		targetsURL  string
+		want        Layout
+		wantErr     string
+	}{
+		{
+			name:        "https default targets",
+			metadataURL: "https://example.com/repo/metadata",
+			want:        Layout{MetadataURL: "https://example.com/repo/metadata", TargetsURL: "https://example.com/repo/targets"},
+		},
+		{
+			name:        "https metadata at the root",
+			metadataURL: "https://example.com/metadata",
+			want:        Layout{MetadataURL: "https://example.com/metadata", TargetsURL: "https://example.com/targets"},
+		},
+		{
+			name:        "https explicit targets",
+			metadataURL: "https://example.com/repo/metadata",
+			targetsURL:  "https://cdn.example.com/targets",
+			want:        Layout{MetadataURL: "https://example.com/repo/metadata", TargetsURL: "https://cdn.example.com/targets"},
+		},
+		{
+			name:        "local standard layout",
+			metadataURL: standardDir,
+			want:        Layout{MetadataURL: "file://" + standardDir, TargetsURL: "file://" + filepath.Join(filepath.Dir(standardDir), "targets")},
+		},
+		{
+			name:        "file URL tuf-on-ci git layout",
+			metadataURL: "file://" + tufOnCiDir,
+			want:        Layout{MetadataURL: "file://" + tufOnCiDir, TargetsURL: "file://" + filepath.Join(filepath.Dir(tufOnCiDir), "targets"), TufOnCiGit: true},
+		},
+		{
+			name:        "oci",
+			metadataURL: "oci://registry.example.com/metadata:latest",
+			targetsURL:  "oci://registry.example.com/targets",
+			want:        Layout{MetadataURL: "oci://registry.example.com/metadata:latest", TargetsURL: "oci://registry.example.com/targets"},
+		},
+		{
+			name:        "oci without targets",
+			metadataURL: "oci://registry.example.com/metadata:latest",
+			wantErr:     "targets URL is required",
+		},
+		{
+			name:        "s3 default targets",
+			metadataURL: "s3://bucket/prod/metadata",
+			want:        Layout{MetadataURL: "s3://bucket/prod/metadata", TargetsURL: "s3://bucket/prod/targets"},
+		},
+		{
+			name:        "s3 metadata at the bucket root",
+			metadataURL: "s3://bucket/metadata",
+			want:        Layout{MetadataURL: "s3://bucket/metadata", TargetsURL: "s3://bucket/targets"},
+		},
+		{
+			name:        "s3 with https targets",
+			metadataURL: "s3://bucket/metadata",
+			targetsURL:  "https://cdn.example.com/targets",
+			wantErr:     "same scheme",
+		},
+		{
+			name:        "oci layout with registry targets",
+			metadataURL: "oci-layout:///layout:latest",
+			targetsURL:  "oci://registry.example.com/targets",
+			wantErr:     "same scheme",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factory, err := fetcherFor(tt.metadataURL)
+			require.NoError(t, err)
+
+			got, err := factory.DetectLayout(tt.metadataURL, tt.targetsURL)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFetcherFor_UnsupportedScheme(t *testing.T) {
+	_, err := fetcherFor("ftp://example.com/metadata")
+	require.ErrorContains(t, err, `unsupported URL scheme "ftp"`)
+	assert.Contains(t, err.Error(), "https")
+
+	_, err = newClient(context.Background(), "ftp://example.com/metadata", t.TempDir(), ClientOptions{})
+	require.ErrorContains(t, err, "unsupported URL scheme")
+}
+
+// dirFetcher serves mem://<name>/... URLs from a local directory
+type dirFetcher struct {
+	dir string
+}
+
+func (f dirFetcher) DownloadFile(urlPath string, maxLength int64, _ time.Duration) ([]byte, error) {
+	_, rest, _ := strings.Cut(strings.TrimPrefix(urlPath, "mem://"), "/")
+	data, err := os.ReadFile(filepath.Join(f.dir, filepath.FromSlash(rest)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, &metadata.ErrDownloadHTTP{StatusCode: 404, URL: urlPath}
+	}
+	if err == nil && int64(len(data)) > maxLength {
+		return nil, &metadata.ErrDownloadLengthMismatch{Msg: "too large"}
+	}
+	return data, err
+}
+
+func TestRegisterFetcher_CustomScheme(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddTarget("targets", "file.txt", []byte("custom transport"))
+	repo.Publish()
+
+	RegisterFetcher("mem", FetcherFactory{
+		DetectLayout: func(metadataURL, targetsURL string) (Layout, error) {
+			return Layout{MetadataURL: metadataURL, TargetsURL: "mem://repo/targets"}, nil
+		},
+		NewFetcher: func(_ context.Context, _ Layout, options FetcherOptions) (fetcher.Fetcher, error) {
+			assert.NotEmpty(t, options.CacheDir)
+			return dirFetcher{dir: repo.Dir}, nil
+		},
+	})
+	t.Cleanup(func() {
+		fetchersMu.Lock()
+		delete(fetchers, "mem")
+		fetchersMu.Unlock()
+	})
+	assert.Contains(t, RegisteredSchemes(), "mem")
+
+	client, err := NewClient("mem://repo/metadata")
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+
+	// Fetchers that can't stream fall back to the updater's download
+	destPath := filepath.Join(t.TempDir(), "file.txt")
+	_, err = client.DownloadTarget("file.txt", destPath)
+	require.NoError(t, err)
+	data, err := os.ReadFile(destPath)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("custom transport"), data)
+}
+
+func TestRegisterFetcher_RequiresBothFunctions(t *testing.T) {
+	assert.Panics(t, func() {
+		RegisterFetcher("broken", FetcherFactory{DetectLayout: detectHTTPLayout})
+	})
+}
+
+func TestNewClient_LocalPath(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddTarget("targets", "dir/file.txt", []byte("local content"))
+	repo.Publish()
+
+	client, err := NewClient(filepath.Join(repo.Dir, "metadata"))
+	require.NoError(t, err)
+	assert.Equal(t, "file://"+filepath.Join(repo.Dir, "metadata"), client.metadataURL)
+	assert.False(t, client.tufOnCiGit)
+	assert.True(t, client.hashPrefixes)
+	require.NoError(t, client.Update())
+
+	destPath := filepath.Join(t.TempDir(), "file.txt")
+	_, err = client.DownloadTarget("dir/file.txt", destPath)
+	require.NoError(t, err)
+	data, err := os.ReadFile(destPath)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("local content"), data)
+}
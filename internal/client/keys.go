@@ -0,0 +1,166 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+	"github.com/theupdateframework/go-tuf/v2/metadata/updater"
+)
+
+// tuf-on-ci key annotations
+const (
+	keyOwnerField     = "x-tuf-on-ci-keyowner"
+	keyOnlineURIField = "x-tuf-on-ci-online-uri"
+)
+
+// KeyInfo describes a key defined by root or a delegating role
+type KeyInfo struct {
+	KeyID  string `json:"keyid"`
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+	// Fingerprint is the hex SHA-256 of the DER (PKIX) public key, the same as
+	// hashing the PEM body, or empty when the key cannot be parsed
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// Owner is tuf-on-ci's key owner, usually a GitHub handle such as @user
+	Owner string `json:"owner,omitempty"`
+	// OnlineURI is set for tuf-on-ci online keys, e.g. a KMS key
+	OnlineURI string `json:"onlineURI,omitempty"`
+	// DefinedBy lists the roles whose metadata defines the key
+	DefinedBy []string `json:"definedBy"`
+	// Roles lists the roles that trust the key, in delegation order
+	Roles []string `json:"roles"`
+}
+
+// GetKeys returns every key defined by root and by delegating roles
+func (c *Client) GetKeys() ([]KeyInfo, error) {
+	return c.GetKeysContext(context.Background())
+}
+
+// GetKeysContext returns every key defined by root and by delegating roles with
+// the roles that trust it. Keys are ordered by the first role that trusts them,
+// followed by unused keys. Delegated metadata is loaded and verified like
+// GetRolesContext does.
+func (c *Client) GetKeysContext(ctx context.Context) (keys []KeyInfo, err error) {
+	ctx, span := c.telemetry.start(ctx, "tufzy.GetKeys")
+	defer func() { c.telemetry.end(ctx, span, "metadata", err) }()
+
+	err = c.withUpdater(ctx, func(u *updater.Updater) error {
+		trusted := u.GetTrustedMetadataSet()
+		if trusted.Root == nil || trusted.Targets[metadata.TARGETS] == nil {
+			return fmt.Errorf("top-level metadata not loaded, update first")
+		}
+		tree, err := c.delegationsOf(ctx, &trusted, metadata.TARGETS, map[string]bool{metadata.TARGETS: true})
+		if err != nil {
+			return err
+		}
+
+		inventory := newKeyInventory()
+		root := trusted.Root.Signed
+		for _, role := range metadata.TOP_LEVEL_ROLE_NAMES {
+			if entry := root.Roles[role]; entry != nil {
+				inventory.trust(role, entry.KeyIDs)
+			}
+		}
+		inventory.define(metadata.ROOT, root.Keys)
+
+		inventory.define(metadata.TARGETS, delegationKeys(trusted.Targets[metadata.TARGETS]))
+		walkDelegatedRoles(metadata.TARGETS, tree, map[string]bool{}, func(_ string, delegation Delegation) {
+			inventory.trust(delegation.Name, delegation.KeyIDs)
+			inventory.define(delegation.Name, delegationKeys(trusted.Targets[delegation.Name]))
+		})
+		keys = inventory.keys()
+		return nil
+	})
+	return keys, err
+}
+
+// delegationKeys returns the keys a targets role defines for its delegations
+func delegationKeys(targets *metadata.Metadata[metadata.TargetsType]) map[string]*metadata.Key {
+	if targets == nil || targets.Signed.Delegations == nil {
+		return nil
+	}
+	return targets.Signed.Delegations.Keys
+}
+
+// keyInventory merges the keys defined and trusted across roles by key ID
+type keyInventory struct {
+	byID    map[string]*KeyInfo
+	trusted []string // Key IDs in the order roles first trust them
+}
+
+func newKeyInventory() *keyInventory {
+	return &keyInventory{byID: map[string]*KeyInfo{}}
+}
+
+// get returns the entry for a key ID, creating it if needed
+func (inv *keyInventory) get(keyID string) *KeyInfo {
+	info, found := inv.byID[keyID]
+	if !found {
+		info = &KeyInfo{KeyID: keyID, DefinedBy: []string{}, Roles: []string{}}
+		inv.byID[keyID] = info
+	}
+	return info
+}
+
+// trust records that role trusts the given keys
+func (inv *keyInventory) trust(role string, keyIDs []string) {
+	for _, keyID := range keyIDs {
+		info := inv.get(keyID)
+		if len(info.Roles) == 0 {
+			inv.trusted = append(inv.trusted, keyID)
+		}
+		info.Roles = append(info.Roles, role)
+	}
+}
+
+// define records the keys a role defines
+func (inv *keyInventory) define(role string, keys map[string]*metadata.Key) {
+	for keyID, key := range keys {
+		info := inv.get(keyID)
+		info.DefinedBy = append(info.DefinedBy, role)
+		if info.Type != "" {
+			continue
+		}
+		info.Type = key.Type
+		info.Scheme = key.Scheme
+		info.Fingerprint = keyFingerprint(key)
+		info.Owner, _ = key.UnrecognizedFields[keyOwnerField].(string)
+		info.OnlineURI, _ = key.UnrecognizedFields[keyOnlineURIField].(string)
+	}
+}
+
+// keys returns the trusted keys in order, then the unused keys sorted by ID
+func (inv *keyInventory) keys() []KeyInfo {
+	var unused []string
+	for keyID, info := range inv.byID {
+		if len(info.Roles) == 0 {
+			unused = append(unused, keyID)
+		}
+	}
+	sort.Strings(unused)
+
+	keys := make([]KeyInfo, 0, len(inv.byID))
+	for _, keyID := range append(inv.trusted, unused...) {
+		keys = append(keys, *inv.byID[keyID])
+	}
+	return keys
+}
+
+// keyFingerprint returns the hex SHA-256 of a key's DER public key
+func keyFingerprint(key *metadata.Key) string {
+	public, err := key.ToPublicKey()
+	if err != nil {
+		return ""
+	}
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
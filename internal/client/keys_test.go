@@ -0,0 +1,79 @@
+package client
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+
+	"github.com/kipz/tufzy/internal/testrepo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+)
+
+func TestClient_GetKeys(t *testing.T) {
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddDelegation("team", []string{"team/*"})
+	root := repo.Root.Signed
+	targetsKeyID := root.Roles[metadata.TARGETS].KeyIDs[0]
+	targetsKey := root.Keys[targetsKeyID]
+
+	// tuf-on-ci annotates keys with their owner, or the online key's URI
+	targetsKey.UnrecognizedFields = map[string]any{keyOwnerField: "@alice"}
+	root.Keys[root.Roles[metadata.TIMESTAMP].KeyIDs[0]].UnrecognizedFields = map[string]any{keyOnlineURIField: "gcpkms:projects/p/keys/online"}
+
+	// alice's key is also trusted for team, next to bob's
+	delegations := repo.Targets[metadata.TARGETS].Signed.Delegations
+	teamKeyID := delegations.Roles[0].KeyIDs[0]
+	delegations.Keys[teamKeyID].UnrecognizedFields = map[string]any{keyOwnerField: "@bob"}
+	delegations.Keys[targetsKeyID] = targetsKey
+	delegations.Roles[0].KeyIDs = append(delegations.Roles[0].KeyIDs, targetsKeyID)
+
+	// A defined key no role trusts
+	unused, _ := newExtraKey(t)
+	root.Keys[unused.ID()] = unused
+	repo.Publish()
+
+	client, err := NewClientWithOptions(filepath.Join(repo.Dir, "metadata"), ClientOptions{CacheDir: t.TempDir()})
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+
+	keys, err := client.GetKeys()
+	require.NoError(t, err)
+	require.Len(t, keys, 6)
+
+	byID := map[string]KeyInfo{}
+	for _, key := range keys {
+		byID[key.KeyID] = key
+		assert.Equal(t, metadata.KeyTypeEd25519, key.Type)
+		assert.Len(t, key.Fingerprint, 64)
+	}
+
+	// Keys are ordered by the first role that trusts them, unused keys last
+	assert.Equal(t, []string{metadata.ROOT}, keys[0].Roles)
+	assert.Equal(t, []string{metadata.TIMESTAMP}, keys[1].Roles)
+	assert.Equal(t, "gcpkms:projects/p/keys/online", keys[1].OnlineURI)
+	assert.Equal(t, unused.ID(), keys[5].KeyID)
+	assert.Empty(t, keys[5].Roles)
+
+	alice := byID[targetsKeyID]
+	assert.Equal(t, "@alice", alice.Owner)
+	assert.Equal(t, []string{metadata.TARGETS, "team"}, alice.Roles)
+	assert.ElementsMatch(t, []string{metadata.ROOT, metadata.TARGETS}, alice.DefinedBy)
+
+	bob := byID[teamKeyID]
+	assert.Equal(t, "@bob", bob.Owner)
+	assert.Equal(t, []string{"team"}, bob.Roles)
+	assert.Equal(t, []string{metadata.TARGETS}, bob.DefinedBy)
+
+	// The fingerprint is the SHA-256 of the DER public key
+	public, err := hex.DecodeString(targetsKey.Value.PublicKey)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(ed25519.PublicKey(public))
+	require.NoError(t, err)
+	sum := sha256.Sum256(der)
+	assert.Equal(t, hex.EncodeToString(sum[:]), alice.Fingerprint)
+}
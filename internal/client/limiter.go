@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// Limiter bounds the number of concurrent requests and the download bandwidth.
+//
+// A single Limiter can be shared by any number of fetchers and goroutines, so
+// bulk operations stay within one budget for the whole process.
+type Limiter struct {
+	slots chan struct{} // nil when concurrency is unlimited
+	rate  *rate.Limiter // Token bucket in bytes, nil when bandwidth is unlimited
+}
+
+// NewLimiter creates a limiter allowing maxConcurrency requests in flight and
+// bytesPerSecond of response bodies. Zero or negative values mean unlimited.
+func NewLimiter(maxConcurrency int, bytesPerSecond int64) *Limiter {
+	l := &Limiter{}
+	if maxConcurrency > 0 {
+		l.slots = make(chan struct{}, maxConcurrency)
+	}
+	if bytesPerSecond > 0 {
+		// Allow bursts of up to one second of traffic
+		l.rate = rate.NewLimiter(rate.Limit(bytesPerSecond), int(bytesPerSecond))
+	}
+	return l
+}
+
+// acquire waits for a request slot and returns the function releasing it.
+func (l *Limiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil || l.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		var once sync.Once
+		return func() { once.Do(func() { <-l.slots }) }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// waitN blocks until n bytes may be read.
+func (l *Limiter) waitN(ctx context.Context, n int) error {
+	if l == nil || l.rate == nil || n <= 0 {
+		return nil
+	}
+	return l.rate.WaitN(ctx, n)
+}
+
+// chunkSize returns the largest read the token bucket allows at once.
+func (l *Limiter) chunkSize(n int) int {
+	if l == nil || l.rate == nil || n <= l.rate.Burst() {
+		return n
+	}
+	return l.rate.Burst()
+}
+
+// Transport wraps base so every request holds a slot until its response body
+// is closed and the body is read no faster than the bandwidth limit.
+func (l *Limiter) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if l == nil || (l.slots == nil && l.rate == nil) {
+		return base
+	}
+	return &limitedTransport{base: base, limiter: l}
+}
+
+// limitedTransport applies a Limiter to an http.RoundTripper.
+type limitedTransport struct {
+	base    http.RoundTripper
+	limiter *Limiter
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := t.limiter.acquire(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &limitedBody{
+		ReadCloser: resp.Body,
+		ctx:        req.Context(),
+		limiter:    t.limiter,
+		release:    release,
+	}
+	return resp, nil
+}
+
+// limitedBody rate limits reads and releases its request slot on close or EOF.
+type limitedBody struct {
+	io.ReadCloser
+	ctx     context.Context
+	limiter *Limiter
+	release func()
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	p = p[:b.limiter.chunkSize(len(p))]
+	n, err := b.ReadCloser.Read(p)
+	if waitErr := b.limiter.waitN(b.ctx, n); waitErr != nil && err == nil {
+		err = waitErr
+	}
+	if err == io.EOF {
+		b.release()
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	b.release()
+	return b.ReadCloser.Close()
+}
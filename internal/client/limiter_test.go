@@ -0,0 +1,198 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inFlight wraps a handler and records the highest number of concurrent requests
+type inFlight struct {
+	current atomic.Int64
+	max     atomic.Int64
+	delay   time.Duration
+}
+
+func (f *inFlight) wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := f.current.Add(1)
+		defer f.current.Add(-1)
+		for {
+			seen := f.max.Load()
+			if n <= seen || f.max.CompareAndSwap(seen, n) {
+				break
+			}
+		}
+		time.Sleep(f.delay)
+		h.ServeHTTP(w, r)
+	})
+}
+
+func TestLimiter_FilesystemFetcherConcurrency(t *testing.T) {
+	tracker := &inFlight{delay: 20 * time.Millisecond}
+	server := httptest.NewServer(tracker.wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("content"))
+	})))
+	defer server.Close()
+
+	limiter := NewLimiter(2, 0)
+	fetcher := NewFilesystemFetcherWithOptions(FilesystemFetcherOptions{Limiter: limiter})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			if i%2 == 0 {
+				_, err = fetcher.DownloadFile(fmt.Sprintf("%s/file-%d", server.URL, i), 1024, 0)
+			} else {
+				_, err = fetcher.StreamFile(context.Background(), fmt.Sprintf("%s/file-%d", server.URL, i), io.Discard, 0, 1024)
+			}
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(2), tracker.max.Load())
+	assert.Empty(t, limiter.slots, "all slots are released")
+}
+
+func TestLimiter_TufOnCiFetcher(t *testing.T) {
+	tracker := &inFlight{delay: 20 * time.Millisecond}
+	server := httptest.NewServer(tracker.wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("content"))
+	})))
+	defer server.Close()
+
+	// A tuf-on-ci checkout whose targets are published over HTTP
+	recorder := &logRecorder{}
+	limiter := NewLimiter(1, 0)
+	layout := Layout{MetadataURL: "file:///repo/metadata", TargetsURL: server.URL + "/targets", TufOnCiGit: true}
+	repoFetcher, err := newFilesystemFetcher(context.Background(), layout, FetcherOptions{
+		CacheDir: t.TempDir(),
+		Limiter:  limiter,
+		Logger:   recorder.logger(),
+	})
+	require.NoError(t, err)
+	require.IsType(t, &TufOnCiFetcher{}, repoFetcher)
+	tufOnCi := repoFetcher.(*TufOnCiFetcher)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := tufOnCi.StreamFile(context.Background(), fmt.Sprintf("%s/file-%d", layout.TargetsURL, i), io.Discard, 0, 1024)
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(1), tracker.max.Load())
+	assert.Empty(t, limiter.slots, "all slots are released")
+	assert.Len(t, recorder.records(t, "HTTP request"), 4)
+}
+
+func TestLimiter_RateLimit(t *testing.T) {
+	const bytesPerSecond = 256 << 10
+	content := bytes.Repeat([]byte("x"), bytesPerSecond*3/2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+
+	fetcher := NewFilesystemFetcherWithOptions(FilesystemFetcherOptions{Limiter: NewLimiter(0, bytesPerSecond)})
+
+	// The bucket starts full, so only the half second past the burst is throttled
+	start := time.Now()
+	var buf bytes.Buffer
+	_, err := fetcher.StreamFile(context.Background(), server.URL+"/file", &buf, 0, int64(len(content)))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond)
+	assert.Equal(t, content, buf.Bytes())
+}
+
+func TestLimiter_RegistryFetcherConcurrency(t *testing.T) {
+	ctx := context.Background()
+
+	tracker := &inFlight{delay: 10 * time.Millisecond}
+	server := httptest.NewServer(tracker.wrap(registry.New(registry.Logger(log.New(io.Discard, "", 0)))))
+	defer server.Close()
+
+	registryAddr := strings.TrimPrefix(server.URL, "http://")
+	metadataRepo := fmt.Sprintf("%s/limits/metadata", registryAddr)
+	targetsRepo := fmt.Sprintf("%s/limits/targets", registryAddr)
+
+	files := map[string][]byte{}
+	for i := 0; i < 8; i++ {
+		role := fmt.Sprintf("role-%d", i)
+		files[role] = []byte(fmt.Sprintf(`{"role":%q}`, role))
+		require.NoError(t, pushMetadataImage(ctx, metadataRepo, role, map[string][]byte{role + ".json": files[role]}))
+	}
+	tracker.max.Store(0)
+
+	// Two fetchers share one limiter
+	limiter := NewLimiter(1, 0)
+	var fetchers []*RegistryFetcher
+	for i := 0; i < 2; i++ {
+		fetcher, err := NewRegistryFetcherWithOptions(ctx, "oci://"+metadataRepo, "oci://"+targetsRepo, RegistryFetcherOptions{Limiter: limiter})
+		require.NoError(t, err)
+		fetchers = append(fetchers, fetcher)
+	}
+
+	var wg sync.WaitGroup
+	i := 0
+	for role, content := range files {
+		wg.Add(1)
+		go func(fetcher *RegistryFetcher, role string, content []byte) {
+			defer wg.Done()
+			data, err := fetcher.DownloadFile("oci://"+metadataRepo+"/"+role+".json", 1024, 30*time.Second)
+			assert.NoError(t, err)
+			assert.Equal(t, content, data)
+		}(fetchers[i%2], role, content)
+		i++
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(1), tracker.max.Load())
+	assert.Empty(t, limiter.slots, "all slots are released")
+}
+
+func TestLimiter_Acquire(t *testing.T) {
+	limiter := NewLimiter(1, 0)
+
+	release, err := limiter.acquire(context.Background())
+	require.NoError(t, err)
+
+	// A full limiter waits until the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = limiter.acquire(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Releasing twice frees a single slot
+	release()
+	release()
+	release, err = limiter.acquire(context.Background())
+	require.NoError(t, err)
+	release()
+
+	// A nil or unlimited limiter leaves the transport untouched
+	var nilLimiter *Limiter
+	assert.Equal(t, http.DefaultTransport, nilLimiter.Transport(nil))
+	assert.Equal(t, http.DefaultTransport, NewLimiter(0, 0).Transport(http.DefaultTransport))
+}
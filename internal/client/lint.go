@@ -0,0 +1,375 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+	"github.com/theupdateframework/go-tuf/v2/metadata/trustedmetadata"
+	"github.com/theupdateframework/go-tuf/v2/metadata/updater"
+)
+
+// Lint finding severities
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+	LintInfo    = "info"
+)
+
+// Lint checks, identifying the kind of problem a finding reports
+const (
+	LintCheckThreshold       = "threshold"
+	LintCheckSharedKey       = "shared-key"
+	LintCheckUnusedKey       = "unused-key"
+	LintCheckOverlappingPath = "overlapping-paths"
+	LintCheckUncoveredTarget = "uncovered-target"
+	LintCheckWeakKey         = "weak-key"
+	LintCheckExpiry          = "expiry"
+	LintCheckUnverifiable    = "unverifiable-role"
+)
+
+// DelegatedRoles is the LintPolicy.MaxExpiry key for delegated roles without their own entry
+const DelegatedRoles = "delegated"
+
+// LintPolicy configures the checks Lint applies
+type LintPolicy struct {
+	// MaxExpiry is the longest each role may stay valid, by role name or
+	// DelegatedRoles. Roles without an entry, or with zero, are not checked.
+	MaxExpiry map[string]time.Duration
+}
+
+// DefaultLintPolicy allows a year for offline roles and a week for timestamps
+func DefaultLintPolicy() LintPolicy {
+	year := 366 * 24 * time.Hour
+	return LintPolicy{MaxExpiry: map[string]time.Duration{
+		metadata.ROOT:      year,
+		metadata.TARGETS:   year,
+		metadata.SNAPSHOT:  year,
+		metadata.TIMESTAMP: 7 * 24 * time.Hour,
+		DelegatedRoles:     year,
+	}}
+}
+
+// LintFinding is a problem found in the repository's metadata
+type LintFinding struct {
+	Severity string `json:"severity"`
+	Check    string `json:"check"`
+	// Role is the role the finding is about, or the delegating role for delegations
+	Role    string `json:"role"`
+	Message string `json:"message"`
+}
+
+// Lint inspects the trusted metadata for common misconfigurations
+func (c *Client) Lint(policy LintPolicy) ([]LintFinding, error) {
+	return c.LintContext(context.Background(), policy)
+}
+
+// LintContext inspects the trusted metadata, including every delegated role, for
+// common misconfigurations. Roles that fail verification are reported as findings.
+// Findings are sorted by severity, then role.
+func (c *Client) LintContext(ctx context.Context, policy LintPolicy) (findings []LintFinding, err error) {
+	ctx, span := c.telemetry.start(ctx, "tufzy.Lint")
+	defer func() { c.telemetry.end(ctx, span, "metadata", err) }()
+
+	err = c.withUpdater(ctx, func(u *updater.Updater) error {
+		trusted := u.GetTrustedMetadataSet()
+		if trusted.Root == nil || trusted.Timestamp == nil || trusted.Snapshot == nil || trusted.Targets[metadata.TARGETS] == nil {
+			return fmt.Errorf("top-level metadata not loaded, update first")
+		}
+
+		l := &linter{policy: policy, now: time.Now()}
+		l.lintRoot(trusted.Root.Signed)
+		l.lintExpiry(metadata.ROOT, metadata.ROOT, trusted.Root.Signed.Expires)
+		l.lintExpiry(metadata.TIMESTAMP, metadata.TIMESTAMP, trusted.Timestamp.Signed.Expires)
+		l.lintExpiry(metadata.SNAPSHOT, metadata.SNAPSHOT, trusted.Snapshot.Signed.Expires)
+		l.lintExpiry(metadata.TARGETS, metadata.TARGETS, trusted.Targets[metadata.TARGETS].Signed.Expires)
+		if err := c.lintDelegations(ctx, l, &trusted, metadata.TARGETS, map[string]bool{metadata.TARGETS: true}); err != nil {
+			return err
+		}
+		findings = l.findings
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	severity := map[string]int{LintError: 0, LintWarning: 1, LintInfo: 2}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if severity[findings[i].Severity] != severity[findings[j].Severity] {
+			return severity[findings[i].Severity] < severity[findings[j].Severity]
+		}
+		return findings[i].Role < findings[j].Role
+	})
+	return findings, nil
+}
+
+// lintDelegations checks the delegations of parent, then loads and checks each
+// delegated role. Roles already checked are not checked again.
+func (c *Client) lintDelegations(ctx context.Context, l *linter, trusted *trustedmetadata.TrustedMetadata, parent string, seen map[string]bool) error {
+	delegations := trusted.Targets[parent].Signed.Delegations
+	if delegations == nil {
+		return nil
+	}
+	l.lintDelegationKeys(parent, delegations)
+	l.lintOverlappingPaths(parent, delegations.Roles)
+
+	for _, role := range delegations.Roles {
+		if seen[role.Name] {
+			continue
+		}
+		seen[role.Name] = true
+
+		loaded, err := c.loadDelegatedTargets(ctx, trusted, role.Name, parent)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			l.add(LintError, LintCheckUnverifiable, role.Name, "failed to load delegated role: %v", err)
+			continue
+		}
+
+		l.lintExpiry(role.Name, DelegatedRoles, loaded.Signed.Expires)
+		l.lintUncoveredTargets(&role, loaded.Signed.Targets)
+		if err := c.lintDelegations(ctx, l, trusted, role.Name, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// linter collects findings
+type linter struct {
+	policy   LintPolicy
+	now      time.Time
+	findings []LintFinding
+}
+
+func (l *linter) add(severity, check, role, format string, args ...any) {
+	l.findings = append(l.findings, LintFinding{Severity: severity, Check: check, Role: role, Message: fmt.Sprintf(format, args...)})
+}
+
+// lintRoot checks the top-level roles' keys and thresholds
+func (l *linter) lintRoot(root metadata.RootType) {
+	used := map[string][]string{} // Key ID -> roles
+	for _, role := range metadata.TOP_LEVEL_ROLE_NAMES {
+		entry := root.Roles[role]
+		if entry == nil {
+			l.add(LintError, LintCheckThreshold, role, "role is missing from root")
+			continue
+		}
+		l.lintThreshold(role, entry.Threshold, entry.KeyIDs, root.Keys)
+		for _, keyID := range entry.KeyIDs {
+			used[keyID] = append(used[keyID], role)
+		}
+	}
+
+	// A key compromise should not give away more than one role. tuf-on-ci
+	// deliberately signs timestamp and snapshot with the same online key.
+	for _, keyID := range sortedKeyIDs(used) {
+		roles := used[keyID]
+		if len(roles) < 2 {
+			continue
+		}
+		severity := LintWarning
+		if len(roles) == 2 && roles[0] == metadata.TIMESTAMP && roles[1] == metadata.SNAPSHOT {
+			severity = LintInfo
+		}
+		l.add(severity, LintCheckSharedKey, metadata.ROOT, "key %s is shared by %s", shortKeyID(keyID), strings.Join(roles, ", "))
+	}
+
+	l.lintKeys(metadata.ROOT, root.Keys, used)
+}
+
+// lintDelegationKeys checks the keys and thresholds of the roles delegated by parent
+func (l *linter) lintDelegationKeys(parent string, delegations *metadata.Delegations) {
+	used := map[string][]string{}
+	for _, role := range delegations.Roles {
+		l.lintThreshold(role.Name, role.Threshold, role.KeyIDs, delegations.Keys)
+		for _, keyID := range role.KeyIDs {
+			used[keyID] = append(used[keyID], role.Name)
+		}
+	}
+	l.lintKeys(parent, delegations.Keys, used)
+}
+
+// lintThreshold checks that a role's threshold can be met by its defined keys
+func (l *linter) lintThreshold(role string, threshold int, keyIDs []string, keys map[string]*metadata.Key) {
+	defined := 0
+	for _, keyID := range keyIDs {
+		if _, found := keys[keyID]; found {
+			defined++
+		} else {
+			l.add(LintError, LintCheckThreshold, role, "key %s is not defined", shortKeyID(keyID))
+		}
+	}
+	if threshold > defined {
+		l.add(LintError, LintCheckThreshold, role, "threshold %d is higher than the number of keys (%d)", threshold, defined)
+	}
+}
+
+// lintKeys reports unused and weak keys defined by owner
+func (l *linter) lintKeys(owner string, keys map[string]*metadata.Key, used map[string][]string) {
+	for _, keyID := range sortedKeyIDs(keys) {
+		if len(used[keyID]) == 0 {
+			l.add(LintWarning, LintCheckUnusedKey, owner, "key %s is not used by any role", shortKeyID(keyID))
+			continue
+		}
+		if weakness := keyWeakness(keys[keyID]); weakness != "" {
+			l.add(LintError, LintCheckWeakKey, strings.Join(used[keyID], ", "), "key %s %s", shortKeyID(keyID), weakness)
+		}
+	}
+}
+
+// keyWeakness describes why a key is weak, or returns "" for a strong key
+func keyWeakness(key *metadata.Key) string {
+	public, err := key.ToPublicKey()
+	if err != nil {
+		return fmt.Sprintf("has an unsupported or invalid type %q", key.Type)
+	}
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		if bits := public.N.BitLen(); bits < 2048 {
+			return fmt.Sprintf("is a %d-bit RSA key (at least 2048 bits expected)", bits)
+		}
+	case *ecdsa.PublicKey:
+		if bits := public.Curve.Params().BitSize; bits < 256 {
+			return fmt.Sprintf("is a %d-bit ECDSA key (at least 256 bits expected)", bits)
+		}
+	}
+	return ""
+}
+
+// lintOverlappingPaths reports delegations whose paths may overlap with a later
+// delegation while the earlier one is not terminating. Such targets are searched
+// for in both roles, so which one serves them depends on the order of delegations.
+func (l *linter) lintOverlappingPaths(parent string, roles []metadata.DelegatedRole) {
+	for i, earlier := range roles {
+		if earlier.Terminating {
+			continue
+		}
+		for _, later := range roles[i+1:] {
+			if pattern, other, found := overlappingPatterns(earlier.Paths, later.Paths); found {
+				l.add(LintWarning, LintCheckOverlappingPath, parent,
+					"non-terminating role %s (%s) may overlap with %s (%s)", earlier.Name, pattern, later.Name, other)
+			}
+		}
+	}
+}
+
+// overlappingPatterns returns the first pair of patterns that may match the same target
+func overlappingPatterns(a, b []string) (string, string, bool) {
+	for _, patternA := range a {
+		for _, patternB := range b {
+			if patternsMayOverlap(patternA, patternB) {
+				return patternA, patternB, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// patternsMayOverlap reports whether two delegation path patterns may match the
+// same target. Each path segment is compared on its literal prefix and suffix,
+// which never misses an overlap but may report one that cannot happen.
+func patternsMayOverlap(a, b string) bool {
+	partsA, partsB := strings.Split(a, "/"), strings.Split(b, "/")
+	if len(partsA) != len(partsB) {
+		return false
+	}
+	for i := range partsA {
+		if !segmentsMayOverlap(partsA[i], partsB[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// segmentsMayOverlap compares one path segment of two patterns
+func segmentsMayOverlap(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if matched, _ := path.Match(a, b); matched {
+		return true
+	}
+	if matched, _ := path.Match(b, a); matched {
+		return true
+	}
+
+	const wildcards = "*?["
+	if !strings.ContainsAny(a, wildcards) && !strings.ContainsAny(b, wildcards) {
+		return false
+	}
+	prefixA, prefixB := a, b
+	if i := strings.IndexAny(a, wildcards); i >= 0 {
+		prefixA = a[:i]
+	}
+	if i := strings.IndexAny(b, wildcards); i >= 0 {
+		prefixB = b[:i]
+	}
+	suffixA, suffixB := a[strings.LastIndexAny(a, wildcards+"]")+1:], b[strings.LastIndexAny(b, wildcards+"]")+1:]
+	return (strings.HasPrefix(prefixA, prefixB) || strings.HasPrefix(prefixB, prefixA)) &&
+		(strings.HasSuffix(suffixA, suffixB) || strings.HasSuffix(suffixB, suffixA))
+}
+
+// lintUncoveredTargets reports targets a role lists outside its delegated paths,
+// which clients never download from it
+func (l *linter) lintUncoveredTargets(role *metadata.DelegatedRole, targets map[string]*metadata.TargetFiles) {
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if delegated, err := role.IsDelegatedPath(name); err == nil && !delegated {
+			l.add(LintWarning, LintCheckUncoveredTarget, role.Name, "target %s is outside the role's delegated paths", name)
+		}
+	}
+}
+
+// lintExpiry checks a role's expiry against the policy for policyRole
+func (l *linter) lintExpiry(role, policyRole string, expires time.Time) {
+	maxExpiry, found := l.policy.MaxExpiry[role]
+	if !found {
+		maxExpiry, found = l.policy.MaxExpiry[policyRole]
+	}
+	if !found || maxExpiry <= 0 {
+		return
+	}
+	if remaining := expires.Sub(l.now); remaining > maxExpiry {
+		l.add(LintWarning, LintCheckExpiry, role, "expires %s, more than %s from now",
+			expires.Format(time.DateOnly), formatPolicyDuration(maxExpiry))
+	}
+}
+
+// formatPolicyDuration formats whole days as days and anything else as a duration
+func formatPolicyDuration(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return d.String()
+}
+
+// shortKeyID abbreviates a key ID for messages
+func shortKeyID(keyID string) string {
+	if len(keyID) > 12 {
+		return keyID[:12]
+	}
+	return keyID
+}
+
+func sortedKeyIDs[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
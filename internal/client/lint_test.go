@@ -0,0 +1,120 @@
+package client
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kipz/tufzy/internal/testrepo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+)
+
+func TestClient_Lint(t *testing.T) {
+	repo := testrepo.New(t, t.TempDir())
+	root := repo.Root.Signed
+
+	// The targets key also signs snapshot, and timestamp shares snapshot's key
+	targetsKey := root.Roles[metadata.TARGETS].KeyIDs[0]
+	snapshotKey := root.Roles[metadata.SNAPSHOT].KeyIDs[0]
+	root.Roles[metadata.SNAPSHOT].KeyIDs = append(root.Roles[metadata.SNAPSHOT].KeyIDs, targetsKey)
+	root.Roles[metadata.TIMESTAMP].KeyIDs = append(root.Roles[metadata.TIMESTAMP].KeyIDs, snapshotKey)
+
+	// A key no role uses
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	unused, err := metadata.KeyFromPublicKey(public)
+	require.NoError(t, err)
+	root.Keys[unused.ID()] = unused
+
+	// team is not terminating and overlaps with team-docs, and lists a target outside its paths
+	repo.AddDelegation("team", []string{"team/*"})
+	repo.AddTarget("team", "other/stray.txt", []byte("stray"))
+	repo.AddDelegation("team-docs", []string{"team/*.md"})
+
+	// legacy also trusts a weak RSA key
+	repo.AddDelegation("legacy", []string{"legacy/*"})
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	weakKey, err := metadata.KeyFromPublicKey(&weak.PublicKey)
+	require.NoError(t, err)
+	require.NoError(t, repo.Targets[metadata.TARGETS].Signed.AddKey(weakKey, "legacy"))
+
+	// broken needs two signatures but has one key
+	repo.AddDelegation("broken", []string{"broken/*"})
+	delegations := repo.Targets[metadata.TARGETS].Signed.Delegations
+	delegations.Roles[len(delegations.Roles)-1].Threshold = 2
+	repo.Publish()
+
+	client, err := NewClientWithOptions(filepath.Join(repo.Dir, "metadata"), ClientOptions{CacheDir: t.TempDir()})
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+
+	findings, err := client.Lint(DefaultLintPolicy())
+	require.NoError(t, err)
+
+	type finding struct{ severity, check, role string }
+	var got []finding
+	for _, f := range findings {
+		got = append(got, finding{f.Severity, f.Check, f.Role})
+		assert.NotEmpty(t, f.Message)
+	}
+	assert.Equal(t, []finding{
+		{LintError, LintCheckThreshold, "broken"},
+		{LintError, LintCheckUnverifiable, "broken"},
+		{LintError, LintCheckWeakKey, "legacy"},
+		{LintWarning, LintCheckSharedKey, metadata.ROOT},
+		{LintWarning, LintCheckUnusedKey, metadata.ROOT},
+		{LintWarning, LintCheckOverlappingPath, metadata.TARGETS},
+		{LintWarning, LintCheckUncoveredTarget, "team"},
+		{LintWarning, LintCheckExpiry, metadata.TIMESTAMP},
+		{LintInfo, LintCheckSharedKey, metadata.ROOT},
+	}, got)
+
+	// Without an expiry policy, nothing is checked against it
+	findings, err = client.Lint(LintPolicy{})
+	require.NoError(t, err)
+	for _, f := range findings {
+		assert.NotEqual(t, LintCheckExpiry, f.Check)
+	}
+
+	// Role-specific limits override the delegated default
+	findings, err = client.Lint(LintPolicy{MaxExpiry: map[string]time.Duration{DelegatedRoles: time.Hour, "team": 0}})
+	require.NoError(t, err)
+	var expiring []string
+	for _, f := range findings {
+		if f.Check == LintCheckExpiry {
+			expiring = append(expiring, f.Role)
+		}
+	}
+	assert.ElementsMatch(t, []string{"team-docs", "legacy"}, expiring)
+}
+
+func TestPatternsMayOverlap(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"team/*", "team/*", true},
+		{"team/*", "team/app.txt", true},
+		{"team/*", "team/*.md", true},
+		{"team/*.txt", "team/*.md", false},
+		{"team/nightly-*", "team/release-*", false},
+		{"team/*", "ops/*", false},
+		{"team/*", "team/sub/*", false},
+		{"*/app.txt", "team/*", true},
+		{"v1.?/*", "v1.2/*", true},
+		{"app.txt", "app.md", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.a+" "+tc.b, func(t *testing.T) {
+			assert.Equal(t, tc.expected, patternsMayOverlap(tc.a, tc.b))
+			assert.Equal(t, tc.expected, patternsMayOverlap(tc.b, tc.a))
+		})
+	}
+}
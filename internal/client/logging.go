@@ -0,0 +1,58 @@
+package client
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// discardLogger is used when no logger is configured
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// loggerOrDiscard returns logger, or a logger that drops everything if it is nil
+func loggerOrDiscard(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return discardLogger
+	}
+	return logger
+}
+
+// loggingTransport logs every HTTP request at debug level
+type loggingTransport struct {
+	base   http.RoundTripper
+	logger *slog.Logger
+}
+
+// logTransport wraps base to log its requests, or returns base when logger is nil
+func logTransport(base http.RoundTripper, logger *slog.Logger) http.RoundTripper {
+	if logger == nil {
+		return base
+	}
+	return &loggingTransport{base: base, logger: logger}
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.logger.DebugContext(req.Context(), "HTTP request failed",
+			"method", req.Method, "url", logURL(req.URL), "duration", time.Since(start), "error", err)
+		return nil, err
+	}
+
+	attrs := []any{"method", req.Method, "url", logURL(req.URL), "status", resp.StatusCode, "duration", time.Since(start)}
+	if byteRange := req.Header.Get("Range"); byteRange != "" {
+		attrs = append(attrs, "range", byteRange)
+	}
+	t.logger.DebugContext(req.Context(), "HTTP request", attrs...)
+	return resp, nil
+}
+
+// logURL drops credentials and query strings, which may carry tokens, from a logged URL
+func logURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	redacted.RawQuery = ""
+	return redacted.String()
+}
@@ -0,0 +1,261 @@
+package client
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// DefaultImageCacheSize is the default in-memory budget for cached manifests and layers
+	DefaultImageCacheSize = 64 << 20
+)
+
+// ImageCache provides caching for manifests and layers keyed by content digest.
+//
+// Entries are held in memory in an LRU bounded by the total number of bytes and,
+// when a directory is configured, persisted to an on-disk content-addressable
+// store so they can be reused across runs. It is safe for concurrent use.
+type ImageCache struct {
+	mu        sync.Mutex
+	maxBytes  int64
+	size      int64
+	entries   map[string]*list.Element
+	lru       *list.List // Front is most recently used
+	dir       string     // On-disk store, empty when disabled
+	logger    *slog.Logger
+	telemetry *telemetry
+}
+
+// ImageCacheOptions contains optional configuration for the image cache
+type ImageCacheOptions struct {
+	// MaxBytes bounds the in-memory cache size (default: DefaultImageCacheSize)
+	MaxBytes int64
+	// Dir enables the on-disk content-addressable store in this directory
+	Dir string
+	// Logger receives a debug record for every lookup and eviction (default: discard)
+	Logger *slog.Logger
+	// MeterProvider counts cache hits and misses (default: the global provider)
+	MeterProvider metric.MeterProvider
+}
+
+// cacheEntry is an item in the in-memory LRU.
+type cacheEntry struct {
+	digest string
+	data   []byte
+}
+
+// NewImageCache creates a new in-memory image cache with the default size.
+func NewImageCache() *ImageCache {
+	cache, _ := NewImageCacheWithOptions(ImageCacheOptions{})
+	return cache
+}
+
+// NewImageCacheWithOptions creates a new image cache with custom options.
+func NewImageCacheWithOptions(options ImageCacheOptions) (*ImageCache, error) {
+	maxBytes := options.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultImageCacheSize
+	}
+
+	if options.Dir != "" {
+		if err := os.MkdirAll(options.Dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create image cache directory: %w", err)
+		}
+	}
+
+	return &ImageCache{
+		maxBytes:  maxBytes,
+		entries:   make(map[string]*list.Element),
+		lru:       list.New(),
+		dir:       options.Dir,
+		logger:    loggerOrDiscard(options.Logger),
+		telemetry: newTelemetry(nil, options.MeterProvider),
+	}, nil
+}
+
+// Get retrieves the content with the given digest from the cache.
+func (c *ImageCache) Get(digest string) ([]byte, bool) {
+	c.mu.Lock()
+	if elem, found := c.entries[digest]; found {
+		c.lru.MoveToFront(elem)
+		data := elem.Value.(*cacheEntry).data
+		c.mu.Unlock()
+		c.logger.Debug("image cache hit", "digest", digest, "source", "memory")
+		c.telemetry.cacheHit(context.Background(), "oci", attrCacheLevel.String("memory"))
+		return data, true
+	}
+	c.mu.Unlock()
+
+	// Fall back to the on-disk store
+	data, found := c.readBlob(digest)
+	if !found {
+		c.logger.Debug("image cache miss", "digest", digest)
+		c.telemetry.cacheMiss(context.Background(), "oci")
+		return nil, false
+	}
+	c.logger.Debug("image cache hit", "digest", digest, "source", "disk")
+	c.telemetry.cacheHit(context.Background(), "oci", attrCacheLevel.String("disk"))
+
+	c.mu.Lock()
+	c.add(digest, data)
+	c.mu.Unlock()
+	return data, true
+}
+
+// Put adds content to the cache. The content must match its digest.
+func (c *ImageCache) Put(digest string, data []byte) error {
+	if err := verifyDigest(digest, data); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.add(digest, data)
+	c.mu.Unlock()
+
+	return c.writeBlob(digest, data)
+}
+
+// Len returns the number of entries held in memory.
+func (c *ImageCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Size returns the number of bytes held in memory.
+func (c *ImageCache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// add inserts or refreshes an entry and evicts the least recently used
+// entries until the cache fits its budget. Callers must hold c.mu.
+func (c *ImageCache) add(digest string, data []byte) {
+	if elem, found := c.entries[digest]; found {
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	// Entries larger than the whole budget are only kept on disk
+	if int64(len(data)) > c.maxBytes {
+		return
+	}
+
+	c.entries[digest] = c.lru.PushFront(&cacheEntry{digest: digest, data: data})
+	c.size += int64(len(data))
+
+	for c.size > c.maxBytes {
+		oldest := c.lru.Back()
+		entry := oldest.Value.(*cacheEntry)
+		c.lru.Remove(oldest)
+		delete(c.entries, entry.digest)
+		c.size -= int64(len(entry.data))
+		c.logger.Debug("image cache eviction", "digest", entry.digest, "size", len(entry.data))
+	}
+}
+
+// blobPath returns the on-disk location for a digest (<dir>/<algorithm>/<hex>).
+func (c *ImageCache) blobPath(digest string) (string, error) {
+	hash, err := v1.NewHash(digest)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(c.dir, hash.Algorithm, hash.Hex), nil
+}
+
+// readBlob reads and verifies a blob from the on-disk store.
+func (c *ImageCache) readBlob(digest string) ([]byte, bool) {
+	if c.dir == "" {
+		return nil, false
+	}
+
+	path, err := c.blobPath(digest)
+	if err != nil {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	// Drop corrupted entries so they are fetched again
+	if err := verifyDigest(digest, data); err != nil {
+		c.logger.Warn("dropping corrupted image cache entry", "digest", digest, "path", path)
+		_ = os.Remove(path)
+		return nil, false
+	}
+	return data, true
+}
+
+// writeBlob atomically writes a blob to the on-disk store.
+func (c *ImageCache) writeBlob(digest string, data []byte) error {
+	if c.dir == "" {
+		return nil
+	}
+
+	path, err := c.blobPath(digest)
+	if err != nil {
+		return err
+	}
+
+	// Content-addressed blobs never change once written
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create image cache directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write image cache entry: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write image cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write image cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write image cache entry: %w", err)
+	}
+	return nil
+}
+
+// errDigestMismatch is returned when content does not match its digest.
+var errDigestMismatch = errors.New("content does not match digest")
+
+// verifyDigest checks that data hashes to digest.
+func verifyDigest(digest string, data []byte) error {
+	want, err := v1.NewHash(digest)
+	if err != nil {
+		return fmt.Errorf("invalid digest %q: %w", digest, err)
+	}
+	if want.Algorithm != "sha256" {
+		return fmt.Errorf("unsupported digest algorithm: %s", want.Algorithm)
+	}
+	got, _, err := v1.SHA256(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("%w: expected %s, got %s", errDigestMismatch, want, got)
+	}
+	return nil
+}
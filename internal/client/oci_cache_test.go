@@ -0,0 +1,148 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// digestOf returns the sha256 digest string of data
+func digestOf(t *testing.T, data []byte) string {
+	hash, _, err := v1.SHA256(bytes.NewReader(data))
+	require.NoError(t, err)
+	return hash.String()
+}
+
+func TestImageCache(t *testing.T) {
+	cache := NewImageCache()
+	require.NotNil(t, cache)
+
+	testData := []byte("test data")
+	testDigest := digestOf(t, testData)
+
+	// Test cache miss
+	_, found := cache.Get(testDigest)
+	assert.False(t, found)
+
+	// Test cache put and get
+	require.NoError(t, cache.Put(testDigest, testData))
+
+	data, found := cache.Get(testDigest)
+	assert.True(t, found)
+	assert.Equal(t, testData, data)
+
+	// Test multiple entries
+	require.NoError(t, cache.Put(digestOf(t, []byte("data1")), []byte("data1")))
+	require.NoError(t, cache.Put(digestOf(t, []byte("data2")), []byte("data2")))
+
+	data1, found1 := cache.Get(digestOf(t, []byte("data1")))
+	assert.True(t, found1)
+	assert.Equal(t, []byte("data1"), data1)
+
+	data2, found2 := cache.Get(digestOf(t, []byte("data2")))
+	assert.True(t, found2)
+	assert.Equal(t, []byte("data2"), data2)
+}
+
+func TestImageCache_RejectsMismatchedContent(t *testing.T) {
+	cache := NewImageCache()
+
+	err := cache.Put(digestOf(t, []byte("expected")), []byte("actual"))
+	require.ErrorIs(t, err, errDigestMismatch)
+
+	err = cache.Put("test-ref", []byte("data"))
+	require.Error(t, err)
+
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestImageCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache, err := NewImageCacheWithOptions(ImageCacheOptions{MaxBytes: 10})
+	require.NoError(t, err)
+
+	a, b, c := []byte("aaaa"), []byte("bbbb"), []byte("cccc")
+	require.NoError(t, cache.Put(digestOf(t, a), a))
+	require.NoError(t, cache.Put(digestOf(t, b), b))
+
+	// Touch a so b becomes the least recently used entry
+	_, found := cache.Get(digestOf(t, a))
+	require.True(t, found)
+
+	require.NoError(t, cache.Put(digestOf(t, c), c))
+
+	_, foundA := cache.Get(digestOf(t, a))
+	_, foundB := cache.Get(digestOf(t, b))
+	_, foundC := cache.Get(digestOf(t, c))
+	assert.True(t, foundA)
+	assert.False(t, foundB)
+	assert.True(t, foundC)
+	assert.Equal(t, int64(8), cache.Size())
+
+	// Entries larger than the budget are not held in memory
+	big := []byte("0123456789abcdef")
+	require.NoError(t, cache.Put(digestOf(t, big), big))
+	_, found = cache.Get(digestOf(t, big))
+	assert.False(t, found)
+	assert.LessOrEqual(t, cache.Size(), int64(10))
+}
+
+func TestImageCache_Persistent(t *testing.T) {
+	dir := t.TempDir()
+	data := []byte("persisted layer")
+	digest := digestOf(t, data)
+
+	cache, err := NewImageCacheWithOptions(ImageCacheOptions{Dir: dir})
+	require.NoError(t, err)
+	require.NoError(t, cache.Put(digest, data))
+
+	hash, err := v1.NewHash(digest)
+	require.NoError(t, err)
+	blobPath := filepath.Join(dir, "sha256", hash.Hex)
+	assert.FileExists(t, blobPath)
+
+	// A new cache over the same directory serves the entry from disk
+	reopened, err := NewImageCacheWithOptions(ImageCacheOptions{Dir: dir})
+	require.NoError(t, err)
+	got, found := reopened.Get(digest)
+	assert.True(t, found)
+	assert.Equal(t, data, got)
+
+	// Corrupted entries are discarded
+	require.NoError(t, os.WriteFile(blobPath, []byte("corrupted"), 0644))
+	corrupted, err := NewImageCacheWithOptions(ImageCacheOptions{Dir: dir})
+	require.NoError(t, err)
+	_, found = corrupted.Get(digest)
+	assert.False(t, found)
+	assert.NoFileExists(t, blobPath)
+}
+
+func TestImageCache_Concurrent(t *testing.T) {
+	cache, err := NewImageCacheWithOptions(ImageCacheOptions{MaxBytes: 256, Dir: t.TempDir()})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				data := []byte(fmt.Sprintf("entry-%d-%d", i, j%5))
+				digest := digestOf(t, data)
+				assert.NoError(t, cache.Put(digest, data))
+				if got, found := cache.Get(digest); found {
+					assert.Equal(t, data, got)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, cache.Size(), int64(256))
+}
@@ -1,22 +1,26 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
 	"net/http"
 	"path"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/distribution/reference"
-	"github.com/google/go-containerregistry/pkg/authn"
-	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/google/go-containerregistry/pkg/v1/types"
 	"github.com/theupdateframework/go-tuf/v2/metadata"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -30,38 +34,24 @@ type Role string
 var Roles = []Role{metadata.ROOT, metadata.SNAPSHOT, metadata.TARGETS, metadata.TIMESTAMP}
 
 // RegistryFetcher implements the TUF Fetcher interface for OCI registries.
+//
+// Tags are resolved to manifest digests the first time they are used and every
+// later lookup of the same tag is served from that digest, so the registry cannot
+// swap an image between the requests made during a session.
 type RegistryFetcher struct {
-	metadataRepo string
-	metadataTag  string
-	targetsRepo  string
-	targetsTag   string
-	cache        *ImageCache
-	timeout      time.Duration
-	metadataURL  string // Original URL for parsing
-	targetsURL   string // Original URL for parsing
-}
-
-// ImageCache provides in-memory caching for manifests and layers.
-type ImageCache struct {
-	cache map[string][]byte
-}
-
-// NewImageCache creates a new image cache.
-func NewImageCache() *ImageCache {
-	return &ImageCache{
-		cache: make(map[string][]byte),
-	}
-}
-
-// Get retrieves an image from cache.
-func (c *ImageCache) Get(imgRef string) ([]byte, bool) {
-	img, found := c.cache[imgRef]
-	return img, found
-}
-
-// Put adds an image to cache.
-func (c *ImageCache) Put(imgRef string, img []byte) {
-	c.cache[imgRef] = img
+	metadataRepo   string
+	metadataTag    string
+	metadataDigest string // Pinned metadata manifest digest, if any
+	targetsRepo    string
+	targetsTag     string
+	targetsDigest  string // Pinned targets manifest digest, if any
+	cache          *ImageCache
+	mu             sync.Mutex        // Guards resolved
+	resolved       map[string]string // Tag reference -> manifest digest
+	source         imageSource       // Where manifests and layers are read from
+	metadataURL    string            // Original URL for parsing
+	targetsURL     string            // Original URL for parsing
+	logger         *slog.Logger
 }
 
 // Layer represents an OCI layer with annotations.
@@ -77,116 +67,290 @@ type Layers struct {
 	MediaType string  `json:"mediaType"`
 }
 
+// RegistryFetcherOptions contains optional configuration for the registry fetcher
+type RegistryFetcherOptions struct {
+	// Cache stores manifests and layers by digest (default: a new in-memory cache)
+	Cache *ImageCache
+	// Transport is used for all registry requests (default: a new pooled transport)
+	Transport http.RoundTripper
+	// Limiter bounds concurrent registry requests and bandwidth (default: unlimited)
+	Limiter *Limiter
+	// Logger receives a debug record for every registry request and tag resolution (default: discard)
+	Logger *slog.Logger
+	// TracerProvider records a span for every registry request (default: the global provider)
+	TracerProvider trace.TracerProvider
+}
+
 // NewRegistryFetcher creates a new RegistryFetcher for downloading TUF metadata and targets from OCI registries.
-// metadataURL and targetsURL should be in the format: oci://registry/repo:tag
-func NewRegistryFetcher(_ context.Context, metadataURL, targetsURL string) (*RegistryFetcher, error) {
+// metadataURL and targetsURL should be in the format: oci://registry/repo:tag or oci://registry/repo@sha256:digest
+func NewRegistryFetcher(ctx context.Context, metadataURL, targetsURL string) (*RegistryFetcher, error) {
+	return NewRegistryFetcherWithOptions(ctx, metadataURL, targetsURL, RegistryFetcherOptions{})
+}
+
+// NewRegistryFetcherWithOptions creates a new RegistryFetcher with custom options.
+// Nothing is fetched until the first download, so ctx only stops construction
+// when it is already done; each download takes its own context.
+func NewRegistryFetcherWithOptions(ctx context.Context, metadataURL, targetsURL string, options RegistryFetcherOptions) (*RegistryFetcher, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Strip oci:// prefix for parsing
 	metadataURLStripped := strings.TrimPrefix(metadataURL, OCIScheme)
 	targetsURLStripped := strings.TrimPrefix(targetsURL, OCIScheme)
 
 	// Parse metadata reference
-	metadataRef, err := reference.ParseNormalizedNamed(metadataURLStripped)
+	metadataRepo, metadataTag, metadataDigest, err := parseRepoReference(metadataURLStripped)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse metadata repo: %w", err)
 	}
-	metadataTag := LatestTag
-	if tag, ok := metadataRef.(reference.Tagged); ok {
-		metadataTag = tag.Tag()
-	}
-	metadataRepo := metadataRef.Name()
 
 	// Parse targets reference
-	targetsRef, err := reference.ParseNormalizedNamed(targetsURLStripped)
+	targetsRepo, targetsTag, targetsDigest, err := parseRepoReference(targetsURLStripped)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse targets repo: %w", err)
 	}
-	targetsTag := LatestTag
-	if tag, ok := targetsRef.(reference.Tagged); ok {
-		targetsTag = tag.Tag()
+
+	cache := options.Cache
+	if cache == nil {
+		cache = NewImageCache()
+	}
+
+	transport := options.Transport
+	if transport == nil {
+		transport = newRegistryTransport()
+	}
+	telemetry := newTelemetry(options.TracerProvider, nil)
+	transport = options.Limiter.Transport(traceTransport(logTransport(transport, options.Logger), telemetry))
+
+	// Reuse a single puller so connections, registry pings and tokens are shared
+	puller, err := remote.NewPuller(remote.WithTransport(transport), MultiKeychainOption())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create registry puller: %w", err)
 	}
-	targetsRepo := targetsRef.Name()
+	source := &registrySource{puller: puller}
 
 	return &RegistryFetcher{
-		metadataRepo: metadataRepo,
-		metadataTag:  metadataTag,
-		targetsRepo:  targetsRepo,
-		targetsTag:   targetsTag,
-		cache:        NewImageCache(),
-		metadataURL:  metadataURL,
-		targetsURL:   targetsURL,
+		metadataRepo:   metadataRepo,
+		metadataTag:    metadataTag,
+		metadataDigest: metadataDigest,
+		targetsRepo:    targetsRepo,
+		targetsTag:     targetsTag,
+		targetsDigest:  targetsDigest,
+		cache:          cache,
+		resolved:       make(map[string]string),
+		source:         source,
+		metadataURL:    metadataURL,
+		targetsURL:     targetsURL,
+		logger:         loggerOrDiscard(options.Logger),
 	}, nil
 }
 
+// parseRepoReference splits an image reference into its repository, tag and digest.
+// The tag defaults to LatestTag unless the reference is pinned by digest.
+func parseRepoReference(ref string) (repo, tag, digest string, err error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", "", "", err
+	}
+	if digested, ok := named.(reference.Digested); ok {
+		digest = digested.Digest().String()
+	}
+	if tagged, ok := named.(reference.Tagged); ok {
+		tag = tagged.Tag()
+	} else if digest == "" {
+		tag = LatestTag
+	}
+	return named.Name(), tag, digest, nil
+}
+
+// ResolvedDigests returns the manifest digest each tag reference resolved to during this session.
+func (d *RegistryFetcher) ResolvedDigests() map[string]string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	digests := make(map[string]string, len(d.resolved))
+	for ref, digest := range d.resolved {
+		digests[ref] = digest
+	}
+	return digests
+}
+
 // DownloadFile downloads a file from an OCI registry, errors out if it failed,
 // its length is larger than maxLength or the timeout is reached.
 func (d *RegistryFetcher) DownloadFile(urlPath string, maxLength int64, timeout time.Duration) ([]byte, error) {
-	d.timeout = timeout
+	return d.DownloadFileContext(context.Background(), urlPath, maxLength, timeout)
+}
 
-	imgRef, fileName, err := d.parseImgRef(urlPath)
+// DownloadFileContext is DownloadFile with a context that cancels the request
+func (d *RegistryFetcher) DownloadFileContext(ctx context.Context, urlPath string, maxLength int64, timeout time.Duration) ([]byte, error) {
+	// Bound this request with a deadline rather than per-request transports,
+	// so the fetcher can be shared and its connection pool reused
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	repo, hash, err := d.locateFile(ctx, urlPath)
 	if err != nil {
 		return nil, err
 	}
 
+	// Get file from layer
+	return d.pullFileLayer(ctx, repo, hash, maxLength)
+}
+
+// StreamFile writes a file from an OCI registry to w starting at offset, without
+// holding it in memory, and errors if it is larger than maxLength.
+func (d *RegistryFetcher) StreamFile(ctx context.Context, urlPath string, w io.Writer, offset, maxLength int64) (int64, error) {
+	repo, hash, err := d.locateFile(ctx, urlPath)
+	if err != nil {
+		return 0, err
+	}
+
+	// Small layers may already be cached
+	if data, found := d.cache.Get(hash.String()); found {
+		if int64(len(data)) > maxLength {
+			return 0, &metadata.ErrDownloadLengthMismatch{Msg: fmt.Sprintf("download failed, length %d is larger than expected %d", len(data), maxLength)}
+		}
+		if offset > int64(len(data)) {
+			offset = int64(len(data))
+		}
+		n, err := w.Write(data[offset:])
+		return int64(n), err
+	}
+
+	layer, err := d.source.layer(ctx, repo, hash)
+	if err != nil {
+		return 0, err
+	}
+	length, err := layer.Size()
+	if err != nil {
+		return 0, err
+	}
+	if length > maxLength {
+		return 0, &metadata.ErrDownloadLengthMismatch{Msg: fmt.Sprintf("download failed, length %d is larger than expected %d", length, maxLength)}
+	}
+
+	content, err := layer.Uncompressed()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = content.Close() }()
+
+	// Blobs are served whole, so skip the part already downloaded
+	if offset > 0 {
+		if _, err := io.CopyN(io.Discard, &contextReader{ctx: ctx, r: content}, offset); err != nil {
+			return 0, err
+		}
+	}
+	return copyWithLimit(w, &contextReader{ctx: ctx, r: content}, maxLength-offset)
+}
+
+// locateFile finds the repository and layer digest holding the file at urlPath.
+func (d *RegistryFetcher) locateFile(ctx context.Context, urlPath string) (string, v1.Hash, error) {
+	imgRef, fileName, err := d.parseImgRef(urlPath)
+	if err != nil {
+		return "", v1.Hash{}, err
+	}
+
 	// Get manifest for image or index
-	mf, err := d.getManifest(imgRef)
+	mf, err := d.getManifest(ctx, imgRef)
 	if err != nil {
-		return nil, err
+		return "", v1.Hash{}, err
 	}
 
 	// Search image/index manifest for file
-	hash, err := d.findFileInManifest(mf, fileName)
+	hash, err := d.findFileInManifest(ctx, mf, fileName)
 	if err != nil {
 		// Return not found error compatible with go-tuf
-		return nil, &metadata.ErrDownloadHTTP{StatusCode: http.StatusNotFound}
+		return "", v1.Hash{}, &metadata.ErrDownloadHTTP{StatusCode: http.StatusNotFound}
 	}
 
-	// Get file from layer
-	parts := strings.Split(imgRef, ":")
-	switch len(parts) {
-	// default host port
-	case 2:
-		return d.pullFileLayer(fmt.Sprintf("%s@%s", parts[0], *hash), maxLength)
-	// custom host port
-	case 3:
-		return d.pullFileLayer(fmt.Sprintf("%s:%s@%s", parts[0], parts[1], *hash), maxLength)
-	default:
-		return nil, fmt.Errorf("invalid image reference: %s", imgRef)
-	}
+	repo, _, _ := splitImgRef(imgRef)
+	return repo, *hash, nil
 }
 
-// getManifest returns the manifest for an image or index.
-func (d *RegistryFetcher) getManifest(ref string) ([]byte, error) {
+// getManifest returns the manifest for an image or index. Tag references are
+// resolved to a digest on first use and fetched by that digest afterwards.
+func (d *RegistryFetcher) getManifest(ctx context.Context, imgRef string) ([]byte, error) {
+	repo, identifier, isTag := splitImgRef(imgRef)
+
+	// Use the digest this tag was pinned to earlier in the session
+	if isTag {
+		if digest, found := d.resolvedDigest(imgRef); found {
+			identifier, isTag = digest, false
+		}
+	}
+
 	// Check cache for manifest
-	if mf, found := d.cache.Get(ref); found {
-		return mf, nil
+	if !isTag {
+		if mf, found := d.cache.Get(identifier); found {
+			return mf, nil
+		}
 	}
 
 	// Pull image manifest
-	mf, err := crane.Manifest(ref,
-		crane.WithTransport(transportWithTimeout(d.timeout)),
-		crane.WithAuth(authn.Anonymous),
-		crane.WithAuthFromKeychain(MultiKeychainAll()))
+	mf, err := d.source.manifest(ctx, repo, identifier)
 	if err != nil {
 		return nil, err
 	}
 
-	// Cache the manifest
-	d.cache.Put(ref, mf)
+	digest, _, err := v1.SHA256(bytes.NewReader(mf))
+	if err != nil {
+		return nil, err
+	}
+	if !isTag && digest.String() != identifier {
+		return nil, fmt.Errorf("manifest digest %s does not match requested digest %s", digest, identifier)
+	}
+
+	// Pin the tag to the digest of the manifest we received, unless a
+	// concurrent lookup pinned it first
+	if isTag {
+		if pinned := d.pin(imgRef, digest.String()); pinned != digest.String() {
+			return d.getManifest(ctx, joinImgRef(repo, pinned))
+		}
+		d.logger.DebugContext(ctx, "resolved tag", "reference", imgRef, "digest", digest.String())
+	}
+
+	// Cache the manifest under its digest; a failed cache write only costs a refetch
+	_ = d.cache.Put(digest.String(), mf)
 	return mf, nil
 }
 
+// resolvedDigest returns the digest a tag reference was pinned to, if any.
+func (d *RegistryFetcher) resolvedDigest(imgRef string) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	digest, found := d.resolved[imgRef]
+	return digest, found
+}
+
+// pin records the digest for a tag reference if it has not been resolved yet
+// and returns the digest the tag is pinned to.
+func (d *RegistryFetcher) pin(imgRef, digest string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if pinned, found := d.resolved[imgRef]; found {
+		return pinned
+	}
+	d.resolved[imgRef] = digest
+	return digest
+}
+
 // pullFileLayer pulls a layer for an image or index and returns its data.
-func (d *RegistryFetcher) pullFileLayer(ref string, maxLength int64) ([]byte, error) {
+func (d *RegistryFetcher) pullFileLayer(ctx context.Context, repo string, digest v1.Hash, maxLength int64) ([]byte, error) {
 	// Check cache for layer
-	if data, found := d.cache.Get(ref); found {
+	if data, found := d.cache.Get(digest.String()); found {
+		if int64(len(data)) > maxLength {
+			return nil, &metadata.ErrDownloadLengthMismatch{Msg: fmt.Sprintf("download failed, length %d is larger than expected %d", len(data), maxLength)}
+		}
 		return data, nil
 	}
 
 	// Pull layer
-	layer, err := crane.PullLayer(ref,
-		crane.WithTransport(transportWithTimeout(d.timeout)),
-		crane.WithAuth(authn.Anonymous),
-		crane.WithAuthFromKeychain(MultiKeychainAll()))
+	layer, err := d.source.layer(ctx, repo, digest)
 	if err != nil {
 		return nil, err
 	}
@@ -197,8 +361,9 @@ func (d *RegistryFetcher) pullFileLayer(ref string, maxLength int64) ([]byte, er
 		return nil, err
 	}
 
-	// Cache the layer
-	d.cache.Put(ref, data)
+	// Cache the layer; compressed layers don't match their digest once
+	// extracted and are rejected by the cache, so they are simply refetched
+	_ = d.cache.Put(digest.String(), data)
 	return data, nil
 }
 
@@ -237,11 +402,15 @@ func getDataFromLayer(fileLayer v1.Layer, maxLength int64) (data []byte, err err
 // parseImgRef parses a URL path to an image reference and file name.
 func (d *RegistryFetcher) parseImgRef(urlPath string) (imgRef, fileName string, err error) {
 	// Check if path is for targets
-	if strings.HasPrefix(urlPath, d.targetsURL) {
+	if strings.HasPrefix(urlPath, d.targetsURL+"/") {
 		// determine if the target path contains subdirectories and set image name accordingly
 		// <repo>/<filename>          -> image = <repo>:<filename>, layer = <filename>
 		// <repo>/<subdir>/<filename> -> index = <repo>:<subdir>  , image = <filename> -> layer = <filename>
+		// A digest-pinned targets reference names the image or index holding every target
 		target := strings.TrimPrefix(urlPath, d.targetsURL+"/")
+		if d.targetsDigest != "" {
+			return fmt.Sprintf("%s@%s", d.targetsRepo, d.targetsDigest), target, nil
+		}
 		subdir, name, found := strings.Cut(target, "/")
 		if found {
 			return fmt.Sprintf("%s:%s", d.targetsRepo, subdir), fmt.Sprintf("%s/%s", subdir, name), nil
@@ -250,7 +419,7 @@ func (d *RegistryFetcher) parseImgRef(urlPath string) (imgRef, fileName string,
 	}
 
 	// Check if path is for metadata
-	if strings.HasPrefix(urlPath, d.metadataURL) {
+	if strings.HasPrefix(urlPath, d.metadataURL+"/") {
 		// build the metadata image name
 		// determine if role is a delegated role and set the tag accordingly
 		fileName = path.Base(urlPath)
@@ -259,6 +428,9 @@ func (d *RegistryFetcher) parseImgRef(urlPath string) (imgRef, fileName string,
 		if isDelegatedRole(role) {
 			return fmt.Sprintf("%s:%s", d.metadataRepo, role), fileName, nil
 		}
+		if d.metadataDigest != "" {
+			return fmt.Sprintf("%s@%s", d.metadataRepo, d.metadataDigest), fileName, nil
+		}
 		return fmt.Sprintf("%s:%s", d.metadataRepo, d.metadataTag), fileName, nil
 	}
 
@@ -266,7 +438,7 @@ func (d *RegistryFetcher) parseImgRef(urlPath string) (imgRef, fileName string,
 }
 
 // findFileInManifest searches the image or index manifest for a file with the given name and returns its digest.
-func (d *RegistryFetcher) findFileInManifest(mf []byte, name string) (*v1.Hash, error) {
+func (d *RegistryFetcher) findFileInManifest(ctx context.Context, mf []byte, name string) (*v1.Hash, error) {
 	var index bool
 
 	// unmarshal manifest with annotations
@@ -310,25 +482,25 @@ func (d *RegistryFetcher) findFileInManifest(mf []byte, name string) (*v1.Hash,
 
 	// if index manifest pull image to get file layer
 	if index {
-		mf, err := d.getManifest(fmt.Sprintf("%s@%s", d.targetsRepo, *hash))
+		mf, err := d.getManifest(ctx, fmt.Sprintf("%s@%s", d.targetsRepo, *hash))
 		if err != nil {
 			return nil, err
 		}
 		parts := strings.Split(name, "/")
-		return d.findFileInManifest(mf, parts[len(parts)-1])
+		return d.findFileInManifest(ctx, mf, parts[len(parts)-1])
 	}
 	return hash, nil
 }
 
-// transportWithTimeout returns a http.RoundTripper with a specified timeout.
-func transportWithTimeout(timeout time.Duration) http.RoundTripper {
-	// transport is based on go-containerregistry remote.DefaultTransport
-	// with modifications to include a specified timeout
+// newRegistryTransport returns the pooled http.RoundTripper shared by a fetcher's requests.
+func newRegistryTransport() http.RoundTripper {
+	// transport is based on go-containerregistry remote.DefaultTransport;
+	// request deadlines come from the context passed to each request
 	return &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
 		DialContext: (&net.Dialer{
-			Timeout:   timeout,
-			KeepAlive: timeout,
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
 		}).DialContext,
 		ForceAttemptHTTP2:     true,
 		MaxIdleConns:          100,
@@ -339,6 +511,62 @@ func transportWithTimeout(timeout time.Duration) http.RoundTripper {
 	}
 }
 
+// imageSource provides the manifests and layers a RegistryFetcher searches for TUF files.
+type imageSource interface {
+	// manifest returns the raw manifest for a tag or digest in repo
+	manifest(ctx context.Context, repo, identifier string) ([]byte, error)
+	// layer returns the layer with the given digest from repo
+	layer(ctx context.Context, repo string, digest v1.Hash) (v1.Layer, error)
+}
+
+// registrySource reads images from a live OCI registry.
+type registrySource struct {
+	puller *remote.Puller
+}
+
+func (s *registrySource) manifest(ctx context.Context, repo, identifier string) ([]byte, error) {
+	imgRef := joinImgRef(repo, identifier)
+	ref, err := name.ParseReference(imgRef)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image reference: %s", imgRef)
+	}
+	desc, err := s.puller.Get(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return desc.Manifest, nil
+}
+
+func (s *registrySource) layer(ctx context.Context, repo string, digest v1.Hash) (v1.Layer, error) {
+	imgRef := joinImgRef(repo, digest.String())
+	ref, err := name.NewDigest(imgRef)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image reference: %s", imgRef)
+	}
+	return s.puller.Layer(ctx, ref)
+}
+
+// splitImgRef splits an image reference into its repository and tag or digest.
+// Repositories may be registry names or layout paths, so this is purely syntactic.
+func splitImgRef(imgRef string) (repo, identifier string, isTag bool) {
+	lastSlash := strings.LastIndex(imgRef, "/")
+	if i := strings.LastIndex(imgRef, "@"); i > lastSlash {
+		return imgRef[:i], imgRef[i+1:], false
+	}
+	if i := strings.LastIndex(imgRef, ":"); i > lastSlash {
+		return imgRef[:i], imgRef[i+1:], true
+	}
+	return imgRef, LatestTag, true
+}
+
+// joinImgRef builds an image reference from a repository and a tag or digest.
+func joinImgRef(repo, identifier string) string {
+	if strings.Contains(identifier, ":") {
+		return repo + "@" + identifier
+	}
+	return repo + ":" + identifier
+}
+
 // isDelegatedRole returns true if the role is a delegated role.
 func isDelegatedRole(role string) bool {
 	for _, r := range Roles {
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+)
+
+// delegatedRoleCount is the number of delegated roles pushed for the benchmark repository
+const delegatedRoleCount = 25
+
+// BenchmarkRegistryFetcher_DelegatedRoles measures the TCP connections opened to
+// fetch the metadata of a repository with many delegated roles. Run with:
+//
+//	go test -run '^$' -bench RegistryFetcher ./internal/client
+func BenchmarkRegistryFetcher_DelegatedRoles(b *testing.B) {
+	ctx := context.Background()
+
+	// Start an in-memory registry that counts new connections
+	var conns atomic.Int64
+	server := httptest.NewUnstartedServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	registryAddr := strings.TrimPrefix(server.URL, "http://")
+	metadataRepo := fmt.Sprintf("%s/bench/metadata", registryAddr)
+	targetsRepo := fmt.Sprintf("%s/bench/targets", registryAddr)
+
+	// Push top-level metadata and one image per delegated role
+	files := []string{"timestamp.json", "snapshot.json", "targets.json"}
+	if err := pushMetadataImage(ctx, metadataRepo, "latest", map[string][]byte{
+		"timestamp.json": []byte(`{"role":"timestamp"}`),
+		"snapshot.json":  []byte(`{"role":"snapshot"}`),
+		"targets.json":   []byte(`{"role":"targets"}`),
+	}); err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < delegatedRoleCount; i++ {
+		role := fmt.Sprintf("role-%d", i)
+		if err := pushMetadataImage(ctx, metadataRepo, role, map[string][]byte{
+			role + ".json": []byte(fmt.Sprintf(`{"role":%q}`, role)),
+		}); err != nil {
+			b.Fatal(err)
+		}
+		files = append(files, role+".json")
+	}
+
+	benchmarks := []struct {
+		name      string
+		transport func() http.RoundTripper
+	}{
+		{"shared transport", newRegistryTransport},
+		{"transport per request", func() http.RoundTripper { return perRequestTransport{} }},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			conns.Store(0)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				fetcher, err := NewRegistryFetcherWithOptions(ctx, "oci://"+metadataRepo, "oci://"+targetsRepo,
+					RegistryFetcherOptions{Transport: bm.transport()})
+				if err != nil {
+					b.Fatal(err)
+				}
+				for _, file := range files {
+					if _, err := fetcher.DownloadFile("oci://"+metadataRepo+"/"+file, 512000, 30*time.Second); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+
+			b.ReportMetric(float64(conns.Load())/float64(b.N), "conns/op")
+		})
+	}
+}
+
+// perRequestTransport reproduces building a new transport for every request,
+// so no connection is ever reused.
+type perRequestTransport struct{}
+
+func (perRequestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := newRegistryTransport().(*http.Transport)
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		transport.CloseIdleConnections()
+		return nil, err
+	}
+	resp.Body = &closeIdleBody{ReadCloser: resp.Body, transport: transport}
+	return resp, nil
+}
+
+// closeIdleBody releases the transport's connection once the body is closed.
+type closeIdleBody struct {
+	io.ReadCloser
+	transport *http.Transport
+}
+
+func (b *closeIdleBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.transport.CloseIdleConnections()
+	return err
+}
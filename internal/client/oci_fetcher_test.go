@@ -2,12 +2,15 @@ package client
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+var testDigestHex = strings.Repeat("ab", 32)
+
 func TestNewRegistryFetcher(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -16,8 +19,10 @@ func TestNewRegistryFetcher(t *testing.T) {
 		wantErr      bool
 		wantMetaRepo string
 		wantMetaTag  string
+		wantMetaDgst string
 		wantTgtRepo  string
 		wantTgtTag   string
+		wantTgtDgst  string
 	}{
 		{
 			name:         "basic URLs with explicit tags",
@@ -39,6 +44,35 @@ func TestNewRegistryFetcher(t *testing.T) {
 			wantTgtRepo:  "registry.example.com/repo/targets",
 			wantTgtTag:   LatestTag,
 		},
+		{
+			name:         "URLs pinned by digest",
+			metadataURL:  "oci://registry.example.com/repo/metadata@sha256:" + testDigestHex,
+			targetsURL:   "oci://registry.example.com/repo/targets@sha256:" + testDigestHex,
+			wantErr:      false,
+			wantMetaRepo: "registry.example.com/repo/metadata",
+			wantMetaTag:  "",
+			wantMetaDgst: "sha256:" + testDigestHex,
+			wantTgtRepo:  "registry.example.com/repo/targets",
+			wantTgtTag:   "",
+			wantTgtDgst:  "sha256:" + testDigestHex,
+		},
+		{
+			name:         "URLs with tag and digest",
+			metadataURL:  "oci://registry.example.com/repo/metadata:v1@sha256:" + testDigestHex,
+			targetsURL:   "oci://registry.example.com/repo/targets:v1",
+			wantErr:      false,
+			wantMetaRepo: "registry.example.com/repo/metadata",
+			wantMetaTag:  "v1",
+			wantMetaDgst: "sha256:" + testDigestHex,
+			wantTgtRepo:  "registry.example.com/repo/targets",
+			wantTgtTag:   "v1",
+		},
+		{
+			name:        "invalid digest",
+			metadataURL: "oci://registry.example.com/repo/metadata@sha256:abc",
+			targetsURL:  "oci://registry.example.com/repo/targets",
+			wantErr:     true,
+		},
 		{
 			name:        "invalid metadata URL",
 			metadataURL: "oci://not valid",
@@ -67,8 +101,10 @@ func TestNewRegistryFetcher(t *testing.T) {
 			require.NotNil(t, fetcher)
 			assert.Equal(t, tt.wantMetaRepo, fetcher.metadataRepo)
 			assert.Equal(t, tt.wantMetaTag, fetcher.metadataTag)
+			assert.Equal(t, tt.wantMetaDgst, fetcher.metadataDigest)
 			assert.Equal(t, tt.wantTgtRepo, fetcher.targetsRepo)
 			assert.Equal(t, tt.wantTgtTag, fetcher.targetsTag)
+			assert.Equal(t, tt.wantTgtDgst, fetcher.targetsDigest)
 			assert.NotNil(t, fetcher.cache)
 		})
 	}
@@ -146,6 +182,55 @@ func TestParseImgRef(t *testing.T) {
 	}
 }
 
+func TestParseImgRef_Pinned(t *testing.T) {
+	ctx := context.Background()
+	metadataURL := "oci://registry.example.com/repo/metadata@sha256:" + testDigestHex
+	targetsURL := "oci://registry.example.com/repo/targets@sha256:" + testDigestHex
+	fetcher, err := NewRegistryFetcher(ctx, metadataURL, targetsURL)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		urlPath      string
+		wantImgRef   string
+		wantFileName string
+	}{
+		{
+			name:         "top-level metadata uses pinned digest",
+			urlPath:      metadataURL + "/timestamp.json",
+			wantImgRef:   "registry.example.com/repo/metadata@sha256:" + testDigestHex,
+			wantFileName: "timestamp.json",
+		},
+		{
+			name:         "delegated metadata still uses role tag",
+			urlPath:      metadataURL + "/role.json",
+			wantImgRef:   "registry.example.com/repo/metadata:role",
+			wantFileName: "role.json",
+		},
+		{
+			name:         "top-level target uses pinned digest",
+			urlPath:      targetsURL + "/abc123.file.txt",
+			wantImgRef:   "registry.example.com/repo/targets@sha256:" + testDigestHex,
+			wantFileName: "abc123.file.txt",
+		},
+		{
+			name:         "delegated target uses pinned digest",
+			urlPath:      targetsURL + "/subdir/file.txt",
+			wantImgRef:   "registry.example.com/repo/targets@sha256:" + testDigestHex,
+			wantFileName: "subdir/file.txt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			imgRef, fileName, err := fetcher.parseImgRef(tt.urlPath)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantImgRef, imgRef)
+			assert.Equal(t, tt.wantFileName, fileName)
+		})
+	}
+}
+
 func TestIsDelegatedRole(t *testing.T) {
 	tests := []struct {
 		name string
@@ -265,32 +350,3 @@ func TestHasOCIScheme(t *testing.T) {
 		})
 	}
 }
-
-func TestImageCache(t *testing.T) {
-	cache := NewImageCache()
-	require.NotNil(t, cache)
-
-	// Test cache miss
-	_, found := cache.Get("test-ref")
-	assert.False(t, found)
-
-	// Test cache put and get
-	testData := []byte("test data")
-	cache.Put("test-ref", testData)
-
-	data, found := cache.Get("test-ref")
-	assert.True(t, found)
-	assert.Equal(t, testData, data)
-
-	// Test multiple entries
-	cache.Put("ref1", []byte("data1"))
-	cache.Put("ref2", []byte("data2"))
-
-	data1, found1 := cache.Get("ref1")
-	assert.True(t, found1)
-	assert.Equal(t, []byte("data1"), data1)
-
-	data2, found2 := cache.Get("ref2")
-	assert.True(t, found2)
-	assert.Equal(t, []byte("data2"), data2)
-}
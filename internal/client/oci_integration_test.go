@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/google/go-containerregistry/pkg/crane"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/empty"
 	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/static"
@@ -142,12 +143,64 @@ func TestOCIRegistryIntegration(t *testing.T) {
 
 		assert.Equal(t, data1, data2)
 	})
+
+	metadataDigest, err := crane.Digest(metadataRepo + ":latest")
+	require.NoError(t, err)
+
+	// Test downloading metadata pinned by digest
+	t.Run("download pinned metadata", func(t *testing.T) {
+		pinnedURL := fmt.Sprintf("%s@%s", metadataURL, metadataDigest)
+		pinned, err := NewRegistryFetcher(ctx, pinnedURL, targetsURL+":latest")
+		require.NoError(t, err)
+
+		data, err := pinned.DownloadFile(pinnedURL+"/timestamp.json", 512000, 30*time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, timestampMetadata, data)
+		assert.Empty(t, pinned.ResolvedDigests())
+	})
+
+	// Test tags stay pinned to the digest they first resolved to
+	t.Run("tags resolve once per session", func(t *testing.T) {
+		assert.Equal(t, metadataDigest, fetcher.ResolvedDigests()[metadataRepo+":latest"])
+
+		// Move the tag to different content
+		err := pushMetadataImage(ctx, metadataRepo, "latest", map[string][]byte{
+			"timestamp.json": []byte("{}"),
+		})
+		require.NoError(t, err)
+
+		data, err := fetcher.DownloadFile(metadataURL+":latest/timestamp.json", 512000, 30*time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, timestampMetadata, data)
+	})
 }
 
 // pushMetadataImage creates and pushes an OCI image with metadata layers
 func pushMetadataImage(ctx context.Context, repo, tag string, files map[string][]byte) error {
-	img := empty.Image
+	img, err := newMetadataImage(files)
+	if err != nil {
+		return err
+	}
+
+	// Push image
+	ref := fmt.Sprintf("%s:%s", repo, tag)
+	return crane.Push(img, ref)
+}
+
+// pushTargetImage creates and pushes an OCI image for a target file
+func pushTargetImage(ctx context.Context, repo, filename string, content []byte) error {
+	img, err := newTargetImage(filename, content)
+	if err != nil {
+		return err
+	}
+
+	ref := fmt.Sprintf("%s:%s", repo, filename)
+	return crane.Push(img, ref)
+}
 
+// newMetadataImage creates an OCI image with one annotated layer per metadata file
+func newMetadataImage(files map[string][]byte) (v1.Image, error) {
+	img := empty.Image
 	// Add config layer
 	img = mutate.ConfigMediaType(img, types.OCIManifestSchema1)
 
@@ -164,38 +217,31 @@ func pushMetadataImage(ctx context.Context, repo, tag string, files map[string][
 	}
 
 	// Add all layers at once with their annotations
-	var err error
-	img, err = mutate.Append(img, adds...)
+	img, err := mutate.Append(img, adds...)
 	if err != nil {
-		return fmt.Errorf("failed to append layers: %w", err)
+		return nil, fmt.Errorf("failed to append layers: %w", err)
 	}
-
-	// Push image
-	ref := fmt.Sprintf("%s:%s", repo, tag)
-	return crane.Push(img, ref)
+	return img, nil
 }
 
-// pushTargetImage creates and pushes an OCI image for a target file
-func pushTargetImage(ctx context.Context, repo, filename string, content []byte) error {
+// newTargetImage creates an OCI image for a target file
+func newTargetImage(filename string, content []byte) (v1.Image, error) {
 	img := empty.Image
 	img = mutate.ConfigMediaType(img, types.OCIManifestSchema1)
 
 	layer := static.NewLayer(content, TUFTargetMediaType)
 
 	// Add layer with annotation
-	var err error
-	img, err = mutate.Append(img, mutate.Addendum{
+	img, err := mutate.Append(img, mutate.Addendum{
 		Layer: layer,
 		Annotations: map[string]string{
 			TUFFilenameAnnotation: filename,
 		},
 	})
 	if err != nil {
-		return fmt.Errorf("failed to append layer: %w", err)
+		return nil, fmt.Errorf("failed to append layer: %w", err)
 	}
-
-	ref := fmt.Sprintf("%s:%s", repo, filename)
-	return crane.Push(img, ref)
+	return img, nil
 }
 
 // createTestRootMetadata creates a minimal TUF root metadata for testing
@@ -333,7 +379,7 @@ func TestNewOCIClient(t *testing.T) {
 
 	// Test with invalid targets URL (should error)
 	t.Run("missing targets URL", func(t *testing.T) {
-		_, err := newOCIClient("oci://registry.example.com/metadata:latest", "", tmpDir)
+		_, err := newClient(context.Background(), "oci://registry.example.com/metadata:latest", tmpDir, ClientOptions{})
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "targets URL is required")
 	})
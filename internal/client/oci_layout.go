@@ -0,0 +1,252 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// LayoutFetcherOptions contains optional configuration for the layout fetcher
+type LayoutFetcherOptions struct {
+	// Cache stores manifests and layers by digest (default: a new in-memory cache)
+	Cache *ImageCache
+	// Limiter bounds concurrent layout reads and bandwidth (default: unlimited)
+	Limiter *Limiter
+	// Logger receives a debug record for every layout read and tag resolution (default: discard)
+	Logger *slog.Logger
+	// TracerProvider records a span for every layout read (default: the global provider)
+	TracerProvider trace.TracerProvider
+}
+
+// NewLayoutFetcher creates a RegistryFetcher that reads TUF metadata and targets from
+// on-disk OCI image layouts (e.g. from `crane pull --format=oci` or skopeo) instead of a
+// live registry. Each layout directory plays the role of one repository and its tags are
+// taken from the org.opencontainers.image.ref.name annotations in index.json.
+// metadataURL and targetsURL should be in the format: oci-layout:///path/to/layout:tag
+func NewLayoutFetcher(ctx context.Context, metadataURL, targetsURL string) (*RegistryFetcher, error) {
+	return NewLayoutFetcherWithOptions(ctx, metadataURL, targetsURL, LayoutFetcherOptions{})
+}
+
+// NewLayoutFetcherWithOptions creates a layout fetcher with custom options.
+func NewLayoutFetcherWithOptions(ctx context.Context, metadataURL, targetsURL string, options LayoutFetcherOptions) (*RegistryFetcher, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Parse metadata layout
+	metadataRepo, metadataTag, metadataDigest, err := parseLayoutReference(strings.TrimPrefix(metadataURL, OCILayoutScheme))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metadata layout: %w", err)
+	}
+
+	// Parse targets layout
+	targetsRepo, targetsTag, targetsDigest, err := parseLayoutReference(strings.TrimPrefix(targetsURL, OCILayoutScheme))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open targets layout: %w", err)
+	}
+
+	cache := options.Cache
+	if cache == nil {
+		cache = NewImageCache()
+	}
+	logger := loggerOrDiscard(options.Logger)
+
+	return &RegistryFetcher{
+		metadataRepo:   metadataRepo,
+		metadataTag:    metadataTag,
+		metadataDigest: metadataDigest,
+		targetsRepo:    targetsRepo,
+		targetsTag:     targetsTag,
+		targetsDigest:  targetsDigest,
+		cache:          cache,
+		resolved:       make(map[string]string),
+		source: &layoutSource{
+			limiter:   options.Limiter,
+			logger:    logger,
+			telemetry: newTelemetry(options.TracerProvider, nil),
+		},
+		metadataURL: metadataURL,
+		targetsURL:  targetsURL,
+		logger:      logger,
+	}, nil
+}
+
+// parseLayoutReference splits a layout reference (/path/to/layout:tag or
+// /path/to/layout@sha256:digest) into its directory, tag and digest.
+func parseLayoutReference(ref string) (repo, tag, digest string, err error) {
+	repo, identifier, isTag := splitImgRef(ref)
+	if repo == "" {
+		return "", "", "", fmt.Errorf("missing layout path in %q", ref)
+	}
+	if isTag {
+		tag = identifier
+	} else {
+		if _, err := v1.NewHash(identifier); err != nil {
+			return "", "", "", fmt.Errorf("invalid digest in %q: %w", ref, err)
+		}
+		digest = identifier
+	}
+
+	// Fail early if the directory is not an OCI image layout
+	if _, err := layout.FromPath(repo); err != nil {
+		return "", "", "", err
+	}
+	return repo, tag, digest, nil
+}
+
+// layoutSource reads images from OCI image layout directories. Like the
+// registry transport, it holds a limiter slot for every read and logs and
+// traces it, so layouts are throttled and observable like registries.
+type layoutSource struct {
+	limiter   *Limiter
+	logger    *slog.Logger
+	telemetry *telemetry
+}
+
+func (s *layoutSource) manifest(ctx context.Context, repo, identifier string) (data []byte, err error) {
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	ctx, done := s.observe(ctx, "manifest", repo, identifier)
+	defer func() { done(err) }()
+
+	path, err := layout.FromPath(repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open OCI layout %s: %w", repo, err)
+	}
+
+	hash, err := v1.NewHash(identifier)
+	if err != nil {
+		// Not a digest, so look the tag up in the layout index
+		hash, err = findLayoutTag(path, identifier)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if data, err = path.Bytes(hash); err != nil {
+		return nil, err
+	}
+	for n := len(data); n > 0; {
+		chunk := s.limiter.chunkSize(n)
+		if err := s.limiter.waitN(ctx, chunk); err != nil {
+			return nil, err
+		}
+		n -= chunk
+	}
+	return data, nil
+}
+
+func (s *layoutSource) layer(ctx context.Context, repo string, digest v1.Hash) (v1.Layer, error) {
+	path, err := layout.FromPath(repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open OCI layout %s: %w", repo, err)
+	}
+	return partial.CompressedToLayer(&layoutBlob{ctx: ctx, source: s, path: path, digest: digest})
+}
+
+// observe starts a span for a read of identifier from the layout at repo. The
+// returned function ends it and logs the read at debug level.
+func (s *layoutSource) observe(ctx context.Context, kind, repo, identifier string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := s.telemetry.start(ctx, "OCI layout read",
+		attrKind.String(kind), attrURL.String(OCILayoutScheme+joinImgRef(repo, identifier)))
+	return ctx, func(err error) {
+		if err != nil {
+			s.logger.DebugContext(ctx, "OCI layout read failed",
+				"kind", kind, "layout", repo, "reference", identifier, "duration", time.Since(start), "error", err)
+		} else {
+			s.logger.DebugContext(ctx, "OCI layout read",
+				"kind", kind, "layout", repo, "reference", identifier, "duration", time.Since(start))
+		}
+		s.telemetry.end(ctx, span, kind, err)
+	}
+}
+
+// findLayoutTag returns the digest of the manifest tagged tag in the layout index.
+func findLayoutTag(path layout.Path, tag string) (v1.Hash, error) {
+	index, err := path.ImageIndex()
+	if err != nil {
+		return v1.Hash{}, err
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	for _, desc := range manifest.Manifests {
+		if layoutRefTag(desc.Annotations[OCIRefNameAnnotation]) == tag {
+			return desc.Digest, nil
+		}
+	}
+	return v1.Hash{}, fmt.Errorf("tag %s not found in OCI layout %s", tag, path)
+}
+
+// layoutRefTag returns the tag from a ref.name annotation, which is either a bare
+// tag (skopeo) or a full image reference (crane pull --annotate-ref).
+func layoutRefTag(refName string) string {
+	if strings.Contains(refName, "@") {
+		return ""
+	}
+	if i := strings.LastIndex(refName, ":"); i > strings.LastIndex(refName, "/") {
+		return refName[i+1:]
+	}
+	if strings.Contains(refName, "/") {
+		return ""
+	}
+	return refName
+}
+
+// layoutBlob exposes a blob in an OCI layout as a compressed layer.
+type layoutBlob struct {
+	ctx    context.Context // Bounds the limiter waits while the blob is read
+	source *layoutSource
+	path   layout.Path
+	digest v1.Hash
+}
+
+func (b *layoutBlob) Digest() (v1.Hash, error) {
+	return b.digest, nil
+}
+
+// Compressed opens the blob, holding a limiter slot until it is closed or read
+// to the end, and reads it no faster than the bandwidth limit.
+func (b *layoutBlob) Compressed() (rc io.ReadCloser, err error) {
+	release, err := b.source.limiter.acquire(b.ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx, done := b.source.observe(b.ctx, "layer", string(b.path), b.digest.String())
+	defer func() { done(err) }()
+
+	blob, err := b.path.Blob(b.digest)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &limitedBody{ReadCloser: blob, ctx: ctx, limiter: b.source.limiter, release: release}, nil
+}
+
+func (b *layoutBlob) Size() (int64, error) {
+	info, err := os.Stat(filepath.Join(string(b.path), "blobs", b.digest.Algorithm, b.digest.Hex))
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func (b *layoutBlob) MediaType() (types.MediaType, error) {
+	return TUFTargetMediaType, nil
+}
@@ -0,0 +1,324 @@
+package client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/kipz/tufzy/internal/testrepo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+)
+
+// writeLayout writes images and indexes to a new OCI layout, tagging each with its ref.name annotation
+func writeLayout(t *testing.T, dir string, images map[string]v1.Image, indexes map[string]v1.ImageIndex) {
+	path, err := layout.Write(dir, empty.Index)
+	require.NoError(t, err)
+
+	for refName, img := range images {
+		require.NoError(t, path.AppendImage(img, layout.WithAnnotations(map[string]string{
+			OCIRefNameAnnotation: refName,
+		})))
+	}
+	for refName, idx := range indexes {
+		require.NoError(t, path.AppendIndex(idx, layout.WithAnnotations(map[string]string{
+			OCIRefNameAnnotation: refName,
+		})))
+	}
+}
+
+func TestLayoutFetcher(t *testing.T) {
+	rootMetadata := createTestRootMetadata(t)
+	timestampMetadata := createTestTimestampMetadata(t)
+	delegatedMetadata := createTestDelegatedMetadata(t, "test-role")
+	testTarget := []byte("test target content")
+	nestedTarget := []byte("nested target content")
+
+	// Metadata layout with the top-level image and a delegated role image
+	metadataImg, err := newMetadataImage(map[string][]byte{
+		"1.root.json":    rootMetadata,
+		"timestamp.json": timestampMetadata,
+	})
+	require.NoError(t, err)
+	roleImg, err := newMetadataImage(map[string][]byte{"test-role.json": delegatedMetadata})
+	require.NoError(t, err)
+
+	metadataDir := filepath.Join(t.TempDir(), "metadata")
+	writeLayout(t, metadataDir, map[string]v1.Image{
+		"latest":    metadataImg,
+		"test-role": roleImg,
+	}, nil)
+
+	// Targets layout with a top-level target image and a subdirectory index
+	targetImg, err := newTargetImage("abc123.test.txt", testTarget)
+	require.NoError(t, err)
+	nestedImg, err := newTargetImage("file.txt", nestedTarget)
+	require.NoError(t, err)
+	subdirIndex := mutate.AppendManifests(empty.Index, mutate.IndexAddendum{
+		Add: nestedImg,
+		Descriptor: v1.Descriptor{
+			Annotations: map[string]string{TUFFilenameAnnotation: "subdir/file.txt"},
+		},
+	})
+
+	targetsDir := filepath.Join(t.TempDir(), "targets")
+	writeLayout(t, targetsDir, map[string]v1.Image{
+		"abc123.test.txt": targetImg,
+	}, map[string]v1.ImageIndex{
+		"subdir": subdirIndex,
+	})
+
+	metadataURL := OCILayoutScheme + metadataDir + ":latest"
+	targetsURL := OCILayoutScheme + targetsDir
+
+	fetcher, err := NewLayoutFetcher(context.Background(), metadataURL, targetsURL)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		urlPath string
+		want    []byte
+	}{
+		{"root metadata", metadataURL + "/1.root.json", rootMetadata},
+		{"timestamp metadata", metadataURL + "/timestamp.json", timestampMetadata},
+		{"delegated metadata", metadataURL + "/test-role.json", delegatedMetadata},
+		{"top-level target", targetsURL + "/abc123.test.txt", testTarget},
+		{"target in subdirectory", targetsURL + "/subdir/file.txt", nestedTarget},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := fetcher.DownloadFile(tt.urlPath, 512000, 30*time.Second)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, data)
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := fetcher.DownloadFile(metadataURL+"/2.root.json", 512000, 30*time.Second)
+		var httpErr *metadata.ErrDownloadHTTP
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, 404, httpErr.StatusCode)
+	})
+
+	t.Run("missing tag", func(t *testing.T) {
+		_, err := fetcher.DownloadFile(metadataURL+"/other-role.json", 512000, 30*time.Second)
+		require.ErrorContains(t, err, "tag other-role not found")
+	})
+
+	t.Run("file exceeds max length", func(t *testing.T) {
+		_, err := fetcher.DownloadFile(targetsURL+"/abc123.test.txt", 4, 30*time.Second)
+		var lengthErr *metadata.ErrDownloadLengthMismatch
+		require.ErrorAs(t, err, &lengthErr)
+	})
+
+	t.Run("tags are pinned", func(t *testing.T) {
+		digest, err := metadataImg.Digest()
+		require.NoError(t, err)
+		assert.Equal(t, digest.String(), fetcher.ResolvedDigests()[metadataDir+":latest"])
+	})
+
+	t.Run("pinned by digest", func(t *testing.T) {
+		digest, err := metadataImg.Digest()
+		require.NoError(t, err)
+		pinnedURL := OCILayoutScheme + metadataDir + "@" + digest.String()
+
+		pinned, err := NewLayoutFetcher(context.Background(), pinnedURL, targetsURL)
+		require.NoError(t, err)
+		data, err := pinned.DownloadFile(pinnedURL+"/timestamp.json", 512000, 30*time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, timestampMetadata, data)
+	})
+}
+
+func TestLayoutFetcher_FullReferenceAnnotations(t *testing.T) {
+	timestampMetadata := createTestTimestampMetadata(t)
+	img, err := newMetadataImage(map[string][]byte{"timestamp.json": timestampMetadata})
+	require.NoError(t, err)
+
+	// crane pull --format=oci --annotate-ref records the full image reference
+	dir := t.TempDir()
+	writeLayout(t, dir, map[string]v1.Image{
+		"registry.example.com:5000/repo/metadata:v2": img,
+	}, nil)
+
+	metadataURL := OCILayoutScheme + dir + ":v2"
+	fetcher, err := NewLayoutFetcher(context.Background(), metadataURL, OCILayoutScheme+dir)
+	require.NoError(t, err)
+
+	data, err := fetcher.DownloadFile(metadataURL+"/timestamp.json", 512000, 30*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, timestampMetadata, data)
+}
+
+func TestNewLayoutFetcher_Errors(t *testing.T) {
+	dir := t.TempDir()
+	writeLayout(t, dir, nil, nil)
+
+	tests := []struct {
+		name        string
+		metadataURL string
+		targetsURL  string
+		wantErr     string
+	}{
+		{
+			name:        "metadata is not a layout",
+			metadataURL: OCILayoutScheme + t.TempDir(),
+			targetsURL:  OCILayoutScheme + dir,
+			wantErr:     "failed to open metadata layout",
+		},
+		{
+			name:        "targets is not a layout",
+			metadataURL: OCILayoutScheme + dir,
+			targetsURL:  OCILayoutScheme + t.TempDir(),
+			wantErr:     "failed to open targets layout",
+		},
+		{
+			name:        "invalid digest",
+			metadataURL: OCILayoutScheme + dir + "@sha256:abc",
+			targetsURL:  OCILayoutScheme + dir,
+			wantErr:     "invalid digest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewLayoutFetcher(context.Background(), tt.metadataURL, tt.targetsURL)
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+// writeRepoLayout writes a published test repository with a team delegation to
+// a new OCI layout and returns its metadata and targets URLs
+func writeRepoLayout(t *testing.T, repo *testrepo.Repo) (metadataURL, targetsURL string) {
+	metadataImg, err := newMetadataImage(map[string][]byte{
+		"1.root.json":     repo.MetadataFile("1.root.json"),
+		"timestamp.json":  repo.MetadataFile("timestamp.json"),
+		"1.snapshot.json": repo.MetadataFile("1.snapshot.json"),
+		"1.targets.json":  repo.MetadataFile("1.targets.json"),
+	})
+	require.NoError(t, err)
+	teamImg, err := newMetadataImage(map[string][]byte{"1.team.json": repo.MetadataFile("1.team.json")})
+	require.NoError(t, err)
+
+	images := map[string]v1.Image{"latest": metadataImg, "team": teamImg}
+	for name, content := range repo.Files {
+		target := repo.TargetHash(name) + "." + name
+		img, err := newTargetImage(target, content)
+		require.NoError(t, err)
+		images[target] = img
+	}
+
+	dir := t.TempDir()
+	writeLayout(t, dir, images, nil)
+	return OCILayoutScheme + dir + ":latest", OCILayoutScheme + dir
+}
+
+func TestNewOCIClient_Layout(t *testing.T) {
+	// Build a signed repository with a delegated role and write it as an OCI layout
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddTarget("targets", "app.txt", []byte("app content"))
+	repo.AddDelegation("team", []string{"team-*"})
+	repo.AddTarget("team", "team-tool.txt", []byte("team content"))
+	repo.Publish()
+	metadataURL, targetsURL := writeRepoLayout(t, repo)
+
+	t.Run("bootstraps root from layout", func(t *testing.T) {
+		cacheDir := t.TempDir()
+		client, err := newClient(context.Background(), metadataURL, cacheDir, ClientOptions{TargetsURL: targetsURL})
+		require.NoError(t, err)
+		assert.Equal(t, metadataURL, client.metadataURL)
+		assert.True(t, client.hashPrefixes)
+
+		trusted, err := os.ReadFile(filepath.Join(cacheDir, "metadata", "root.json"))
+		require.NoError(t, err)
+		assert.Equal(t, repo.MetadataFile("1.root.json"), trusted)
+	})
+
+	t.Run("updates and downloads targets", func(t *testing.T) {
+		client, err := newClient(context.Background(), metadataURL, t.TempDir(), ClientOptions{TargetsURL: targetsURL})
+		require.NoError(t, err)
+		require.NoError(t, client.Update())
+
+		targets, err := client.GetTargets()
+		require.NoError(t, err)
+		require.Len(t, targets, 1)
+		assert.Equal(t, "app.txt", targets[0].Name)
+
+		for name, content := range repo.Files {
+			destPath := filepath.Join(t.TempDir(), name)
+			_, err := client.DownloadTarget(name, destPath)
+			require.NoError(t, err, name)
+
+			data, err := os.ReadFile(destPath)
+			require.NoError(t, err)
+			assert.Equal(t, content, data)
+		}
+	})
+
+	t.Run("targets must use the same scheme", func(t *testing.T) {
+		_, err := newClient(context.Background(), metadataURL, t.TempDir(), ClientOptions{TargetsURL: "oci://registry.example.com/targets"})
+		require.ErrorContains(t, err, "same scheme")
+	})
+}
+
+func TestNewOCIClient_LayoutOptions(t *testing.T) {
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddTarget("targets", "app.txt", []byte("app content"))
+	repo.AddDelegation("team", []string{"team-*"})
+	repo.Publish()
+	metadataURL, targetsURL := writeRepoLayout(t, repo)
+
+	// Layout reads are logged, traced, cached and limited like registry requests
+	recorder := &logRecorder{}
+	telemetry := newTelemetryRecorder()
+	limiter := NewLimiter(1, 0)
+	client, err := NewClientWithOptions(metadataURL, ClientOptions{
+		CacheDir:       t.TempDir(),
+		TargetsURL:     targetsURL,
+		Limiter:        limiter,
+		Logger:         recorder.logger(),
+		TracerProvider: telemetry.tracerProvider,
+		MeterProvider:  telemetry.meterProvider,
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+	_, err = client.DownloadTarget("app.txt", filepath.Join(t.TempDir(), "app.txt"))
+	require.NoError(t, err)
+
+	assert.Contains(t, recorder.values(t, "OCI layout read", "kind"), "manifest")
+	assert.Contains(t, recorder.values(t, "OCI layout read", "kind"), "layer")
+	assert.NotEmpty(t, recorder.records(t, "resolved tag"))
+	assert.NotEmpty(t, telemetry.spansNamed("OCI layout read"))
+	assert.Positive(t, telemetry.counter(t, "tufzy.cache.misses", attrCache.String("oci")))
+	assert.Empty(t, limiter.slots, "all slots are released")
+}
+
+func TestLayoutRefTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		refName string
+		want    string
+	}{
+		{"bare tag", "latest", "latest"},
+		{"full reference", "registry.example.com/repo/metadata:v1", "v1"},
+		{"full reference with port", "localhost:5000/repo/metadata:v1", "v1"},
+		{"full reference without tag", "localhost:5000/repo/metadata", ""},
+		{"digest reference", "registry.example.com/repo@sha256:abc", ""},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, layoutRefTag(tt.refName))
+		})
+	}
+}
@@ -10,4 +10,10 @@ const (
 
 	// OCI URL scheme
 	OCIScheme = "oci://"
+
+	// OCI image layout URL scheme (oci-layout:///path/to/layout:tag)
+	OCILayoutScheme = "oci-layout://"
+
+	// Annotation naming the reference of a manifest in an OCI image layout index
+	OCIRefNameAnnotation = "org.opencontainers.image.ref.name"
 )
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+)
+
+// OpenTarget opens a verified copy of a target from the cache, downloading it first if needed
+func (c *Client) OpenTarget(name string) (*TargetInfo, *os.File, error) {
+	return c.OpenTargetContext(context.Background(), name)
+}
+
+// OpenTargetContext opens a verified copy of a target from the cache, downloading
+// it first if needed and stopping when ctx is done. Copies are stored by hash, so
+// one never stands in for another version of the target, and each is verified
+// again before it is returned. The caller closes the file.
+func (c *Client) OpenTargetContext(ctx context.Context, name string) (_ *TargetInfo, _ *os.File, err error) {
+	ctx, span := c.telemetry.start(ctx, "tufzy.OpenTarget", attrTarget.String(name))
+	defer func() { c.telemetry.end(ctx, span, "target", err, attrTarget.String(name)) }()
+
+	targetFile, err := c.lookupTarget(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	alg, digest, err := primaryHash(targetFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	objectsDir := filepath.Join(c.cacheDir, "objects")
+	path := filepath.Join(objectsDir, alg+"-"+digest)
+
+	// Step 1: reuse the cached copy, discarding it if it no longer verifies
+	file, err := openVerified(ctx, path, targetFile)
+	if err == nil {
+		c.telemetry.cacheHit(ctx, "target")
+		return newTargetInfo(name, targetFile), file, nil
+	}
+	if ctx.Err() != nil {
+		return nil, nil, ctx.Err()
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		c.logger.WarnContext(ctx, "discarding cached target that failed verification", "target", name, "error", err)
+		_ = os.Remove(path)
+	}
+
+	// Step 2: download it to a file of its own next to the cache, then rename it
+	// into place. Concurrent downloads of the target wait for each other in
+	// streamTarget, and whichever is renamed last wins with the same content.
+	if err := os.MkdirAll(objectsDir, 0755); err != nil {
+		return nil, nil, fmt.Errorf("failed to create objects directory: %w", err)
+	}
+	temp, err := os.CreateTemp(objectsDir, ".download-*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to cache target: %w", err)
+	}
+	_ = temp.Close()
+	defer func() { _ = os.Remove(temp.Name()) }()
+	if _, err := c.DownloadTargetContext(ctx, name, temp.Name()); err != nil {
+		return nil, nil, err
+	}
+
+	// Opened before the rename, so a concurrent discard cannot remove it first
+	file, err = os.Open(temp.Name())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open target: %w", err)
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		_ = file.Close()
+		return nil, nil, fmt.Errorf("failed to cache target: %w", err)
+	}
+	return newTargetInfo(name, targetFile), file, nil
+}
+
+// openVerified opens the file at path if it matches the target's length and hashes
+func openVerified(ctx context.Context, path string, targetFile *metadata.TargetFiles) (*os.File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := newTargetVerifier(targetFile)
+	if err == nil {
+		_, err = io.Copy(verifier, &contextReader{ctx: ctx, r: file})
+	}
+	if err == nil {
+		err = verifier.verify()
+	}
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return file, nil
+}
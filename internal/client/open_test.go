@@ -0,0 +1,102 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/kipz/tufzy/internal/testrepo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+)
+
+func TestClient_OpenTarget(t *testing.T) {
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddTarget(metadata.TARGETS, "app.txt", []byte("app"))
+	repo.Publish()
+
+	cacheDir := t.TempDir()
+	client, err := NewClientWithOptions(filepath.Join(repo.Dir, "metadata"), ClientOptions{CacheDir: cacheDir})
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+
+	readTarget := func() string {
+		info, file, err := client.OpenTarget("app.txt")
+		require.NoError(t, err)
+		defer func() { _ = file.Close() }()
+		assert.Equal(t, int64(3), info.Length)
+		assert.Equal(t, repo.TargetHash("app.txt"), info.Hashes["sha256"])
+		data, err := io.ReadAll(file)
+		require.NoError(t, err)
+		return string(data)
+	}
+	assert.Equal(t, "app", readTarget())
+
+	// The cached copy is used once the repository no longer has the file
+	cached := filepath.Join(cacheDir, "objects", "sha256-"+repo.TargetHash("app.txt"))
+	require.FileExists(t, cached)
+	require.NoError(t, os.RemoveAll(filepath.Join(repo.Dir, "targets")))
+	assert.Equal(t, "app", readTarget())
+
+	// A damaged copy is discarded and downloaded again
+	require.NoError(t, os.WriteFile(cached, []byte("bad"), 0644))
+	repo.Publish()
+	assert.Equal(t, "app", readTarget())
+
+	_, _, err = client.OpenTarget("missing.txt")
+	assert.ErrorIs(t, err, ErrTargetNotFound)
+}
+
+func TestClient_OpenTarget_RejectsTamperedTarget(t *testing.T) {
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddTarget(metadata.TARGETS, "app.txt", []byte("app"))
+	repo.Publish()
+
+	client, err := NewClientWithOptions(filepath.Join(repo.Dir, "metadata"), ClientOptions{CacheDir: t.TempDir()})
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+
+	// Same length, different content
+	path := filepath.Join(repo.Dir, "targets", repo.TargetHash("app.txt")+".app.txt")
+	require.NoError(t, os.WriteFile(path, []byte("evl"), 0644))
+
+	_, _, err = client.OpenTarget("app.txt")
+	var mismatch *metadata.ErrLengthOrHashMismatch
+	assert.ErrorAs(t, err, &mismatch)
+}
+
+func TestClient_OpenTarget_Concurrent(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 64*1024)
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddTarget(metadata.TARGETS, "big.bin", content)
+	repo.Publish()
+
+	client, err := NewClientWithOptions(filepath.Join(repo.Dir, "metadata"), ClientOptions{CacheDir: t.TempDir()})
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, file, err := client.OpenTarget("big.bin")
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer func() { _ = file.Close() }()
+			data, err := io.ReadAll(file)
+			assert.NoError(t, err)
+			assert.Equal(t, content, data)
+		}()
+	}
+	wg.Wait()
+
+	entries, err := os.ReadDir(filepath.Join(client.cacheDir, "objects"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// partialLocks serialises downloads in this process that share a partial file.
+// Entries are removed once no download waits on them.
+var partialLocks = struct {
+	sync.Mutex
+	locks map[string]*partialLock
+}{locks: map[string]*partialLock{}}
+
+// partialLock is held by the download using a partial file
+type partialLock struct {
+	held chan struct{}
+	refs int
+}
+
+// lockPartial waits until no other download in this process uses the partial
+// file at path, or ctx is done. The returned func releases the lock.
+func lockPartial(ctx context.Context, path string) (func(), error) {
+	partialLocks.Lock()
+	lock := partialLocks.locks[path]
+	if lock == nil {
+		lock = &partialLock{held: make(chan struct{}, 1)}
+		partialLocks.locks[path] = lock
+	}
+	lock.refs++
+	partialLocks.Unlock()
+
+	release := func() {
+		partialLocks.Lock()
+		defer partialLocks.Unlock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(partialLocks.locks, path)
+		}
+	}
+
+	select {
+	case lock.held <- struct{}{}:
+		return func() {
+			<-lock.held
+			release()
+		}, nil
+	case <-ctx.Done():
+		release()
+		return nil, ctx.Err()
+	}
+}
+
+// openPartial opens the partial file at path for the exclusive use of one
+// download, waiting for other goroutines and, where files can be locked, other
+// processes using it. The file must be closed before the returned func is called.
+func openPartial(ctx context.Context, path string) (*os.File, func(), error) {
+	unlock, err := lockPartial(ctx, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for {
+		partial, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			unlock()
+			return nil, nil, fmt.Errorf("failed to open partial download: %w", err)
+		}
+		if err := lockFile(ctx, partial); err != nil {
+			_ = partial.Close()
+			unlock()
+			return nil, nil, err
+		}
+
+		// Another process may have finished and moved the file while we waited
+		opened, statErr := partial.Stat()
+		current, err := os.Stat(path)
+		if statErr == nil && err == nil && os.SameFile(opened, current) {
+			return partial, unlock, nil
+		}
+		_ = partial.Close()
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package client
+
+import (
+	"context"
+	"os"
+)
+
+// lockFile does nothing where files cannot be locked, so only downloads in the
+// same process are serialised
+func lockFile(_ context.Context, _ *os.File) error {
+	return nil
+}
@@ -0,0 +1,50 @@
+//go:build unix
+
+package client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kipz/tufzy/internal/testrepo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_DownloadTarget_WaitsForOtherProcess(t *testing.T) {
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddTarget("targets", "app.txt", []byte("app"))
+	repo.Publish()
+
+	client, err := NewClientWithOptions(filepath.Join(repo.Dir, "metadata"), ClientOptions{CacheDir: t.TempDir()})
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+
+	// A separate descriptor holding the file lock stands in for another process
+	partialPath := filepath.Join(client.cacheDir, "partial", "sha256-"+repo.TargetHash("app.txt"))
+	require.NoError(t, os.MkdirAll(filepath.Dir(partialPath), 0755))
+	other, err := os.OpenFile(partialPath, os.O_RDWR|os.O_CREATE, 0644)
+	require.NoError(t, err)
+	require.NoError(t, lockFile(context.Background(), other))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	destPath := filepath.Join(t.TempDir(), "app.txt")
+	_, err = client.DownloadTargetContext(ctx, "app.txt", destPath)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The other process finishes the download and moves the file away
+	_, err = other.WriteString("app")
+	require.NoError(t, err)
+	require.NoError(t, os.Rename(partialPath, filepath.Join(t.TempDir(), "moved")))
+	require.NoError(t, other.Close())
+
+	_, err = client.DownloadTarget("app.txt", destPath)
+	require.NoError(t, err)
+	data, err := os.ReadFile(destPath)
+	require.NoError(t, err)
+	assert.Equal(t, "app", string(data))
+}
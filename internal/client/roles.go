@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+	"github.com/theupdateframework/go-tuf/v2/metadata/trustedmetadata"
+	"github.com/theupdateframework/go-tuf/v2/metadata/updater"
+)
+
+// signingPeriodField is the number of days before expiry tuf-on-ci signs a role again
+const signingPeriodField = "x-tuf-on-ci-signing-period"
+
+// RoleMetadata describes a role's trusted metadata
+type RoleMetadata struct {
+	Name string
+	// Parent is the role that delegates to this role, or empty for top-level roles
+	Parent  string
+	Version int64
+	Expires time.Time
+	// SigningPeriod is how long before expiry tuf-on-ci signs the role again,
+	// or zero when the repository does not set one
+	SigningPeriod time.Duration
+}
+
+// GetRoles returns the top-level roles followed by every delegated role
+func (c *Client) GetRoles() ([]RoleMetadata, error) {
+	return c.GetRolesContext(context.Background())
+}
+
+// GetRolesContext returns the top-level roles (root, timestamp, snapshot and
+// targets) followed by every delegated role in delegation order. Delegated
+// metadata is loaded and verified like GetDelegationTreeContext does.
+func (c *Client) GetRolesContext(ctx context.Context) (roles []RoleMetadata, err error) {
+	ctx, span := c.telemetry.start(ctx, "tufzy.GetRoles")
+	defer func() { c.telemetry.end(ctx, span, "metadata", err) }()
+
+	err = c.withUpdater(ctx, func(u *updater.Updater) error {
+		trusted := u.GetTrustedMetadataSet()
+		if trusted.Root == nil || trusted.Timestamp == nil || trusted.Snapshot == nil || trusted.Targets[metadata.TARGETS] == nil {
+			return fmt.Errorf("top-level metadata not loaded, update first")
+		}
+		tree, err := c.delegationsOf(ctx, &trusted, metadata.TARGETS, map[string]bool{metadata.TARGETS: true})
+		if err != nil {
+			return err
+		}
+
+		// Online roles keep their signing period in root, offline roles in their own metadata
+		root := trusted.Root.Signed
+		targets := trusted.Targets[metadata.TARGETS].Signed
+		roles = []RoleMetadata{
+			{Name: metadata.ROOT, Version: root.Version, Expires: root.Expires, SigningPeriod: signingPeriod(root.UnrecognizedFields)},
+			{Name: metadata.TIMESTAMP, Version: trusted.Timestamp.Signed.Version, Expires: trusted.Timestamp.Signed.Expires,
+				SigningPeriod: topLevelSigningPeriod(&trusted, metadata.TIMESTAMP, trusted.Timestamp.Signed.UnrecognizedFields)},
+			{Name: metadata.SNAPSHOT, Version: trusted.Snapshot.Signed.Version, Expires: trusted.Snapshot.Signed.Expires,
+				SigningPeriod: topLevelSigningPeriod(&trusted, metadata.SNAPSHOT, trusted.Snapshot.Signed.UnrecognizedFields)},
+			{Name: metadata.TARGETS, Version: targets.Version, Expires: targets.Expires,
+				SigningPeriod: topLevelSigningPeriod(&trusted, metadata.TARGETS, targets.UnrecognizedFields)},
+		}
+		walkDelegatedRoles(metadata.TARGETS, tree, map[string]bool{}, func(parent string, delegation Delegation) {
+			signed := trusted.Targets[delegation.Name].Signed
+			roles = append(roles, RoleMetadata{
+				Name:          delegation.Name,
+				Parent:        parent,
+				Version:       signed.Version,
+				Expires:       signed.Expires,
+				SigningPeriod: signingPeriod(signed.UnrecognizedFields),
+			})
+		})
+		return nil
+	})
+	return roles, err
+}
+
+// walkDelegatedRoles calls fn for each role in a delegation tree in pre-order,
+// visiting roles delegated by more than one parent once
+func walkDelegatedRoles(parent string, tree []Delegation, seen map[string]bool, fn func(parent string, delegation Delegation)) {
+	for _, delegation := range tree {
+		if seen[delegation.Name] {
+			continue
+		}
+		seen[delegation.Name] = true
+
+		fn(parent, delegation)
+		walkDelegatedRoles(delegation.Name, delegation.Children, seen, fn)
+	}
+}
+
+// topLevelSigningPeriod returns a top-level role's signing period from its own
+// metadata, falling back to its entry in root
+func topLevelSigningPeriod(trusted *trustedmetadata.TrustedMetadata, role string, fields map[string]any) time.Duration {
+	if period := signingPeriod(fields); period > 0 {
+		return period
+	}
+	if entry := trusted.Root.Signed.Roles[role]; entry != nil {
+		return signingPeriod(entry.UnrecognizedFields)
+	}
+	return 0
+}
+
+// signingPeriod reads tuf-on-ci's signing period, which is a number of days
+func signingPeriod(fields map[string]any) time.Duration {
+	days, ok := fields[signingPeriodField].(float64)
+	if !ok || days <= 0 {
+		return 0
+	}
+	return time.Duration(days * float64(24*time.Hour))
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kipz/tufzy/internal/testrepo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+)
+
+func TestClient_GetRoles(t *testing.T) {
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddDelegation("team", []string{"team/*"})
+	repo.AddDelegationFrom("team", "team-nightly", []string{"team/nightly-*"})
+	repo.AddDelegation("ops", []string{"ops/*"})
+
+	// tuf-on-ci keeps online signing periods in root and offline ones in each role
+	repo.Root.Signed.UnrecognizedFields = map[string]any{signingPeriodField: 60}
+	repo.Root.Signed.Roles[metadata.TIMESTAMP].UnrecognizedFields = map[string]any{signingPeriodField: 1}
+	repo.Targets["team"].Signed.UnrecognizedFields = map[string]any{signingPeriodField: 30}
+	repo.Targets["ops"].Signed.Expires = repo.Targets["ops"].Signed.Expires.AddDate(0, -6, 0)
+	repo.Publish()
+
+	client, err := NewClientWithOptions(filepath.Join(repo.Dir, "metadata"), ClientOptions{CacheDir: t.TempDir()})
+	require.NoError(t, err)
+	_, err = client.GetRoles()
+	require.Error(t, err, "roles listed before an update")
+	require.NoError(t, client.Update())
+
+	roles, err := client.GetRoles()
+	require.NoError(t, err)
+
+	day := 24 * time.Hour
+	expires := repo.Root.Signed.Expires
+	expected := []RoleMetadata{
+		{Name: "root", Version: 1, Expires: expires, SigningPeriod: 60 * day},
+		{Name: "timestamp", Version: 1, Expires: expires, SigningPeriod: day},
+		{Name: "snapshot", Version: 1, Expires: expires},
+		{Name: "targets", Version: 1, Expires: expires},
+		{Name: "team", Parent: "targets", Version: 1, Expires: expires, SigningPeriod: 30 * day},
+		{Name: "team-nightly", Parent: "team", Version: 1, Expires: expires},
+		{Name: "ops", Parent: "targets", Version: 1, Expires: expires.AddDate(0, -6, 0)},
+	}
+	require.Len(t, roles, len(expected))
+	for i := range expected {
+		assert.Equal(t, expected[i].Name, roles[i].Name)
+		assert.Equal(t, expected[i].Parent, roles[i].Parent, roles[i].Name)
+		assert.Equal(t, expected[i].Version, roles[i].Version, roles[i].Name)
+		assert.True(t, expected[i].Expires.Equal(roles[i].Expires), "%s expires %s", roles[i].Name, roles[i].Expires)
+		assert.Equal(t, expected[i].SigningPeriod, roles[i].SigningPeriod, roles[i].Name)
+	}
+}
+
+func TestSigningPeriod(t *testing.T) {
+	testCases := []struct {
+		name     string
+		fields   map[string]any
+		expected time.Duration
+	}{{
+		name:     "days",
+		fields:   map[string]any{signingPeriodField: float64(7)},
+		expected: 7 * 24 * time.Hour,
+	}, {
+		name:     "fractional days",
+		fields:   map[string]any{signingPeriodField: 0.5},
+		expected: 12 * time.Hour,
+	}, {
+		name:   "missing",
+		fields: nil,
+	}, {
+		name:   "not a number",
+		fields: map[string]any{signingPeriodField: "7"},
+	}, {
+		name:   "negative",
+		fields: map[string]any{signingPeriodField: float64(-1)},
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, signingPeriod(tc.fields))
+		})
+	}
+}
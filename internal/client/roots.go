@@ -0,0 +1,178 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+	"github.com/theupdateframework/go-tuf/v2/metadata/trustedmetadata"
+	"github.com/theupdateframework/go-tuf/v2/metadata/updater"
+)
+
+// RootVersion is one verified version of root in the repository's history
+type RootVersion struct {
+	Version            int64     `json:"version"`
+	Expires            time.Time `json:"expires"`
+	ConsistentSnapshot bool      `json:"consistentSnapshot"`
+	// ConsistentSnapshotChanged is set when this version toggled consistent_snapshot
+	ConsistentSnapshotChanged bool `json:"consistentSnapshotChanged,omitempty"`
+	// SignedBy lists the keys of this or the previous root with a valid signature
+	SignedBy []string `json:"signedBy"`
+	// Changes lists the top-level roles whose keys or threshold changed from the
+	// previous version, or every role for version 1
+	Changes []RootRoleChange `json:"changes"`
+}
+
+// RootRoleChange describes how a top-level role changed between root versions
+type RootRoleChange struct {
+	Role        string   `json:"role"`
+	KeysAdded   []string `json:"keysAdded,omitempty"`
+	KeysRemoved []string `json:"keysRemoved,omitempty"`
+	// PreviousThreshold differs from Threshold when the threshold changed
+	PreviousThreshold int `json:"previousThreshold"`
+	Threshold         int `json:"threshold"`
+}
+
+// GetRootHistory returns every version of root up to the trusted root
+func (c *Client) GetRootHistory() ([]RootVersion, error) {
+	return c.GetRootHistoryContext(context.Background())
+}
+
+// GetRootHistoryContext downloads 1.root.json through the trusted root's version,
+// verifying each version against the one before it like a root rotation, and
+// returns what changed in each. The walk must end at the trusted root.
+func (c *Client) GetRootHistoryContext(ctx context.Context) (history []RootVersion, err error) {
+	ctx, span := c.telemetry.start(ctx, "tufzy.GetRootHistory")
+	defer func() { c.telemetry.end(ctx, span, "metadata", err) }()
+
+	err = c.withUpdater(ctx, func(u *updater.Updater) error {
+		current := u.GetTrustedMetadataSet().Root
+		if current == nil {
+			return fmt.Errorf("trusted root not loaded")
+		}
+
+		// Step 1: the first root is only verified against itself
+		data, err := c.downloadRoot(1)
+		if err != nil {
+			return err
+		}
+		chain, err := trustedmetadata.New(data)
+		if err != nil {
+			return fmt.Errorf("failed to verify root version 1: %w", err)
+		}
+		history = append(history, newRootVersion(nil, chain.Root))
+
+		// Step 2: each later root must be signed by the previous root and by itself
+		for version := int64(2); version <= current.Signed.Version; version++ {
+			previous := chain.Root
+			data, err := c.downloadRoot(version)
+			if err != nil {
+				return err
+			}
+			if _, err := chain.UpdateRoot(data); err != nil {
+				return fmt.Errorf("failed to verify root version %d: %w", version, err)
+			}
+			history = append(history, newRootVersion(previous, chain.Root))
+		}
+
+		// Step 3: the walk must arrive at the root the client trusts
+		walked, err := json.Marshal(chain.Root.Signed)
+		if err != nil {
+			return err
+		}
+		trusted, err := json.Marshal(current.Signed)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(walked, trusted) {
+			return &metadata.ErrRepository{Msg: fmt.Sprintf("root version %d does not match the trusted root", current.Signed.Version)}
+		}
+		return nil
+	})
+	return history, err
+}
+
+// downloadRoot downloads a version of root with the updater's fetcher
+func (c *Client) downloadRoot(version int64) ([]byte, error) {
+	name := fmt.Sprintf("%d.root.json", version)
+	data, err := c.calls.DownloadFile(strings.TrimSuffix(c.metadataURL, "/")+"/"+name, 512000, 30*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// newRootVersion describes root, comparing it with the previous version when there is one
+func newRootVersion(previous, root *metadata.Metadata[metadata.RootType]) RootVersion {
+	version := RootVersion{
+		Version:            root.Signed.Version,
+		Expires:            root.Signed.Expires,
+		ConsistentSnapshot: root.Signed.ConsistentSnapshot,
+		SignedBy:           []string{},
+		Changes:            []RootRoleChange{},
+	}
+
+	// A rotation is signed by the previous root's keys as well as its own
+	signers := root.Signed.Roles[metadata.ROOT].KeyIDs
+	if previous != nil {
+		version.ConsistentSnapshotChanged = previous.Signed.ConsistentSnapshot != root.Signed.ConsistentSnapshot
+		signers = append(slices.Clone(previous.Signed.Roles[metadata.ROOT].KeyIDs), signers...)
+	}
+	for _, keyID := range signers {
+		if slices.Contains(version.SignedBy, keyID) {
+			continue
+		}
+		if signedByRootKey(previous, root, keyID) {
+			version.SignedBy = append(version.SignedBy, keyID)
+		}
+	}
+
+	for _, role := range metadata.TOP_LEVEL_ROLE_NAMES {
+		entry := root.Signed.Roles[role]
+		if entry == nil {
+			continue
+		}
+		change := RootRoleChange{Role: role, Threshold: entry.Threshold, KeysAdded: entry.KeyIDs}
+		if previous != nil {
+			var before metadata.Role
+			if entry := previous.Signed.Roles[role]; entry != nil {
+				before = *entry
+			}
+			change.PreviousThreshold = before.Threshold
+			change.KeysAdded = keyIDsNotIn(entry.KeyIDs, before.KeyIDs)
+			change.KeysRemoved = keyIDsNotIn(before.KeyIDs, entry.KeyIDs)
+		}
+		if len(change.KeysAdded) > 0 || len(change.KeysRemoved) > 0 || change.PreviousThreshold != change.Threshold {
+			version.Changes = append(version.Changes, change)
+		}
+	}
+	return version
+}
+
+// signedByRootKey reports whether root has a valid signature from keyID, which
+// may be defined by root or by the previous root
+func signedByRootKey(previous, root *metadata.Metadata[metadata.RootType], keyID string) bool {
+	if _, found := root.Signed.Keys[keyID]; found {
+		return rootSignedBy(root, metadata.ROOT, keyID, root)
+	}
+	if previous != nil {
+		return rootSignedBy(previous, metadata.ROOT, keyID, root)
+	}
+	return false
+}
+
+// keyIDsNotIn returns the key IDs in a that are not in b
+func keyIDsNotIn(a, b []string) []string {
+	var missing []string
+	for _, keyID := range a {
+		if !slices.Contains(b, keyID) {
+			missing = append(missing, keyID)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/kipz/tufzy/internal/testrepo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+)
+
+func TestClient_GetRootHistory(t *testing.T) {
+	repo := testrepo.New(t, t.TempDir())
+	repo.Publish()
+	root := repo.Root
+	metadataDir := filepath.Join(repo.Dir, "metadata")
+	firstKeyID := root.Signed.Roles[metadata.ROOT].KeyIDs[0]
+	firstSigner := repo.Signers[metadata.ROOT]
+
+	// Version 2 rotates the root key and turns off consistent snapshots,
+	// signed by the outgoing and the incoming key
+	require.NoError(t, root.Signed.RevokeKey(firstKeyID, metadata.ROOT))
+	second := repo.NewKey(metadata.ROOT)
+	require.NoError(t, root.Signed.AddKey(second, metadata.ROOT))
+	root.Signed.Version = 2
+	root.Signed.ConsistentSnapshot = false
+	root.ClearSignatures()
+	_, err := root.Sign(firstSigner)
+	require.NoError(t, err)
+	_, err = root.Sign(repo.Signers[metadata.ROOT])
+	require.NoError(t, err)
+	require.NoError(t, root.ToFile(filepath.Join(metadataDir, "2.root.json"), true))
+
+	// Version 3 adds a second root key with a threshold of 2 and turns
+	// consistent snapshots back on
+	third, thirdSigner := newExtraKey(t)
+	require.NoError(t, root.Signed.AddKey(third, metadata.ROOT))
+	root.Signed.Roles[metadata.ROOT].Threshold = 2
+	root.Signed.Version = 3
+	root.Signed.ConsistentSnapshot = true
+	root.ClearSignatures()
+	_, err = root.Sign(repo.Signers[metadata.ROOT])
+	require.NoError(t, err)
+	_, err = root.Sign(thirdSigner)
+	require.NoError(t, err)
+	require.NoError(t, root.ToFile(filepath.Join(metadataDir, "3.root.json"), true))
+
+	client, err := NewClientWithOptions(metadataDir, ClientOptions{CacheDir: t.TempDir()})
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+
+	history, err := client.GetRootHistory()
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+
+	// Version 1 introduces every top-level role
+	assert.Equal(t, int64(1), history[0].Version)
+	assert.Equal(t, []string{firstKeyID}, history[0].SignedBy)
+	require.Len(t, history[0].Changes, 4)
+	for _, change := range history[0].Changes {
+		assert.Len(t, change.KeysAdded, 1, change.Role)
+		assert.Empty(t, change.KeysRemoved, change.Role)
+		assert.Equal(t, 0, change.PreviousThreshold, change.Role)
+		assert.Equal(t, 1, change.Threshold, change.Role)
+	}
+
+	assert.Equal(t, int64(2), history[1].Version)
+	assert.False(t, history[1].ConsistentSnapshot)
+	assert.True(t, history[1].ConsistentSnapshotChanged)
+	assert.Equal(t, []string{firstKeyID, second.ID()}, history[1].SignedBy)
+	assert.Equal(t, []RootRoleChange{{
+		Role:              metadata.ROOT,
+		KeysAdded:         []string{second.ID()},
+		KeysRemoved:       []string{firstKeyID},
+		PreviousThreshold: 1,
+		Threshold:         1,
+	}}, history[1].Changes)
+
+	assert.Equal(t, int64(3), history[2].Version)
+	assert.True(t, history[2].ConsistentSnapshot)
+	assert.True(t, history[2].ConsistentSnapshotChanged)
+	assert.Equal(t, []string{second.ID(), third.ID()}, history[2].SignedBy)
+	assert.Equal(t, []RootRoleChange{{
+		Role:              metadata.ROOT,
+		KeysAdded:         []string{third.ID()},
+		PreviousThreshold: 1,
+		Threshold:         2,
+	}}, history[2].Changes)
+
+	t.Run("rotation not signed by the previous root", func(t *testing.T) {
+		root.Signed.Version = 2
+		root.ClearSignatures()
+		_, err := root.Sign(repo.Signers[metadata.ROOT])
+		require.NoError(t, err)
+		require.NoError(t, root.ToFile(filepath.Join(metadataDir, "2.root.json"), true))
+
+		_, err = client.GetRootHistory()
+		assert.ErrorContains(t, err, "failed to verify root version 2")
+	})
+}
@@ -0,0 +1,270 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/theupdateframework/go-tuf/v2/metadata/fetcher"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// emptyPayloadHash is the SHA256 of an empty request body
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Fetcher fetches s3://bucket/key URLs from S3-compatible object storage,
+// signing every request with AWS Signature Version 4
+type S3Fetcher struct {
+	*FilesystemFetcher
+	endpoint     *url.URL
+	usePathStyle bool
+}
+
+// S3FetcherOptions contains optional configuration for the S3 fetcher
+type S3FetcherOptions struct {
+	// Endpoint overrides the S3 endpoint, e.g. http://localhost:9000 for MinIO
+	// (default: $AWS_ENDPOINT_URL_S3, $AWS_ENDPOINT_URL, then AWS S3 for the region)
+	Endpoint string
+	// Region is the signing region (default: from the AWS config, then us-east-1)
+	Region string
+	// UsePathStyle requests endpoint/bucket/key instead of bucket.endpoint/key
+	UsePathStyle bool
+	// Credentials signs requests (default: the AWS SDK default credential chain)
+	Credentials aws.CredentialsProvider
+	// Validators enables conditional requests for timestamp.json
+	Validators *ValidatorStore
+	// MetadataDir is the local metadata cache served when the timestamp is not modified
+	MetadataDir string
+	// Limiter bounds concurrent requests and bandwidth (default: unlimited)
+	Limiter *Limiter
+	// Logger receives a debug record for every request (default: discard)
+	Logger *slog.Logger
+	// TracerProvider records a span for every request (default: the global provider)
+	TracerProvider trace.TracerProvider
+	// MeterProvider counts timestamp revalidations (default: the global provider)
+	MeterProvider metric.MeterProvider
+}
+
+// NewS3Fetcher creates a new S3 fetcher using the default AWS configuration
+func NewS3Fetcher(ctx context.Context) (*S3Fetcher, error) {
+	return NewS3FetcherWithOptions(ctx, S3FetcherOptions{})
+}
+
+// NewS3FetcherWithOptions creates a new S3 fetcher with custom options
+func NewS3FetcherWithOptions(ctx context.Context, options S3FetcherOptions) (*S3Fetcher, error) {
+	// Fill in anything not given from the shared AWS config (env vars, ~/.aws, IMDS)
+	credentials, region, endpoint := options.Credentials, options.Region, options.Endpoint
+	if endpoint == "" {
+		endpoint = os.Getenv("AWS_ENDPOINT_URL_S3")
+	}
+	if credentials == nil || region == "" || endpoint == "" {
+		cfg, err := config.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS config: %w", err)
+		}
+		if credentials == nil {
+			credentials = cfg.Credentials
+		}
+		if region == "" {
+			region = cfg.Region
+		}
+		if endpoint == "" && cfg.BaseEndpoint != nil {
+			endpoint = *cfg.BaseEndpoint
+		}
+	}
+	if credentials == nil {
+		return nil, fmt.Errorf("no AWS credentials found")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil || endpointURL.Host == "" || (endpointURL.Scheme != "http" && endpointURL.Scheme != "https") {
+		return nil, fmt.Errorf("invalid S3 endpoint %q: expected http(s)://host[:port]", endpoint)
+	}
+
+	signer := &s3SigningTransport{
+		credentials: credentials,
+		signer:      v4.NewSigner(),
+		region:      region,
+	}
+	return &S3Fetcher{
+		FilesystemFetcher: NewFilesystemFetcherWithOptions(FilesystemFetcherOptions{
+			Validators:     options.Validators,
+			MetadataDir:    options.MetadataDir,
+			Limiter:        options.Limiter,
+			WrapTransport:  signer.wrap,
+			Logger:         options.Logger,
+			TracerProvider: options.TracerProvider,
+			MeterProvider:  options.MeterProvider,
+		}),
+		endpoint:     endpointURL,
+		usePathStyle: options.UsePathStyle,
+	}, nil
+}
+
+// DownloadFile downloads an s3:// URL
+func (f *S3Fetcher) DownloadFile(urlPath string, maxLength int64, timeout time.Duration) ([]byte, error) {
+	return f.DownloadFileContext(context.Background(), urlPath, maxLength, timeout)
+}
+
+// DownloadFileContext is DownloadFile with a context that cancels the request
+func (f *S3Fetcher) DownloadFileContext(ctx context.Context, urlPath string, maxLength int64, timeout time.Duration) ([]byte, error) {
+	objectURL, err := f.objectURL(urlPath)
+	if err != nil {
+		return nil, err
+	}
+	return f.FilesystemFetcher.DownloadFileContext(ctx, objectURL, maxLength, timeout)
+}
+
+// StreamFile streams an s3:// URL, resuming with a Range request
+func (f *S3Fetcher) StreamFile(ctx context.Context, urlPath string, w io.Writer, offset, maxLength int64) (int64, error) {
+	objectURL, err := f.objectURL(urlPath)
+	if err != nil {
+		return 0, err
+	}
+	return f.FilesystemFetcher.StreamFile(ctx, objectURL, w, offset, maxLength)
+}
+
+// missingInitialRoot lets buckets that only hold the current root fall back
+// to root.json. Buckets answer 403 rather than 404 for missing keys unless the
+// reader may list them.
+func (f *S3Fetcher) missingInitialRoot(_ string, err error) bool {
+	return isHTTPStatus(err, http.StatusNotFound, http.StatusForbidden)
+}
+
+// objectURL maps s3://bucket/key to the object's HTTP URL on the endpoint
+func (f *S3Fetcher) objectURL(s3URL string) (string, error) {
+	bucket, key, err := parseS3URL(s3URL)
+	if err != nil {
+		return "", err
+	}
+
+	objectURL := *f.endpoint
+	basePath := strings.TrimSuffix(objectURL.Path, "/")
+	if f.usePathStyle {
+		objectURL.Path = basePath + "/" + bucket + "/" + key
+	} else {
+		objectURL.Host = bucket + "." + objectURL.Host
+		objectURL.Path = basePath + "/" + key
+	}
+	objectURL.RawPath = s3EscapePath(objectURL.Path)
+	return objectURL.String(), nil
+}
+
+// parseS3URL splits s3://bucket/key into its bucket and key
+func parseS3URL(s3URL string) (string, string, error) {
+	parsedURL, err := url.Parse(s3URL)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid S3 URL: %w", err)
+	}
+	if parsedURL.Scheme != "s3" || parsedURL.Host == "" {
+		return "", "", fmt.Errorf("invalid S3 URL %q: expected s3://bucket/key", s3URL)
+	}
+	return parsedURL.Host, strings.TrimPrefix(parsedURL.Path, "/"), nil
+}
+
+// s3EscapePath percent-encodes every byte of a path except unreserved characters
+// and slashes, matching the canonical URI S3 uses to verify signatures
+func s3EscapePath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if c == '/' || c == '-' || c == '_' || c == '.' || c == '~' ||
+			('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// s3SigningTransport signs requests with AWS Signature Version 4
+type s3SigningTransport struct {
+	base        http.RoundTripper
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+	region      string
+}
+
+// wrap returns a copy of the transport sending requests through base
+func (t *s3SigningTransport) wrap(base http.RoundTripper) http.RoundTripper {
+	wrapped := *t
+	wrapped.base = base
+	return &wrapped
+}
+
+func (t *s3SigningTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	credentials, err := t.credentials.Retrieve(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+
+	// Requests have no body, so sign the empty payload
+	signed := req.Clone(req.Context())
+	signed.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	err = t.signer.SignHTTP(req.Context(), credentials, signed, emptyPayloadHash, "s3", t.region, time.Now(), func(o *v4.SignerOptions) {
+		// The path is already escaped the way S3 expects
+		o.DisableURIPathEscaping = true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign S3 request: %w", err)
+	}
+	return t.base.RoundTrip(signed)
+}
+
+// NewS3FetcherFactory returns a FetcherFactory for s3:// URLs using the given options.
+// The client's cache, metadata directory and limiter are filled in per repository.
+func NewS3FetcherFactory(options S3FetcherOptions) FetcherFactory {
+	return FetcherFactory{
+		DetectLayout: detectS3Layout,
+		NewFetcher: func(ctx context.Context, _ Layout, fetcherOptions FetcherOptions) (fetcher.Fetcher, error) {
+			options := options
+			options.Validators = NewValidatorStore(filepath.Join(fetcherOptions.CacheDir, "validators.json"))
+			options.MetadataDir = fetcherOptions.MetadataDir
+			options.Limiter = fetcherOptions.Limiter
+			options.Logger = fetcherOptions.Logger
+			options.TracerProvider = fetcherOptions.TracerProvider
+			options.MeterProvider = fetcherOptions.MeterProvider
+
+			s3Fetcher, err := NewS3FetcherWithOptions(ctx, options)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create S3 fetcher: %w", err)
+			}
+			return s3Fetcher, nil
+		},
+	}
+}
+
+// detectS3Layout assumes targets live in ../targets in the same bucket, and
+// requires an explicit targets URL to also be in S3
+func detectS3Layout(metadataURL, targetsURL string) (Layout, error) {
+	bucket, key, err := parseS3URL(metadataURL)
+	if err != nil {
+		return Layout{}, err
+	}
+
+	if targetsURL == "" {
+		targetsURL = "s3://" + bucket + "/" + strings.TrimPrefix(path.Join(path.Dir(key), "targets"), "/")
+	} else if _, _, err := parseS3URL(targetsURL); err != nil {
+		return Layout{}, fmt.Errorf("targets URL must use the same scheme as the metadata URL (s3://): %w", err)
+	}
+	return Layout{MetadataURL: metadataURL, TargetsURL: targetsURL}, nil
+}
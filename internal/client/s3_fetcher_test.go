@@ -0,0 +1,415 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/kipz/tufzy/internal/testrepo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+)
+
+var testS3Credentials = credentials.NewStaticCredentialsProvider("AKIDTUFZY", "tufzy-secret-key", "")
+
+// registerS3 replaces the s3 fetcher for the duration of a test
+func registerS3(t *testing.T, options S3FetcherOptions) {
+	RegisterFetcher("s3", NewS3FetcherFactory(options))
+	t.Cleanup(func() {
+		RegisterFetcher("s3", NewS3FetcherFactory(S3FetcherOptions{}))
+	})
+}
+
+// fakeS3 serves a directory as a path-style bucket and rejects requests
+// without a valid Signature Version 4
+type fakeS3 struct {
+	t           *testing.T
+	bucket      string
+	dir         string
+	credentials aws.Credentials
+	region      string
+	missing     int // Status for missing keys, 403 when the reader may not list the bucket (default: 404)
+	rejected    atomic.Int64
+	notModified atomic.Int64
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.verify(r) {
+		s.rejected.Add(1)
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+
+	key, found := strings.CutPrefix(r.URL.Path, "/"+s.bucket+"/")
+	if !found {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+	file, err := os.Open(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if err != nil && s.missing == http.StatusForbidden {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		http.Error(w, "NoSuchKey", http.StatusNotFound)
+		return
+	}
+	defer func() { _ = file.Close() }()
+	info, err := file.Stat()
+	require.NoError(s.t, err)
+
+	recorder := &statusRecorder{ResponseWriter: w}
+	http.ServeContent(recorder, r, key, info.ModTime(), file)
+	if recorder.status == http.StatusNotModified {
+		s.notModified.Add(1)
+	}
+}
+
+// verify re-signs the request's signed headers and compares the signatures
+func (s *fakeS3) verify(r *http.Request) bool {
+	auth := r.Header.Get("Authorization")
+	signingTime, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil || !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 ") {
+		return false
+	}
+	_, signedHeaders, _ := strings.Cut(auth, "SignedHeaders=")
+	signedHeaders, _, _ = strings.Cut(signedHeaders, ",")
+
+	req := &http.Request{
+		Method: r.Method,
+		URL:    &url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path, RawPath: r.URL.RawPath, RawQuery: r.URL.RawQuery},
+		Host:   r.Host,
+		Header: http.Header{},
+	}
+	for _, name := range strings.Split(signedHeaders, ";") {
+		if name != "host" {
+			req.Header[http.CanonicalHeaderKey(name)] = r.Header.Values(name)
+		}
+	}
+	err = v4.NewSigner().SignHTTP(r.Context(), s.credentials, req, r.Header.Get("X-Amz-Content-Sha256"), "s3", s.region, signingTime, func(o *v4.SignerOptions) {
+		o.DisableURIPathEscaping = true
+	})
+	return err == nil && req.Header.Get("Authorization") == auth
+}
+
+// statusRecorder records the status code written to a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func TestS3Fetcher_ObjectURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		endpoint     string
+		usePathStyle bool
+		s3URL        string
+		want         string
+		wantErr      string
+	}{
+		{
+			name:     "virtual-hosted AWS",
+			endpoint: "https://s3.eu-west-1.amazonaws.com",
+			s3URL:    "s3://tuf-bucket/repo/metadata/1.root.json",
+			want:     "https://tuf-bucket.s3.eu-west-1.amazonaws.com/repo/metadata/1.root.json",
+		},
+		{
+			name:         "path-style endpoint override",
+			endpoint:     "http://localhost:9000",
+			usePathStyle: true,
+			s3URL:        "s3://tuf-bucket/repo/metadata/timestamp.json",
+			want:         "http://localhost:9000/tuf-bucket/repo/metadata/timestamp.json",
+		},
+		{
+			name:         "endpoint with a base path",
+			endpoint:     "https://storage.example.com/s3/",
+			usePathStyle: true,
+			s3URL:        "s3://tuf-bucket/metadata/root.json",
+			want:         "https://storage.example.com/s3/tuf-bucket/metadata/root.json",
+		},
+		{
+			name:         "keys are escaped like S3 canonical URIs",
+			endpoint:     "http://localhost:9000",
+			usePathStyle: true,
+			s3URL:        "s3://tuf-bucket/targets/abc.my file+v1=(2).txt",
+			want:         "http://localhost:9000/tuf-bucket/targets/abc.my%20file%2Bv1%3D%282%29.txt",
+		},
+		{
+			name:     "not an s3 URL",
+			endpoint: "http://localhost:9000",
+			s3URL:    "https://example.com/metadata/root.json",
+			wantErr:  "expected s3://bucket/key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher, err := NewS3FetcherWithOptions(context.Background(), S3FetcherOptions{
+				Endpoint:     tt.endpoint,
+				Region:       "eu-west-1",
+				UsePathStyle: tt.usePathStyle,
+				Credentials:  testS3Credentials,
+			})
+			require.NoError(t, err)
+
+			got, err := fetcher.objectURL(tt.s3URL)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewS3FetcherWithOptions_Defaults(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("AWS_ENDPOINT_URL_S3", "")
+	t.Setenv("AWS_ENDPOINT_URL", "")
+	t.Setenv("AWS_REGION", "ap-south-1")
+
+	fetcher, err := NewS3FetcherWithOptions(context.Background(), S3FetcherOptions{Credentials: testS3Credentials})
+	require.NoError(t, err)
+	assert.Equal(t, "https://s3.ap-south-1.amazonaws.com", fetcher.endpoint.String())
+
+	t.Setenv("AWS_ENDPOINT_URL_S3", "http://minio.internal:9000")
+	fetcher, err = NewS3FetcherWithOptions(context.Background(), S3FetcherOptions{Credentials: testS3Credentials})
+	require.NoError(t, err)
+	assert.Equal(t, "http://minio.internal:9000", fetcher.endpoint.String())
+
+	_, err = NewS3FetcherWithOptions(context.Background(), S3FetcherOptions{
+		Endpoint:    "localhost:9000",
+		Region:      "us-east-1",
+		Credentials: testS3Credentials,
+	})
+	require.ErrorContains(t, err, "invalid S3 endpoint")
+}
+
+func TestS3Fetcher_Client(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddTarget("targets", "dir/file+1.txt", []byte("s3 target content"))
+	repo.Publish()
+
+	creds, err := testS3Credentials.Retrieve(context.Background())
+	require.NoError(t, err)
+	s3 := &fakeS3{t: t, bucket: "tuf", dir: repo.Dir, credentials: creds, region: "eu-central-1"}
+	server := httptest.NewServer(s3)
+	defer server.Close()
+
+	registerS3(t, S3FetcherOptions{
+		Endpoint:     server.URL,
+		Region:       "eu-central-1",
+		UsePathStyle: true,
+		Credentials:  testS3Credentials,
+	})
+
+	t.Run("updates and downloads signed requests", func(t *testing.T) {
+		client, err := NewClient("s3://tuf/metadata")
+		require.NoError(t, err)
+		assert.Equal(t, "s3://tuf/targets", client.targetsURL)
+		require.NoError(t, client.Update())
+
+		destPath := filepath.Join(t.TempDir(), "file.txt")
+		_, err = client.DownloadTarget("dir/file+1.txt", destPath)
+		require.NoError(t, err)
+		data, err := os.ReadFile(destPath)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("s3 target content"), data)
+		assert.Zero(t, s3.rejected.Load())
+
+		// timestamp.json is revalidated with a signed conditional request
+		client, err = NewClient("s3://tuf/metadata")
+		require.NoError(t, err)
+		require.NoError(t, client.Update())
+		assert.Equal(t, int64(1), s3.notModified.Load())
+	})
+
+	t.Run("wrong credentials are rejected", func(t *testing.T) {
+		fetcher, err := NewS3FetcherWithOptions(context.Background(), S3FetcherOptions{
+			Endpoint:     server.URL,
+			Region:       "eu-central-1",
+			UsePathStyle: true,
+			Credentials:  credentials.NewStaticCredentialsProvider("AKIDTUFZY", "wrong-secret", ""),
+		})
+		require.NoError(t, err)
+
+		_, err = fetcher.DownloadFile("s3://tuf/metadata/1.root.json", 512000, 0)
+		var httpErr *metadata.ErrDownloadHTTP
+		require.True(t, errors.As(err, &httpErr))
+		assert.Equal(t, http.StatusForbidden, httpErr.StatusCode)
+		assert.Equal(t, int64(1), s3.rejected.Load())
+	})
+
+	t.Run("initial root falls back when missing keys are forbidden", func(t *testing.T) {
+		// Only the current root is published, to a bucket the reader may not list
+		dir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "metadata"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "metadata", "root.json"), repo.MetadataFile("1.root.json"), 0644))
+		private := httptest.NewServer(&fakeS3{t: t, bucket: "tuf", dir: dir, credentials: creds, region: "eu-central-1", missing: http.StatusForbidden})
+		defer private.Close()
+
+		fetcher, err := NewS3FetcherWithOptions(context.Background(), S3FetcherOptions{
+			Endpoint:     private.URL,
+			Region:       "eu-central-1",
+			UsePathStyle: true,
+			Credentials:  testS3Credentials,
+		})
+		require.NoError(t, err)
+
+		data, err := downloadInitialRoot(context.Background(), fetcher, "s3://tuf/metadata", discardLogger)
+		require.NoError(t, err)
+		assert.Equal(t, repo.MetadataFile("1.root.json"), data)
+	})
+}
+
+// putS3Object uploads content with a signed PUT request (an empty key creates the bucket)
+func putS3Object(ctx context.Context, endpoint, bucket, key string, content []byte) error {
+	creds, err := testS3Credentials.Retrieve(ctx)
+	if err != nil {
+		return err
+	}
+
+	objectPath := "/" + bucket
+	if key != "" {
+		objectPath += "/" + key
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint+s3EscapePath(objectPath), bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(content)
+	payloadHash := hex.EncodeToString(sum[:])
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	err = v4.NewSigner().SignHTTP(ctx, creds, req, payloadHash, "s3", "us-east-1", time.Now(), func(o *v4.SignerOptions) {
+		o.DisableURIPathEscaping = true
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("PUT %s: %s", objectPath, resp.Status)
+	}
+	return nil
+}
+
+// TestS3Integration_MinIO tests the full S3 flow against a MinIO server using testcontainers
+func TestS3Integration_MinIO(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+	t.Setenv("HOME", t.TempDir())
+
+	ctx := context.Background()
+	creds, err := testS3Credentials.Retrieve(ctx)
+	require.NoError(t, err)
+
+	// Start MinIO with the test credentials
+	req := testcontainers.ContainerRequest{
+		Image:        "minio/minio:latest",
+		Cmd:          []string{"server", "/data"},
+		ExposedPorts: []string{"9000/tcp"},
+		Env: map[string]string{
+			"MINIO_ROOT_USER":     creds.AccessKeyID,
+			"MINIO_ROOT_PASSWORD": creds.SecretAccessKey,
+		},
+		WaitingFor: wait.ForHTTP("/minio/health/live").WithPort("9000/tcp"),
+	}
+	minioContainer, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	require.NoError(t, err)
+	defer func() {
+		_ = minioContainer.Terminate(ctx)
+	}()
+
+	host, err := minioContainer.Host(ctx)
+	require.NoError(t, err)
+	port, err := minioContainer.MappedPort(ctx, "9000")
+	require.NoError(t, err)
+	endpoint := fmt.Sprintf("http://%s:%s", host, port.Port())
+
+	// Upload a signed repository under a prefix
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddTarget("targets", "app/config.json", []byte(`{"minio":true}`))
+	repo.AddDelegation("team", []string{"team/*"})
+	repo.AddTarget("team", "team/notes.txt", []byte("delegated via s3"))
+	repo.Publish()
+
+	require.NoError(t, putS3Object(ctx, endpoint, "tuf-repos", "", nil))
+	err = filepath.WalkDir(repo.Dir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(repo.Dir, filePath)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		return putS3Object(ctx, endpoint, "tuf-repos", "prod/"+filepath.ToSlash(rel), content)
+	})
+	require.NoError(t, err)
+
+	registerS3(t, S3FetcherOptions{
+		Endpoint:     endpoint,
+		Region:       "us-east-1",
+		UsePathStyle: true,
+		Credentials:  testS3Credentials,
+	})
+
+	client, err := NewClient("s3://tuf-repos/prod/metadata")
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+
+	targets, err := client.GetTargets()
+	require.NoError(t, err)
+	assert.Len(t, targets, 2)
+
+	for name, want := range map[string][]byte{
+		"app/config.json": []byte(`{"minio":true}`),
+		"team/notes.txt":  []byte("delegated via s3"),
+	} {
+		destPath := filepath.Join(t.TempDir(), filepath.Base(name))
+		_, err := client.DownloadTarget(name, destPath)
+		require.NoError(t, err, name)
+		data, err := os.ReadFile(destPath)
+		require.NoError(t, err)
+		assert.Equal(t, want, data)
+	}
+}
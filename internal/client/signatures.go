@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+	"github.com/theupdateframework/go-tuf/v2/metadata/updater"
+)
+
+// RoleSignatures describes the keys authorised to sign a role and which of them have
+type RoleSignatures struct {
+	Name string
+	// Parent is the role that delegates to this role, or empty for top-level roles
+	Parent    string
+	Version   int64
+	Threshold int
+	Keys      []SignatureKey
+}
+
+// SignatureKey is a key authorised to sign a role
+type SignatureKey struct {
+	KeyID string
+	// Type and Scheme are empty when the delegating role does not define the key
+	Type   string
+	Scheme string
+	// Signed is set when the role's metadata carries a valid signature from the key
+	Signed bool
+}
+
+// Signed returns the number of keys with a valid signature
+func (r RoleSignatures) Signed() int {
+	signed := 0
+	for _, key := range r.Keys {
+		if key.Signed {
+			signed++
+		}
+	}
+	return signed
+}
+
+// Margin returns how many valid signatures the role has beyond its threshold.
+// At zero, losing any one signature breaks the role.
+func (r RoleSignatures) Margin() int {
+	return r.Signed() - r.Threshold
+}
+
+// GetSignatures returns the signatures of the top-level roles followed by every delegated role
+func (c *Client) GetSignatures() ([]RoleSignatures, error) {
+	return c.GetSignaturesContext(context.Background())
+}
+
+// GetSignaturesContext returns, for the top-level roles followed by every delegated
+// role, each authorised key and whether the role's trusted metadata carries a valid
+// signature from it. Delegated metadata is loaded and verified like GetRolesContext does.
+func (c *Client) GetSignaturesContext(ctx context.Context) (report []RoleSignatures, err error) {
+	ctx, span := c.telemetry.start(ctx, "tufzy.GetSignatures")
+	defer func() { c.telemetry.end(ctx, span, "metadata", err) }()
+
+	err = c.withUpdater(ctx, func(u *updater.Updater) error {
+		trusted := u.GetTrustedMetadataSet()
+		if trusted.Root == nil || trusted.Timestamp == nil || trusted.Snapshot == nil || trusted.Targets[metadata.TARGETS] == nil {
+			return fmt.Errorf("top-level metadata not loaded, update first")
+		}
+		tree, err := c.delegationsOf(ctx, &trusted, metadata.TARGETS, map[string]bool{metadata.TARGETS: true})
+		if err != nil {
+			return err
+		}
+
+		// Top-level roles are delegated by root, including root itself
+		root := trusted.Root
+		topLevel := []struct {
+			name     string
+			version  int64
+			verified any
+		}{
+			{metadata.ROOT, root.Signed.Version, root},
+			{metadata.TIMESTAMP, trusted.Timestamp.Signed.Version, trusted.Timestamp},
+			{metadata.SNAPSHOT, trusted.Snapshot.Signed.Version, trusted.Snapshot},
+			{metadata.TARGETS, trusted.Targets[metadata.TARGETS].Signed.Version, trusted.Targets[metadata.TARGETS]},
+		}
+		for _, role := range topLevel {
+			entry := root.Signed.Roles[role.name]
+			if entry == nil {
+				continue
+			}
+			report = append(report, RoleSignatures{
+				Name:      role.name,
+				Version:   role.version,
+				Threshold: entry.Threshold,
+				Keys: signatureKeys(entry.KeyIDs, root.Signed.Keys, func(keyID string) bool {
+					return rootSignedBy(root, role.name, keyID, role.verified)
+				}),
+			})
+		}
+
+		walkDelegatedRoles(metadata.TARGETS, tree, map[string]bool{}, func(parent string, delegation Delegation) {
+			delegator := trusted.Targets[parent]
+			delegated := trusted.Targets[delegation.Name]
+			report = append(report, RoleSignatures{
+				Name:      delegation.Name,
+				Parent:    parent,
+				Version:   delegated.Signed.Version,
+				Threshold: delegation.Threshold,
+				Keys: signatureKeys(delegation.KeyIDs, delegator.Signed.Delegations.Keys, func(keyID string) bool {
+					return targetsSignedBy(delegator, delegation.Name, keyID, delegated)
+				}),
+			})
+		})
+		return nil
+	})
+	return report, err
+}
+
+// signatureKeys describes each authorised key, checking its signature with signedBy
+func signatureKeys(keyIDs []string, keys map[string]*metadata.Key, signedBy func(keyID string) bool) []SignatureKey {
+	described := make([]SignatureKey, 0, len(keyIDs))
+	for _, keyID := range keyIDs {
+		key := SignatureKey{KeyID: keyID}
+		if defined, found := keys[keyID]; found {
+			key.Type = defined.Type
+			key.Scheme = defined.Scheme
+			key.Signed = signedBy(keyID)
+		}
+		described = append(described, key)
+	}
+	return described
+}
+
+// rootSignedBy reports whether a top-level role's metadata has a valid signature
+// from keyID. go-tuf only verifies thresholds, so this verifies against a copy of
+// root that delegates the role to that key alone.
+func rootSignedBy(root *metadata.Metadata[metadata.RootType], role, keyID string, verified any) bool {
+	signed := root.Signed
+	signed.Roles = map[string]*metadata.Role{role: {KeyIDs: []string{keyID}, Threshold: 1}}
+	delegator := &metadata.Metadata[metadata.RootType]{Signed: signed}
+	return delegator.VerifyDelegate(role, verified) == nil
+}
+
+// targetsSignedBy reports whether a delegated role's metadata has a valid
+// signature from keyID, like rootSignedBy
+func targetsSignedBy(parent *metadata.Metadata[metadata.TargetsType], role, keyID string, verified *metadata.Metadata[metadata.TargetsType]) bool {
+	delegations := *parent.Signed.Delegations
+	delegations.Roles = []metadata.DelegatedRole{{Name: role, KeyIDs: []string{keyID}, Threshold: 1}}
+	delegations.SuccinctRoles = nil
+	signed := parent.Signed
+	signed.Delegations = &delegations
+	delegator := &metadata.Metadata[metadata.TargetsType]{Signed: signed}
+	return delegator.VerifyDelegate(role, verified) == nil
+}
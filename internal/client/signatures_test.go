@@ -0,0 +1,95 @@
+package client
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/kipz/tufzy/internal/testrepo"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+)
+
+// newExtraKey generates a key pair that is not a role's primary signer
+func newExtraKey(t *testing.T) (*metadata.Key, signature.Signer) {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := signature.LoadSigner(private, crypto.Hash(0))
+	require.NoError(t, err)
+	key, err := metadata.KeyFromPublicKey(public)
+	require.NoError(t, err)
+	return key, signer
+}
+
+func TestClient_GetSignatures(t *testing.T) {
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddDelegation("team", []string{"team/*"})
+
+	// targets needs 2 of 3 keys: the second signs, the third's signature is bogus
+	second, secondSigner := newExtraKey(t)
+	third, _ := newExtraKey(t)
+	require.NoError(t, repo.Root.Signed.AddKey(second, metadata.TARGETS))
+	require.NoError(t, repo.Root.Signed.AddKey(third, metadata.TARGETS))
+	repo.Root.Signed.Roles[metadata.TARGETS].Threshold = 2
+
+	// team needs 1 of 2 keys and has both signatures
+	backup, backupSigner := newExtraKey(t)
+	require.NoError(t, repo.Targets[metadata.TARGETS].Signed.AddKey(backup, "team"))
+	repo.Publish()
+
+	targets := repo.Targets[metadata.TARGETS]
+	_, err := targets.Sign(secondSigner)
+	require.NoError(t, err)
+	targets.Signatures = append(targets.Signatures, metadata.Signature{KeyID: third.ID(), Signature: []byte("bogus")})
+	require.NoError(t, targets.ToFile(filepath.Join(repo.Dir, "metadata", "1.targets.json"), true))
+	_, err = repo.Targets["team"].Sign(backupSigner)
+	require.NoError(t, err)
+	require.NoError(t, repo.Targets["team"].ToFile(filepath.Join(repo.Dir, "metadata", "1.team.json"), true))
+
+	client, err := NewClientWithOptions(filepath.Join(repo.Dir, "metadata"), ClientOptions{CacheDir: t.TempDir()})
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+
+	report, err := client.GetSignatures()
+	require.NoError(t, err)
+	require.Len(t, report, 5)
+
+	byName := map[string]RoleSignatures{}
+	for _, role := range report {
+		byName[role.Name] = role
+	}
+	assert.Equal(t, []string{"root", "timestamp", "snapshot", "targets", "team"},
+		[]string{report[0].Name, report[1].Name, report[2].Name, report[3].Name, report[4].Name})
+
+	for _, name := range []string{"root", "timestamp", "snapshot"} {
+		role := byName[name]
+		assert.Equal(t, 1, role.Threshold, name)
+		require.Len(t, role.Keys, 1, name)
+		assert.True(t, role.Keys[0].Signed, name)
+		assert.Equal(t, metadata.KeyTypeEd25519, role.Keys[0].Type, name)
+		assert.Zero(t, role.Margin(), name)
+	}
+
+	role := byName[metadata.TARGETS]
+	assert.Equal(t, 2, role.Threshold)
+	require.Len(t, role.Keys, 3)
+	assert.True(t, role.Keys[0].Signed)
+	assert.Equal(t, second.ID(), role.Keys[1].KeyID)
+	assert.True(t, role.Keys[1].Signed)
+	assert.Equal(t, third.ID(), role.Keys[2].KeyID)
+	assert.False(t, role.Keys[2].Signed, "bogus signature was accepted")
+	assert.Equal(t, 2, role.Signed())
+	assert.Zero(t, role.Margin())
+
+	role = byName["team"]
+	assert.Equal(t, metadata.TARGETS, role.Parent)
+	assert.Equal(t, 1, role.Threshold)
+	assert.Equal(t, 2, role.Signed())
+	assert.Equal(t, 1, role.Margin())
+}
@@ -0,0 +1,308 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/theupdateframework/go-tuf/v2/metadata/updater"
+)
+
+// SyncStateFile records what was synced, in the root of a synced directory
+const SyncStateFile = ".tufzy-sync.json"
+
+// ErrSyncDirInUse is returned when Sync would delete files that it did not
+// sync from the same repository, unless SyncOptions.Force is set
+var ErrSyncDirInUse = errors.New("directory has files not synced from this repository")
+
+// syncTempDir holds downloads in a synced directory until they are verified
+const syncTempDir = ".tufzy-sync.tmp"
+
+// SyncOptions configures Sync
+type SyncOptions struct {
+	// Patterns select the targets to sync, like path.Match where * does not
+	// match /. Every target is synced when there are none.
+	Patterns []string
+	// Force allows syncing into a non-empty directory that was not synced from
+	// this repository before, deleting the files that are not targets
+	Force bool
+}
+
+// SyncResult describes what Sync changed
+type SyncResult struct {
+	SnapshotVersion int64 `json:"snapshotVersion"`
+	// UpToDate is set when the state file showed nothing could have changed,
+	// so no target was checked
+	UpToDate   bool     `json:"upToDate"`
+	Downloaded []string `json:"downloaded"`
+	Deleted    []string `json:"deleted"`
+	Unchanged  int      `json:"unchanged"`
+}
+
+// syncState is the content of SyncStateFile
+type syncState struct {
+	MetadataURL     string                `json:"metadataURL"`
+	SnapshotVersion int64                 `json:"snapshotVersion"`
+	Patterns        []string              `json:"patterns"`
+	Files           map[string]syncedFile `json:"files"`
+}
+
+// syncedFile is a target written to a synced directory
+type syncedFile struct {
+	Length int64             `json:"length"`
+	Hashes map[string]string `json:"hashes"`
+}
+
+// Sync makes dir contain exactly the targets matching the options
+func (c *Client) Sync(dir string, options SyncOptions) (*SyncResult, error) {
+	return c.SyncContext(context.Background(), dir, options)
+}
+
+// SyncContext makes dir contain exactly the verified targets matching the
+// options, named by their target paths. New and changed targets are downloaded
+// and verified before being renamed into place, so no file is ever partially
+// written, and files that are no longer targets are deleted. The snapshot
+// version is recorded in SyncStateFile, so a repeated sync of an unchanged
+// repository only checks that the synced files still exist.
+func (c *Client) SyncContext(ctx context.Context, dir string, options SyncOptions) (result *SyncResult, err error) {
+	ctx, span := c.telemetry.start(ctx, "tufzy.Sync")
+	defer func() { c.telemetry.end(ctx, span, "target", err) }()
+
+	for _, pattern := range options.Patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	patterns := slices.Clone(options.Patterns)
+	sort.Strings(patterns)
+
+	var snapshotVersion int64
+	err = c.withUpdater(ctx, func(u *updater.Updater) error {
+		trusted := u.GetTrustedMetadataSet()
+		if trusted.Snapshot == nil {
+			return fmt.Errorf("top-level metadata not loaded, update first")
+		}
+		snapshotVersion = trusted.Snapshot.Signed.Version
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result = &SyncResult{SnapshotVersion: snapshotVersion, Downloaded: []string{}, Deleted: []string{}}
+
+	// Step 1: skip the sync if the last one used the same snapshot and its files are still there
+	previous, err := readSyncState(dir)
+	if err != nil {
+		return nil, err
+	}
+	if previous != nil && previous.MetadataURL == c.metadataURL && previous.SnapshotVersion == snapshotVersion &&
+		slices.Equal(previous.Patterns, patterns) && syncedFilesPresent(dir, previous.Files) {
+		result.UpToDate = true
+		result.Unchanged = len(previous.Files)
+		return result, nil
+	}
+	if !options.Force {
+		if err := checkSyncDir(dir, previous, c.metadataURL); err != nil {
+			return nil, err
+		}
+	}
+
+	// Step 2: resolve every matching target the way a download would
+	tree, err := c.GetDelegationTreeContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	listed, err := c.targetsOf(ctx, tree)
+	if err != nil {
+		return nil, err
+	}
+	wanted := map[string]*TargetInfo{}
+	var names []string
+	for _, target := range listed {
+		if _, found := wanted[target.Name]; found || !matchesAny(patterns, target.Name) {
+			continue
+		}
+		if !filepath.IsLocal(filepath.FromSlash(target.Name)) || target.Name == SyncStateFile ||
+			strings.HasPrefix(target.Name, syncTempDir+"/") {
+			return nil, fmt.Errorf("target %s cannot be synced to a local path", target.Name)
+		}
+		info, err := c.GetTargetInfoContext(ctx, target.Name)
+		if errors.Is(err, ErrTargetNotFound) {
+			// Listed by a role that is not trusted for the path
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		wanted[target.Name] = info
+		names = append(names, target.Name)
+	}
+
+	// Step 3: download new and changed targets, verifying them before they are renamed into place
+	tempDir := filepath.Join(dir, syncTempDir)
+	if err := os.RemoveAll(tempDir); err != nil {
+		return nil, fmt.Errorf("failed to clean up previous sync: %w", err)
+	}
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create sync directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tempDir) }()
+
+	for i, name := range names {
+		dest := filepath.Join(dir, filepath.FromSlash(name))
+		if _, err := os.Stat(dest); err == nil {
+			if _, err := c.VerifyTargetContext(ctx, name, dest); err == nil {
+				result.Unchanged++
+				continue
+			}
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		temp := filepath.Join(tempDir, fmt.Sprintf("%d", i))
+		if _, err := c.DownloadTargetContext(ctx, name, temp); err != nil {
+			return nil, fmt.Errorf("failed to sync %s: %w", name, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return nil, fmt.Errorf("failed to sync %s: %w", name, err)
+		}
+		if err := os.Rename(temp, dest); err != nil {
+			return nil, fmt.Errorf("failed to sync %s: %w", name, err)
+		}
+		result.Downloaded = append(result.Downloaded, name)
+	}
+
+	// Step 4: delete everything else, then record the sync
+	if result.Deleted, err = deleteUnwanted(dir, wanted); err != nil {
+		return nil, err
+	}
+	state := syncState{MetadataURL: c.metadataURL, SnapshotVersion: snapshotVersion, Patterns: patterns, Files: map[string]syncedFile{}}
+	for name, info := range wanted {
+		state.Files[name] = syncedFile{Length: info.Length, Hashes: info.Hashes}
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, SyncStateFile), data); err != nil {
+		return nil, fmt.Errorf("failed to write sync state: %w", err)
+	}
+	return result, nil
+}
+
+// readSyncState returns the state of the last sync into dir, or nil if there was none
+func readSyncState(dir string) (*syncState, error) {
+	data, err := os.ReadFile(filepath.Join(dir, SyncStateFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+	var state syncState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse sync state %s: %w", filepath.Join(dir, SyncStateFile), err)
+	}
+	return &state, nil
+}
+
+// checkSyncDir refuses to delete files from a directory that tufzy did not sync
+// from this repository
+func checkSyncDir(dir string, previous *syncState, metadataURL string) error {
+	if previous != nil {
+		if previous.MetadataURL != metadataURL {
+			return fmt.Errorf("%w: %s was synced from %s", ErrSyncDirInUse, dir, previous.MetadataURL)
+		}
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("%w: %s is not empty and was not synced before", ErrSyncDirInUse, dir)
+	}
+	return nil
+}
+
+// syncedFilesPresent reports whether every synced file still exists with its length
+func syncedFilesPresent(dir string, files map[string]syncedFile) bool {
+	for name, file := range files {
+		info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil || !info.Mode().IsRegular() || info.Size() != file.Length {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAny reports whether name matches one of the patterns, or there are none
+func matchesAny(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteUnwanted removes the files in dir that are not wanted targets, then any
+// directories left empty, and returns the target paths of the deleted files
+func deleteUnwanted(dir string, wanted map[string]*TargetInfo) ([]string, error) {
+	deleted := []string{}
+	var dirs []string
+	err := filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		switch {
+		case name == ".":
+			return nil
+		case name == SyncStateFile:
+			return nil
+		case name == syncTempDir:
+			return filepath.SkipDir
+		case entry.IsDir():
+			dirs = append(dirs, file)
+			return nil
+		case wanted[name] != nil:
+			return nil
+		}
+		if err := os.Remove(file); err != nil {
+			return err
+		}
+		deleted = append(deleted, name)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete old files: %w", err)
+	}
+
+	// Deepest first, so parents are empty by the time they are reached
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if entries, err := os.ReadDir(dirs[i]); err == nil && len(entries) == 0 {
+			_ = os.Remove(dirs[i])
+		}
+	}
+	return deleted, nil
+}
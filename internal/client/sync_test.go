@@ -0,0 +1,109 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kipz/tufzy/internal/testrepo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+)
+
+func TestClient_Sync(t *testing.T) {
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddTarget(metadata.TARGETS, "policies/a.rego", []byte("a v1"))
+	repo.AddTarget(metadata.TARGETS, "policies/b.rego", []byte("b"))
+	repo.AddTarget(metadata.TARGETS, "other.txt", []byte("other"))
+	repo.AddDelegation("team", []string{"policies/team-*"})
+	repo.AddTarget("team", "policies/team-x.rego", []byte("x"))
+	repo.Publish()
+
+	cacheDir := t.TempDir()
+	updatedClient := func() *Client {
+		client, err := NewClientWithOptions(filepath.Join(repo.Dir, "metadata"), ClientOptions{CacheDir: cacheDir})
+		require.NoError(t, err)
+		require.NoError(t, client.Update())
+		return client
+	}
+	dir := filepath.Join(t.TempDir(), "sync")
+	options := SyncOptions{Patterns: []string{"policies/*"}}
+	readFile := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	result, err := updatedClient().Sync(dir, options)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"policies/a.rego", "policies/b.rego", "policies/team-x.rego"}, result.Downloaded)
+	assert.Empty(t, result.Deleted)
+	assert.Equal(t, "a v1", readFile("policies/a.rego"))
+	assert.Equal(t, "x", readFile("policies/team-x.rego"))
+	assert.NoFileExists(t, filepath.Join(dir, "other.txt"))
+	assert.FileExists(t, filepath.Join(dir, SyncStateFile))
+	assert.NoDirExists(t, filepath.Join(dir, syncTempDir))
+
+	// Nothing changed, so the state file is enough
+	result, err = updatedClient().Sync(dir, options)
+	require.NoError(t, err)
+	assert.True(t, result.UpToDate)
+	assert.Equal(t, 3, result.Unchanged)
+
+	// A damaged file is replaced even though the repository did not change
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "policies/b.rego"), []byte("tampered"), 0644))
+	result, err = updatedClient().Sync(dir, options)
+	require.NoError(t, err)
+	assert.False(t, result.UpToDate)
+	assert.Equal(t, []string{"policies/b.rego"}, result.Downloaded)
+	assert.Equal(t, 2, result.Unchanged)
+	assert.Equal(t, "b", readFile("policies/b.rego"))
+
+	// Changed and new targets are downloaded, removed targets and stray files deleted
+	repo.AddTarget(metadata.TARGETS, "policies/a.rego", []byte("a v2"))
+	repo.AddTarget(metadata.TARGETS, "policies/c.rego", []byte("c"))
+	delete(repo.Targets[metadata.TARGETS].Signed.Targets, "policies/b.rego")
+	delete(repo.Files, "policies/b.rego")
+	repo.Targets[metadata.TARGETS].Signed.Version++
+	repo.Snapshot.Signed.Version++
+	repo.Timestamp.Signed.Version++
+	repo.Publish()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "stray"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stray", "file.txt"), []byte("stray"), 0644))
+
+	result, err = updatedClient().Sync(dir, options)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.SnapshotVersion)
+	assert.Equal(t, []string{"policies/a.rego", "policies/c.rego"}, result.Downloaded)
+	assert.Equal(t, []string{"policies/b.rego", "stray/file.txt"}, result.Deleted)
+	assert.Equal(t, 1, result.Unchanged)
+	assert.Equal(t, "a v2", readFile("policies/a.rego"))
+	assert.NoFileExists(t, filepath.Join(dir, "policies/b.rego"))
+	assert.NoDirExists(t, filepath.Join(dir, "stray"))
+}
+
+func TestClient_Sync_RefusesUnknownDirectory(t *testing.T) {
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddTarget(metadata.TARGETS, "app.txt", []byte("app"))
+	repo.Publish()
+
+	client, err := NewClientWithOptions(filepath.Join(repo.Dir, "metadata"), ClientOptions{CacheDir: t.TempDir()})
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "precious.txt"), []byte("keep me"), 0644))
+
+	_, err = client.Sync(dir, SyncOptions{})
+	require.ErrorIs(t, err, ErrSyncDirInUse)
+	assert.FileExists(t, filepath.Join(dir, "precious.txt"))
+
+	result, err := client.Sync(dir, SyncOptions{Force: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app.txt"}, result.Downloaded)
+	assert.Equal(t, []string{"precious.txt"}, result.Deleted)
+
+	_, err = client.Sync(dir, SyncOptions{Patterns: []string{"[bad"}})
+	assert.ErrorContains(t, err, "invalid pattern")
+}
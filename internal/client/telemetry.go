@@ -0,0 +1,141 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path"
+
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies tufzy's tracer and meter
+const instrumentationName = "github.com/kipz/tufzy"
+
+// Attribute keys recorded on spans and metrics
+const (
+	attrRole       = attribute.Key("tuf.role")
+	attrTarget     = attribute.Key("tuf.target")
+	attrKind       = attribute.Key("tufzy.kind")
+	attrCache      = attribute.Key("tufzy.cache")
+	attrCacheLevel = attribute.Key("tufzy.cache.source")
+	attrURL        = attribute.Key("url.full")
+)
+
+// telemetry records spans and metrics with the configured OpenTelemetry providers
+type telemetry struct {
+	tracer         trace.Tracer
+	bytes          metric.Int64Counter
+	cacheHits      metric.Int64Counter
+	cacheMisses    metric.Int64Counter
+	verifyFailures metric.Int64Counter
+}
+
+// newTelemetry creates the instruments, using the global providers when none are given.
+// The global providers do nothing unless the application installs an SDK.
+func newTelemetry(tracerProvider trace.TracerProvider, meterProvider metric.MeterProvider) *telemetry {
+	if tracerProvider == nil {
+		tracerProvider = otel.GetTracerProvider()
+	}
+	if meterProvider == nil {
+		meterProvider = otel.GetMeterProvider()
+	}
+	meter := meterProvider.Meter(instrumentationName)
+
+	// Instrument creation only fails for invalid names, and then returns a
+	// working no-op instrument, so the errors are not worth surfacing
+	t := &telemetry{tracer: tracerProvider.Tracer(instrumentationName)}
+	t.bytes, _ = meter.Int64Counter("tufzy.download.bytes",
+		metric.WithUnit("By"), metric.WithDescription("Bytes of metadata and targets downloaded"))
+	t.cacheHits, _ = meter.Int64Counter("tufzy.cache.hits",
+		metric.WithDescription("Lookups served from a local cache instead of the repository"))
+	t.cacheMisses, _ = meter.Int64Counter("tufzy.cache.misses",
+		metric.WithDescription("Cache lookups that had to go to the repository"))
+	t.verifyFailures, _ = meter.Int64Counter("tufzy.verification.failures",
+		metric.WithDescription("Metadata or targets rejected by TUF verification"))
+	return t
+}
+
+// start starts a span, which must be ended with end
+func (t *telemetry) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return t.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// end records err on span, counting verification failures of the given kind, and ends it
+func (t *telemetry) end(ctx context.Context, span trace.Span, kind string, err error, attrs ...attribute.KeyValue) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		if isVerificationFailure(err) {
+			t.verifyFailures.Add(ctx, 1, metric.WithAttributes(append(attrs, attrKind.String(kind))...))
+		}
+	}
+	span.End()
+}
+
+// addBytes counts downloaded bytes of the given kind
+func (t *telemetry) addBytes(ctx context.Context, kind string, n int64) {
+	if n > 0 {
+		t.bytes.Add(ctx, n, metric.WithAttributes(attrKind.String(kind)))
+	}
+}
+
+// cacheHit counts a lookup served by the given cache
+func (t *telemetry) cacheHit(ctx context.Context, cache string, attrs ...attribute.KeyValue) {
+	t.cacheHits.Add(ctx, 1, metric.WithAttributes(append(attrs, attrCache.String(cache))...))
+}
+
+// cacheMiss counts a lookup the given cache could not serve
+func (t *telemetry) cacheMiss(ctx context.Context, cache string) {
+	t.cacheMisses.Add(ctx, 1, metric.WithAttributes(attrCache.String(cache)))
+}
+
+// isVerificationFailure reports whether err is go-tuf rejecting metadata or a
+// target, as opposed to a download or local failure
+func isVerificationFailure(err error) bool {
+	return errors.Is(err, &metadata.ErrRepository{})
+}
+
+// roleFromURL returns the role a metadata URL is for, e.g. "snapshot" for .../3.snapshot.json
+func roleFromURL(urlPath string) string {
+	return roleFromConsistentName(path.Base(urlPath))
+}
+
+// tracingTransport records a client span for every HTTP request. It does not
+// inject trace headers, which signed S3 requests and registries don't expect.
+type tracingTransport struct {
+	base   http.RoundTripper
+	tracer trace.Tracer
+}
+
+// traceTransport wraps base to trace its requests, or returns base when t is nil
+func traceTransport(base http.RoundTripper, t *telemetry) http.RoundTripper {
+	if t == nil {
+		return base
+	}
+	return &tracingTransport{base: base, tracer: t.tracer}
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := t.tracer.Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("http.request.method", req.Method), attrURL.String(logURL(req.URL))))
+	defer span.End()
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}
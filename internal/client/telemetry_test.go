@@ -0,0 +1,206 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kipz/tufzy/internal/testrepo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// telemetryRecorder collects spans and metrics in memory
+type telemetryRecorder struct {
+	spans          *tracetest.SpanRecorder
+	reader         *sdkmetric.ManualReader
+	tracerProvider *sdktrace.TracerProvider
+	meterProvider  *sdkmetric.MeterProvider
+}
+
+func newTelemetryRecorder() *telemetryRecorder {
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	return &telemetryRecorder{
+		spans:          spans,
+		reader:         reader,
+		tracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+		meterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	}
+}
+
+// spansNamed returns the ended spans with the given name
+func (r *telemetryRecorder) spansNamed(name string) []sdktrace.ReadOnlySpan {
+	var spans []sdktrace.ReadOnlySpan
+	for _, span := range r.spans.Ended() {
+		if span.Name() == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// counter sums a counter's data points that have all of the given attributes
+func (r *telemetryRecorder) counter(t *testing.T, name string, attrs ...attribute.KeyValue) int64 {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, r.reader.Collect(context.Background(), &rm))
+
+	var total int64
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if m.Name != name {
+				continue
+			}
+			for _, point := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				matches := true
+				for _, attr := range attrs {
+					if value, found := point.Attributes.Value(attr.Key); !found || value != attr.Value {
+						matches = false
+					}
+				}
+				if matches {
+					total += point.Value
+				}
+			}
+		}
+	}
+	return total
+}
+
+// spanAttr returns a span attribute as a string
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) string {
+	for _, attr := range span.Attributes() {
+		if attr.Key == key {
+			return attr.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestClient_Telemetry(t *testing.T) {
+	content := []byte("team tool contents")
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddDelegation("team", []string{"team/*"})
+	repo.AddTarget("team", "team/tool.txt", content)
+	repo.Publish()
+
+	server := httptest.NewServer(http.FileServer(http.Dir(repo.Dir)))
+	defer server.Close()
+
+	recorder := newTelemetryRecorder()
+	options := ClientOptions{
+		CacheDir:       t.TempDir(),
+		TracerProvider: recorder.tracerProvider,
+		MeterProvider:  recorder.meterProvider,
+	}
+	client, err := NewClientWithOptions(server.URL+"/metadata", options)
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+	_, err = client.DownloadTarget("team/tool.txt", filepath.Join(t.TempDir(), "tool.txt"))
+	require.NoError(t, err)
+
+	// Metadata fetches are children of the update, each tagged with its role
+	updates := recorder.spansNamed("tufzy.Update")
+	require.Len(t, updates, 1)
+	roles := map[string]bool{}
+	for _, span := range recorder.spansNamed("tufzy.FetchMetadata") {
+		roles[spanAttr(span, attrRole)] = true
+		if spanAttr(span, attrRole) == "snapshot" {
+			assert.Equal(t, updates[0].SpanContext().SpanID(), span.Parent().SpanID())
+		}
+	}
+	for _, role := range []string{"root", "timestamp", "snapshot", "targets", "team"} {
+		assert.True(t, roles[role], "no fetch span for %s", role)
+	}
+
+	// The download span covers the delegated lookup and the HTTP requests
+	downloads := recorder.spansNamed("tufzy.DownloadTarget")
+	require.Len(t, downloads, 1)
+	assert.Equal(t, "team/tool.txt", spanAttr(downloads[0], attrTarget))
+	assert.Equal(t, codes.Unset, downloads[0].Status().Code)
+	var targetRequests int
+	for _, span := range recorder.spansNamed("HTTP GET") {
+		if span.Parent().SpanID() == downloads[0].SpanContext().SpanID() {
+			targetRequests++
+		}
+	}
+	assert.Equal(t, 1, targetRequests)
+
+	assert.Equal(t, int64(len(content)), recorder.counter(t, "tufzy.download.bytes", attrKind.String("target")))
+	assert.Positive(t, recorder.counter(t, "tufzy.download.bytes", attrKind.String("metadata")))
+	assert.Equal(t, int64(1), recorder.counter(t, "tufzy.cache.misses", attrCache.String("timestamp")))
+	assert.Zero(t, recorder.counter(t, "tufzy.verification.failures"))
+
+	// A second client revalidates the timestamp and reuses the delegated role
+	client, err = NewClientWithOptions(server.URL+"/metadata", options)
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+	_, err = client.GetDelegationTree()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), recorder.counter(t, "tufzy.cache.hits", attrCache.String("timestamp")))
+	assert.Equal(t, int64(1), recorder.counter(t, "tufzy.cache.hits", attrCache.String("delegated_metadata"), attrRole.String("team")))
+}
+
+func TestClient_Telemetry_VerificationFailure(t *testing.T) {
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddTarget("targets", "app.txt", []byte("app content"))
+	repo.Publish()
+
+	// Serve different bytes of the same length than the metadata lists
+	targetPath := filepath.Join(repo.Dir, "targets", repo.TargetHash("app.txt")+".app.txt")
+	require.NoError(t, os.WriteFile(targetPath, []byte("bad content"), 0644))
+
+	recorder := newTelemetryRecorder()
+	client, err := NewClientWithOptions(filepath.Join(repo.Dir, "metadata"), ClientOptions{
+		CacheDir:       t.TempDir(),
+		TracerProvider: recorder.tracerProvider,
+		MeterProvider:  recorder.meterProvider,
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+
+	_, err = client.DownloadTarget("app.txt", filepath.Join(t.TempDir(), "app.txt"))
+	require.Error(t, err)
+
+	downloads := recorder.spansNamed("tufzy.DownloadTarget")
+	require.Len(t, downloads, 1)
+	assert.Equal(t, codes.Error, downloads[0].Status().Code)
+	assert.Equal(t, int64(1), recorder.counter(t, "tufzy.verification.failures", attrKind.String("target"), attrTarget.String("app.txt")))
+
+	// Missing targets are not verification failures
+	_, err = client.DownloadTarget("missing.txt", filepath.Join(t.TempDir(), "missing.txt"))
+	require.ErrorIs(t, err, ErrTargetNotFound)
+	assert.Equal(t, int64(1), recorder.counter(t, "tufzy.verification.failures"))
+}
+
+func TestImageCache_Telemetry(t *testing.T) {
+	recorder := newTelemetryRecorder()
+	dir := t.TempDir()
+	data := []byte("counted layer")
+	digest := digestOf(t, data)
+
+	cache, err := NewImageCacheWithOptions(ImageCacheOptions{Dir: dir, MeterProvider: recorder.meterProvider})
+	require.NoError(t, err)
+	_, _ = cache.Get(digest)
+	require.NoError(t, cache.Put(digest, data))
+	_, _ = cache.Get(digest)
+
+	reopened, err := NewImageCacheWithOptions(ImageCacheOptions{Dir: dir, MeterProvider: recorder.meterProvider})
+	require.NoError(t, err)
+	_, _ = reopened.Get(digest)
+
+	assert.Equal(t, int64(1), recorder.counter(t, "tufzy.cache.misses", attrCache.String("oci")))
+	assert.Equal(t, int64(1), recorder.counter(t, "tufzy.cache.hits", attrCache.String("oci"), attrCacheLevel.String("memory")))
+	assert.Equal(t, int64(1), recorder.counter(t, "tufzy.cache.hits", attrCache.String("oci"), attrCacheLevel.String("disk")))
+}
@@ -1,9 +1,13 @@
 package client
 
 import (
+	"context"
+	"io"
 	"net/url"
-	"path/filepath"
+	"path"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,8 +20,14 @@ type TufOnCiFetcher struct {
 
 // NewTufOnCiFetcher creates a fetcher for tuf-on-ci git repositories
 func NewTufOnCiFetcher(metadataBaseURL string) *TufOnCiFetcher {
+	return NewTufOnCiFetcherWithOptions(metadataBaseURL, FilesystemFetcherOptions{})
+}
+
+// NewTufOnCiFetcherWithOptions creates a tuf-on-ci fetcher whose underlying
+// filesystem fetcher uses the given options
+func NewTufOnCiFetcherWithOptions(metadataBaseURL string, options FilesystemFetcherOptions) *TufOnCiFetcher {
 	return &TufOnCiFetcher{
-		FilesystemFetcher: NewFilesystemFetcher(),
+		FilesystemFetcher: NewFilesystemFetcherWithOptions(options),
 		metadataBaseURL:   metadataBaseURL,
 	}
 }
@@ -31,55 +41,64 @@ func (f *TufOnCiFetcher) DownloadFile(urlPath string, maxLength int64, timeout t
 	return f.FilesystemFetcher.DownloadFile(mappedURL, maxLength, timeout)
 }
 
-// mapTufOnCiURL converts TUF versioned URLs to tuf-on-ci git layout
-//
-// TUF expects:
-//   - N.root.json (where N > 1) → metadata/root_history/N.root.json
-//   - 1.root.json → metadata/1.root.json (initial root)
-//   - N.snapshot.json → metadata/snapshot.json (always use current)
-//   - N.timestamp.json → metadata/timestamp.json (always use current)
-//   - N.targets.json → metadata/targets.json (always use current)
-//   - delegated.json → metadata/delegated.json (no version prefix)
+// DownloadFileContext is DownloadFile with a context that cancels the request
+func (f *TufOnCiFetcher) DownloadFileContext(ctx context.Context, urlPath string, maxLength int64, timeout time.Duration) ([]byte, error) {
+	return f.FilesystemFetcher.DownloadFileContext(ctx, f.mapTufOnCiURL(urlPath), maxLength, timeout)
+}
+
+// StreamFile maps TUF versioned filenames to tuf-on-ci git layout before streaming
+func (f *TufOnCiFetcher) StreamFile(ctx context.Context, urlPath string, w io.Writer, offset, maxLength int64) (int64, error) {
+	return f.FilesystemFetcher.StreamFile(ctx, f.mapTufOnCiURL(urlPath), w, offset, maxLength)
+}
+
+// mapTufOnCiURL converts TUF versioned URLs to tuf-on-ci git layout. Only
+// metadata is mapped, since targets can have names that look versioned.
 func (f *TufOnCiFetcher) mapTufOnCiURL(tufURL string) string {
+	if !strings.HasPrefix(tufURL, strings.TrimSuffix(f.metadataBaseURL, "/")+"/") {
+		return tufURL
+	}
 	parsedURL, err := url.Parse(tufURL)
 	if err != nil {
 		return tufURL
 	}
 
-	// Extract filename from path
-	filename := filepath.Base(parsedURL.Path)
-	dirPath := filepath.Dir(parsedURL.Path)
-
-	// Pattern: N.metadata.json where N is version number
-	versionedPattern := regexp.MustCompile(`^(\d+)\.(root|snapshot|timestamp|targets)\.json$`)
-	matches := versionedPattern.FindStringSubmatch(filename)
+	filename := path.Base(parsedURL.Path)
+	mapped, _ := TufOnCiMetadataPath(filename)
+	if mapped == filename {
+		return tufURL
+	}
+	parsedURL.Path = path.Join(path.Dir(parsedURL.Path), mapped)
+	return parsedURL.String()
+}
 
-	if matches != nil {
-		version := matches[1]
-		role := matches[2]
+// tufOnCiVersioned matches the versioned metadata file names TUF clients request
+var tufOnCiVersioned = regexp.MustCompile(`^(\d+)\.(.+)\.json$`)
 
-		var newFilename string
-		switch role {
-		case "root":
-			if version == "1" {
-				// 1.root.json stays as is (initial root)
-				newFilename = filename
-			} else {
-				// N.root.json → root_history/N.root.json
-				dirPath = filepath.Join(dirPath, "root_history")
-				newFilename = filename
-			}
-		case "snapshot", "timestamp", "targets":
-			// N.snapshot.json → snapshot.json (use current, ignore version)
-			newFilename = role + ".json"
-		default:
-			newFilename = filename
+// TufOnCiMetadataPath maps the name of a metadata file requested by a TUF client
+// to its slash-separated path in a tuf-on-ci checkout's metadata directory:
+//   - 1.root.json → 1.root.json (initial root)
+//   - N.root.json (where N > 1) → root_history/N.root.json
+//   - N.role.json for every other role, including delegated roles → role.json (always the current version)
+//   - timestamp.json and other unversioned names pass through as-is
+//
+// For names mapped to a current version, version is the version requested, so
+// callers can check the file holds it; otherwise it is zero.
+func TufOnCiMetadataPath(name string) (mapped string, version int64) {
+	matches := tufOnCiVersioned.FindStringSubmatch(name)
+	if matches == nil {
+		return name, 0
+	}
+	role := matches[2]
+	if role == "root" {
+		if matches[1] == "1" {
+			return name, 0
 		}
-
-		parsedURL.Path = filepath.Join(dirPath, newFilename)
-		return parsedURL.String()
+		return path.Join("root_history", name), 0
 	}
 
-	// Non-versioned files (delegated roles) pass through as-is
-	return tufURL
+	version, err := strconv.ParseInt(matches[1], 10, 64)
+	if err != nil {
+		return name, 0
+	}
+	return role + ".json", version
 }
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTufOnCiMetadataPath(t *testing.T) {
+	tests := []struct {
+		name        string
+		wantPath    string
+		wantVersion int64
+	}{
+		{name: "1.root.json", wantPath: "1.root.json"},
+		{name: "3.root.json", wantPath: "root_history/3.root.json"},
+		{name: "root.json", wantPath: "root.json"},
+		{name: "timestamp.json", wantPath: "timestamp.json"},
+		{name: "7.snapshot.json", wantPath: "snapshot.json", wantVersion: 7},
+		{name: "2.targets.json", wantPath: "targets.json", wantVersion: 2},
+		{name: "4.team.json", wantPath: "team.json", wantVersion: 4},
+		{name: "5.team.nightly.json", wantPath: "team.nightly.json", wantVersion: 5},
+		{name: "team.json", wantPath: "team.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, version := TufOnCiMetadataPath(tt.name)
+			assert.Equal(t, tt.wantPath, path)
+			assert.Equal(t, tt.wantVersion, version)
+		})
+	}
+}
+
+func TestTufOnCiFetcher_MapsURLs(t *testing.T) {
+	f := NewTufOnCiFetcher("file:///repo/metadata")
+	assert.Equal(t, "file:///repo/metadata/root_history/2.root.json", f.mapTufOnCiURL("file:///repo/metadata/2.root.json"))
+	assert.Equal(t, "file:///repo/metadata/team.json", f.mapTufOnCiURL("file:///repo/metadata/3.team.json"))
+	assert.Equal(t, "file:///repo/targets/2.config.json", f.mapTufOnCiURL("file:///repo/targets/2.config.json"))
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Validator holds the HTTP cache validators returned with a metadata file
+type Validator struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	// SHA256 is the digest of the body the validators were returned with
+	SHA256 string `json:"sha256"`
+}
+
+// ValidatorStore persists HTTP cache validators per URL so unchanged
+// metadata can be revalidated with a conditional request.
+// It is safe for concurrent use.
+type ValidatorStore struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]Validator
+}
+
+// NewValidatorStore loads the validator store at path. A missing or
+// unreadable store starts empty, which only costs full downloads.
+func NewValidatorStore(path string) *ValidatorStore {
+	store := &ValidatorStore{
+		path:    path,
+		entries: make(map[string]Validator),
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &store.entries); err != nil {
+			store.entries = make(map[string]Validator)
+		}
+	}
+	return store
+}
+
+// Get returns the validators stored for a URL
+func (s *ValidatorStore) Get(url string) (Validator, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, found := s.entries[url]
+	return v, found
+}
+
+// Put stores the validators for a URL and writes the store to disk
+func (s *ValidatorStore) Put(url string, v Validator) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[url] = v
+	return s.save()
+}
+
+// Delete removes the validators for a URL and writes the store to disk
+func (s *ValidatorStore) Delete(url string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, found := s.entries[url]; !found {
+		return nil
+	}
+	delete(s.entries, url)
+	return s.save()
+}
+
+// save atomically writes the store. Callers must hold s.mu.
+func (s *ValidatorStore) save() error {
+	data, err := json.MarshalIndent(s.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".validators-*")
+	if err != nil {
+		return fmt.Errorf("failed to write validator store: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write validator store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write validator store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write validator store: %w", err)
+	}
+	return nil
+}
+
+// validatorFor builds the validators for a response and its body, or returns
+// false if the server sent none
+func validatorFor(resp *http.Response, body []byte) (Validator, bool) {
+	v := Validator{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		SHA256:       sha256Hex(body),
+	}
+	return v, v.ETag != "" || v.LastModified != ""
+}
+
+// setConditionalHeaders asks the server to only send the body if it changed
+func (v Validator) setConditionalHeaders(req *http.Request) {
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
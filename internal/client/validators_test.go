@@ -0,0 +1,231 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kipz/tufzy/internal/testrepo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+)
+
+func TestValidatorStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "validators.json")
+
+	store := NewValidatorStore(path)
+	_, found := store.Get("https://example.com/metadata/timestamp.json")
+	assert.False(t, found)
+
+	want := Validator{ETag: `"abc"`, LastModified: "Mon, 02 Jan 2006 15:04:05 GMT", SHA256: sha256Hex([]byte("data"))}
+	require.NoError(t, store.Put("https://example.com/metadata/timestamp.json", want))
+
+	// Validators survive a reload
+	reloaded := NewValidatorStore(path)
+	got, found := reloaded.Get("https://example.com/metadata/timestamp.json")
+	require.True(t, found)
+	assert.Equal(t, want, got)
+
+	require.NoError(t, reloaded.Delete("https://example.com/metadata/timestamp.json"))
+	_, found = NewValidatorStore(path).Get("https://example.com/metadata/timestamp.json")
+	assert.False(t, found)
+
+	// A corrupted store starts empty
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0644))
+	_, found = NewValidatorStore(path).Get("https://example.com/metadata/timestamp.json")
+	assert.False(t, found)
+}
+
+// timestampServer serves timestamp.json with an ETag and counts full and not-modified responses
+type timestampServer struct {
+	mu          sync.Mutex
+	content     []byte
+	etag        string
+	full        int
+	notModified int
+}
+
+func (s *timestampServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.etag != "" && r.Header.Get("If-None-Match") == s.etag {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	s.full++
+	if s.etag != "" {
+		w.Header().Set("ETag", s.etag)
+	}
+	_, _ = w.Write(s.content)
+}
+
+func (s *timestampServer) set(content []byte, etag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.content, s.etag = content, etag
+}
+
+func (s *timestampServer) counts() (full, notModified int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	full, notModified = s.full, s.notModified
+	s.full, s.notModified = 0, 0
+	return full, notModified
+}
+
+func TestFilesystemFetcher_ConditionalTimestamp(t *testing.T) {
+	ts := &timestampServer{}
+	server := httptest.NewServer(ts)
+	defer server.Close()
+
+	metadataDir := t.TempDir()
+	fetcher := NewFilesystemFetcherWithOptions(FilesystemFetcherOptions{
+		Validators:  NewValidatorStore(filepath.Join(t.TempDir(), "validators.json")),
+		MetadataDir: metadataDir,
+	})
+	timestampURL := server.URL + "/metadata/timestamp.json"
+
+	// persist mimics the updater persisting an accepted timestamp
+	persist := func(data []byte) {
+		require.NoError(t, os.WriteFile(filepath.Join(metadataDir, "timestamp.json"), data, 0644))
+	}
+
+	t.Run("first request downloads in full", func(t *testing.T) {
+		ts.set([]byte(`{"version":1}`), `"v1"`)
+		data, err := fetcher.DownloadFile(timestampURL, 1024, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []byte(`{"version":1}`), data)
+		persist(data)
+
+		full, notModified := ts.counts()
+		assert.Equal(t, 1, full)
+		assert.Equal(t, 0, notModified)
+	})
+
+	t.Run("unchanged timestamp is served from the cache", func(t *testing.T) {
+		data, err := fetcher.DownloadFile(timestampURL, 1024, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []byte(`{"version":1}`), data)
+
+		full, notModified := ts.counts()
+		assert.Equal(t, 0, full)
+		assert.Equal(t, 1, notModified)
+	})
+
+	t.Run("changed timestamp is downloaded", func(t *testing.T) {
+		ts.set([]byte(`{"version":2}`), `"v2"`)
+		data, err := fetcher.DownloadFile(timestampURL, 1024, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []byte(`{"version":2}`), data)
+
+		full, _ := ts.counts()
+		assert.Equal(t, 1, full)
+	})
+
+	t.Run("validators are ignored when the cached timestamp differs", func(t *testing.T) {
+		// The v2 timestamp was never persisted, so v1 is still cached locally
+		data, err := fetcher.DownloadFile(timestampURL, 1024, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []byte(`{"version":2}`), data)
+
+		full, notModified := ts.counts()
+		assert.Equal(t, 1, full)
+		assert.Equal(t, 0, notModified)
+	})
+
+	t.Run("responses without validators clear the store", func(t *testing.T) {
+		ts.set([]byte(`{"version":3}`), "")
+		data, err := fetcher.DownloadFile(timestampURL, 1024, 0)
+		require.NoError(t, err)
+		persist(data)
+
+		_, found := fetcher.validators.Get(timestampURL)
+		assert.False(t, found)
+	})
+
+	t.Run("other metadata is not conditional", func(t *testing.T) {
+		ts.set([]byte(`{"version":1}`), `"v1"`)
+		ts.counts()
+		for i := 0; i < 2; i++ {
+			_, err := fetcher.DownloadFile(server.URL+"/metadata/1.snapshot.json", 1024, 0)
+			require.NoError(t, err)
+		}
+
+		full, notModified := ts.counts()
+		assert.Equal(t, 2, full)
+		assert.Equal(t, 0, notModified)
+	})
+}
+
+// notModifiedRecorder serves a directory and counts 304 responses for timestamp.json
+type notModifiedRecorder struct {
+	mu          sync.Mutex
+	notModified int
+}
+
+func (nm *notModifiedRecorder) wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		if rec.Code == http.StatusNotModified && filepath.Base(r.URL.Path) == "timestamp.json" {
+			nm.mu.Lock()
+			nm.notModified++
+			nm.mu.Unlock()
+		}
+		for k, v := range rec.Header() {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(rec.Code)
+		_, _ = w.Write(rec.Body.Bytes())
+	})
+}
+
+func TestClient_Update_ConditionalTimestamp(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	// The timestamp expires shortly so expiry can be checked after a 304
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddTarget("targets", "file.txt", []byte("content"))
+	repo.Timestamp.Signed.Expires = time.Now().UTC().Add(2 * time.Second).Truncate(time.Second)
+	repo.Publish()
+
+	// Serve files with Last-Modified in the past so If-Modified-Since matches
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(repo.Dir, "metadata", "timestamp.json"), past, past))
+
+	recorder := &notModifiedRecorder{}
+	server := httptest.NewServer(http.StripPrefix("/repo", recorder.wrap(http.FileServer(http.Dir(repo.Dir)))))
+	defer server.Close()
+	metadataURL := server.URL + "/repo/metadata"
+
+	client, err := NewClient(metadataURL)
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+	assert.Equal(t, 0, recorder.notModified)
+
+	// A new session revalidates the timestamp instead of downloading it
+	client, err = NewClient(metadataURL)
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+	assert.Equal(t, 1, recorder.notModified)
+
+	targets, err := client.GetTargets()
+	require.NoError(t, err)
+	assert.Len(t, targets, 1)
+
+	// An unmodified timestamp is still rejected once it expires
+	time.Sleep(time.Until(repo.Timestamp.Signed.Expires) + 100*time.Millisecond)
+	client, err = NewClient(metadataURL)
+	require.NoError(t, err)
+	err = client.Update()
+	var expired *metadata.ErrExpiredMetadata
+	require.ErrorAs(t, err, &expired)
+	assert.Equal(t, 2, recorder.notModified)
+}
@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WatchEventType is the kind of change a WatchEvent reports
+type WatchEventType string
+
+const (
+	WatchRoleAdded      WatchEventType = "role-added"
+	WatchRoleRemoved    WatchEventType = "role-removed"
+	WatchRoleUpdated    WatchEventType = "role-updated"
+	WatchTargetAdded    WatchEventType = "target-added"
+	WatchTargetRemoved  WatchEventType = "target-removed"
+	WatchTargetModified WatchEventType = "target-modified"
+	// WatchRefreshFailed is reported when a refresh fails; watching continues
+	WatchRefreshFailed WatchEventType = "refresh-failed"
+)
+
+// WatchEvent is a change noticed by Watch
+type WatchEvent struct {
+	Type WatchEventType `json:"type"`
+	Time time.Time      `json:"time"`
+	// Name is the role or target that changed
+	Name string `json:"name,omitempty"`
+	// Role is the parent of a delegated role, or the role that lists a target
+	Role       string `json:"role,omitempty"`
+	OldVersion int64  `json:"oldVersion,omitempty"`
+	NewVersion int64  `json:"newVersion,omitempty"`
+	// OldTarget and NewTarget are set for target events
+	OldTarget *TargetInfo `json:"oldTarget,omitempty"`
+	NewTarget *TargetInfo `json:"newTarget,omitempty"`
+	// Error is set for refresh failures
+	Error string `json:"error,omitempty"`
+}
+
+// WatchOptions configures Watch
+type WatchOptions struct {
+	// Interval is the time between refreshes, one minute when zero
+	Interval time.Duration
+}
+
+// Watch refreshes the repository every interval until ctx is done, calling fn with
+// an event for each role whose metadata changed and each target added, removed or
+// modified. The first refresh sets the baseline and reports nothing. Failed refreshes
+// are reported as WatchRefreshFailed events and the previous state is kept, so a
+// rollback or bad signature never produces events. After each successful refresh
+// the client's other methods see the new metadata.
+//
+// Watch returns ctx's error when ctx is done, or the first error returned by fn.
+func (c *Client) Watch(ctx context.Context, options WatchOptions, fn func(WatchEvent) error) error {
+	interval := options.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	state, err := c.refreshState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load initial state: %w", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		next, err := c.refreshState(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			c.logger.WarnContext(ctx, "watch refresh failed", "error", err)
+			if err := fn(WatchEvent{Type: WatchRefreshFailed, Time: time.Now().UTC(), Error: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, event := range watchEvents(Diff(state, next), time.Now().UTC()) {
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+		state = next
+	}
+}
+
+// refreshState refreshes the client and returns the repository's new state
+func (c *Client) refreshState(ctx context.Context) (*RepositoryState, error) {
+	if err := c.refresh(ctx); err != nil {
+		return nil, err
+	}
+	return c.GetStateContext(ctx)
+}
+
+// refresh updates the client with a new updater and fetcher, since an updater only
+// refreshes once and OCI tags stay pinned for a fetcher's lifetime. Trusted
+// metadata is reused from the cache, so rollbacks are still detected.
+func (c *Client) refresh(ctx context.Context) error {
+	next, err := newClient(ctx, c.sourceURL, c.cacheDir, c.options)
+	if err != nil {
+		return err
+	}
+	if err := next.UpdateContext(ctx); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.updater = next.updater
+	c.calls = next.calls
+	c.fetcher = next.fetcher
+	c.registry = next.registry
+	c.consistentSnapshot = next.consistentSnapshot
+	c.hashPrefixes = next.hashPrefixes
+	return nil
+}
+
+// watchEvents converts the role and target changes of a diff to events
+func watchEvents(diff *RepositoryDiff, now time.Time) []WatchEvent {
+	var events []WatchEvent
+	for _, role := range diff.Roles {
+		event := WatchEvent{Time: now, Name: role.Name, Role: role.Role}
+		switch role.Change {
+		case ChangeAdded:
+			event.Type = WatchRoleAdded
+		case ChangeRemoved:
+			event.Type = WatchRoleRemoved
+		default:
+			event.Type = WatchRoleUpdated
+		}
+		if role.Old != nil {
+			event.OldVersion = role.Old.Version
+		}
+		if role.New != nil {
+			event.NewVersion = role.New.Version
+		}
+		events = append(events, event)
+	}
+
+	for _, target := range diff.Targets {
+		event := WatchEvent{Time: now, Name: target.Name, Role: target.Role, OldTarget: target.Old, NewTarget: target.New}
+		switch target.Change {
+		case ChangeAdded:
+			event.Type = WatchTargetAdded
+		case ChangeRemoved:
+			event.Type = WatchTargetRemoved
+		default:
+			event.Type = WatchTargetModified
+		}
+		events = append(events, event)
+	}
+	return events
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kipz/tufzy/internal/testrepo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+)
+
+func TestClient_Watch(t *testing.T) {
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddTarget(metadata.TARGETS, "app.txt", []byte("v1"))
+	repo.Publish()
+
+	client, err := NewClientWithOptions(filepath.Join(repo.Dir, "metadata"), ClientOptions{CacheDir: t.TempDir()})
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	events := make(chan WatchEvent, 100)
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Watch(ctx, WatchOptions{Interval: 20 * time.Millisecond}, func(event WatchEvent) error {
+			events <- event
+			return nil
+		})
+	}()
+
+	// Unchanged metadata produces no events
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected event %+v", event)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	repo.AddTarget(metadata.TARGETS, "new.txt", []byte("new"))
+	repo.Targets[metadata.TARGETS].Signed.Version++
+	repo.Snapshot.Signed.Version++
+	repo.Timestamp.Signed.Version++
+	repo.Publish()
+
+	// A refresh may catch the repository half-written; those fail and are retried
+	byType := map[WatchEventType]WatchEvent{}
+	for byType[WatchTargetAdded].Name == "" {
+		select {
+		case event := <-events:
+			if event.Type != WatchRefreshFailed {
+				byType[event.Type] = event
+			}
+		case <-ctx.Done():
+			t.Fatalf("no target-added event, got %+v", byType)
+		}
+	}
+
+	updated := byType[WatchRoleUpdated]
+	assert.Contains(t, []string{metadata.TIMESTAMP, metadata.SNAPSHOT, metadata.TARGETS}, updated.Name)
+	assert.Equal(t, int64(1), updated.OldVersion)
+	assert.Equal(t, int64(2), updated.NewVersion)
+
+	added := byType[WatchTargetAdded]
+	assert.Equal(t, "new.txt", added.Name)
+	assert.Equal(t, metadata.TARGETS, added.Role)
+	assert.Nil(t, added.OldTarget)
+	assert.Equal(t, repo.TargetHash("new.txt"), added.NewTarget.Hashes["sha256"])
+
+	// The client itself now trusts the new metadata
+	info, err := client.GetTargetInfo("new.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), info.Length)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestClient_Watch_StopsOnCallbackError(t *testing.T) {
+	repo := testrepo.New(t, t.TempDir())
+	repo.Publish()
+
+	client, err := NewClientWithOptions(filepath.Join(repo.Dir, "metadata"), ClientOptions{CacheDir: t.TempDir()})
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+
+	// Expired metadata fails every refresh after the baseline
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	stop := errors.New("stop")
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Watch(ctx, WatchOptions{Interval: 20 * time.Millisecond}, func(event WatchEvent) error {
+			assert.Equal(t, WatchRefreshFailed, event.Type)
+			assert.NotEmpty(t, event.Error)
+			return stop
+		})
+	}()
+
+	time.Sleep(300 * time.Millisecond)
+	repo.Timestamp.Signed.Expires = time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	repo.Timestamp.Signed.Version++
+	repo.Publish()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, stop)
+	case <-ctx.Done():
+		t.Fatal("watch did not stop")
+	}
+}
+
+func TestWatchEvents(t *testing.T) {
+	now := time.Now()
+	old := &TargetInfo{Name: "a.txt", Length: 1}
+	diff := &RepositoryDiff{
+		Roles: []DiffEntry[RoleState]{
+			{Name: "team", Role: metadata.TARGETS, Change: ChangeAdded, New: &RoleState{Version: 1}},
+			{Name: metadata.SNAPSHOT, Change: ChangeModified, Old: &RoleState{Version: 4}, New: &RoleState{Version: 5}},
+		},
+		Targets: []DiffEntry[TargetInfo]{
+			{Name: "a.txt", Role: metadata.TARGETS, Change: ChangeRemoved, Old: old},
+		},
+	}
+
+	assert.Equal(t, []WatchEvent{
+		{Type: WatchRoleAdded, Time: now, Name: "team", Role: metadata.TARGETS, NewVersion: 1},
+		{Type: WatchRoleUpdated, Time: now, Name: metadata.SNAPSHOT, OldVersion: 4, NewVersion: 5},
+		{Type: WatchTargetRemoved, Time: now, Name: "a.txt", Role: metadata.TARGETS, OldTarget: old},
+	}, watchEvents(diff, now))
+}
+
+func TestClient_Watch_ConcurrentUse(t *testing.T) {
+	repo := testrepo.New(t, t.TempDir())
+	repo.AddTarget(metadata.TARGETS, "app.txt", []byte("v1"))
+	repo.Publish()
+
+	client, err := NewClientWithOptions(filepath.Join(repo.Dir, "metadata"), ClientOptions{CacheDir: t.TempDir()})
+	require.NoError(t, err)
+	require.NoError(t, client.Update())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Watch(ctx, WatchOptions{Interval: 5 * time.Millisecond}, func(WatchEvent) error { return nil })
+	}()
+
+	// Downloads and info run while refreshes swap the client's updater and fetcher
+	destDir := t.TempDir()
+	deadline := time.Now().Add(300 * time.Millisecond)
+	for i := 0; time.Now().Before(deadline); i++ {
+		_, err := client.DownloadTarget("app.txt", filepath.Join(destDir, "app.txt"))
+		require.NoError(t, err)
+		_, err = client.GetRepositoryInfo()
+		require.NoError(t, err)
+		_, err = client.GetDelegations()
+		require.NoError(t, err)
+		if i == 10 {
+			repo.Timestamp.Signed.Version++
+			repo.Publish()
+		}
+	}
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
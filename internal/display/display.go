@@ -1,7 +1,10 @@
 package display
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -94,6 +97,21 @@ func ShowRepositoryInfo(info *client.RepositoryInfo) {
 	fmt.Printf("  Metadata: %s\n", cyan(info.MetadataURL))
 	fmt.Printf("  Targets:  %s\n\n", cyan(info.TargetsURL))
 
+	// Show the digests OCI tags were pinned to so the fetch can be reproduced
+	if len(info.ResolvedDigests) > 0 {
+		refs := make([]string, 0, len(info.ResolvedDigests))
+		for ref := range info.ResolvedDigests {
+			refs = append(refs, ref)
+		}
+		sort.Strings(refs)
+
+		fmt.Printf("%s %s\n", bold("📌"), bold("Resolved Digests:"))
+		for _, ref := range refs {
+			fmt.Printf("  %s\n    → %s\n", ref, cyan(info.ResolvedDigests[ref]))
+		}
+		fmt.Printf("\n")
+	}
+
 	// Show detected settings
 	fmt.Printf("%s %s\n", bold("🔍"), bold("Auto-detected:"))
 	if info.TufOnCiGit {
@@ -166,6 +184,350 @@ func ShowDownloadError(targetName string, err error) {
 	fmt.Printf("%s Failed to download %s: %v\n\n", red("❌"), targetName, err)
 }
 
+// Role check statuses, from best to worst
+const (
+	CheckOK       = "ok"
+	CheckWarning  = "warning"
+	CheckCritical = "critical"
+	CheckExpired  = "expired"
+)
+
+// RoleCheck is the expiry status of one role
+type RoleCheck struct {
+	client.RoleMetadata
+	// Status is CheckOK, CheckWarning, CheckCritical or CheckExpired
+	Status string
+	// Window is the warning window the role was checked against
+	Window time.Duration
+}
+
+// ShowRoleChecks displays the expiry status of every role with a summary
+func ShowRoleChecks(metadataURL string, checks []RoleCheck) {
+	fmt.Printf("\n%s %s %s\n\n", bold("🩺"), bold("Expiry Check"), cyan(metadataURL))
+
+	// Delegated roles are indented under the role that delegates to them
+	depth := map[string]int{}
+	counts := map[string]int{}
+	for _, check := range checks {
+		if check.Parent != "" {
+			depth[check.Name] = depth[check.Parent] + 1
+		}
+		counts[check.Status]++
+
+		status := green("✅")
+		switch check.Status {
+		case CheckWarning:
+			status = yellow("⚠️ ")
+		case CheckCritical:
+			status = red("🔥")
+		case CheckExpired:
+			status = red("❌")
+		}
+
+		detail := fmt.Sprintf("expired %s ago", formatDuration(time.Since(check.Expires)))
+		if check.Status != CheckExpired {
+			detail = fmt.Sprintf("expires in %s", formatDuration(time.Until(check.Expires)))
+		}
+		if check.Status == CheckWarning && check.SigningPeriod > 0 && check.Window == check.SigningPeriod {
+			detail += ", within signing period"
+		}
+
+		name := strings.Repeat("  ", depth[check.Name]) + check.Name
+		fmt.Printf("  %s %-24s v%-4d %s (%s)\n", status, name, check.Version, check.Expires.Format("2006-01-02 15:04"), detail)
+	}
+
+	fmt.Printf("\n  %s %d ok, %d warning, %d critical, %d expired\n\n", bold("Summary:"),
+		counts[CheckOK], counts[CheckWarning], counts[CheckCritical], counts[CheckExpired])
+}
+
+// ShowLintFindings displays lint findings grouped by severity with a summary
+func ShowLintFindings(metadataURL string, findings []client.LintFinding) {
+	fmt.Printf("\n%s %s %s\n\n", bold("🔎"), bold("Lint"), cyan(metadataURL))
+
+	if len(findings) == 0 {
+		fmt.Printf("  %s No problems found\n\n", green("✅"))
+		return
+	}
+
+	counts := map[string]int{}
+	for _, finding := range findings {
+		counts[finding.Severity]++
+
+		status := cyan("ℹ️ ")
+		switch finding.Severity {
+		case client.LintError:
+			status = red("❌")
+		case client.LintWarning:
+			status = yellow("⚠️ ")
+		}
+		fmt.Printf("  %s %-16s %-18s %s\n", status, finding.Role, finding.Check, finding.Message)
+	}
+
+	fmt.Printf("\n  %s %d errors, %d warnings, %d info\n\n", bold("Summary:"),
+		counts[client.LintError], counts[client.LintWarning], counts[client.LintInfo])
+}
+
+// ShowSignatures displays each role's authorised keys, which of them signed,
+// and how far the role is from losing its threshold
+func ShowSignatures(report []client.RoleSignatures) {
+	fmt.Printf("%s %s\n", bold("🔏"), bold("Signatures:"))
+
+	for _, role := range report {
+		name := role.Name
+		if role.Parent != "" {
+			name = fmt.Sprintf("%s (delegated by %s)", role.Name, role.Parent)
+		}
+
+		margin := role.Margin()
+		status := green("✅")
+		detail := fmt.Sprintf("%d more signature(s) than needed", margin)
+		switch {
+		case margin < 0:
+			status = red("❌")
+			detail = "threshold not met"
+		case margin == 0:
+			status = yellow("⚠️ ")
+			detail = "one missing signature breaks this role"
+		}
+
+		fmt.Printf("  %s %s v%d: %d/%d signed, threshold %d (%s)\n",
+			status, bold(name), role.Version, role.Signed(), len(role.Keys), role.Threshold, detail)
+		for _, key := range role.Keys {
+			signed := green("✓ signed  ")
+			if !key.Signed {
+				signed = red("✗ unsigned")
+			}
+			keyType := key.Type + "/" + key.Scheme
+			if key.Type == "" {
+				keyType = red("undefined key")
+			}
+			fmt.Printf("      %s %s %s\n", signed, key.KeyID, keyType)
+		}
+	}
+	fmt.Println()
+}
+
+// ShowKeys displays each key with its owner, the roles that trust it and its fingerprint
+func ShowKeys(keys []client.KeyInfo) {
+	fmt.Printf("\n%s %s (%d)\n\n", bold("🔑"), bold("Keys"), len(keys))
+
+	if len(keys) == 0 {
+		fmt.Printf("  %s No matching keys\n\n", yellow("⚠️"))
+		return
+	}
+
+	for _, key := range keys {
+		owner := key.Owner
+		switch {
+		case owner == "" && key.OnlineURI != "":
+			owner = "online"
+		case owner == "":
+			owner = "unknown owner"
+		}
+		fmt.Printf("  %s %s\n", cyan(key.KeyID), bold(owner))
+
+		roles := strings.Join(key.Roles, ", ")
+		if roles == "" {
+			roles = yellow("none (unused)")
+		}
+		fmt.Printf("    Roles:       %s\n", roles)
+		fmt.Printf("    Scheme:      %s/%s\n", key.Type, key.Scheme)
+		if key.Fingerprint != "" {
+			fmt.Printf("    Fingerprint: SHA256:%s\n", key.Fingerprint)
+		}
+		if key.OnlineURI != "" {
+			fmt.Printf("    Online URI:  %s\n", key.OnlineURI)
+		}
+		fmt.Printf("    Defined by:  %s\n\n", strings.Join(key.DefinedBy, ", "))
+	}
+}
+
+// ShowRootHistory displays each verified version of root and what changed in it
+func ShowRootHistory(history []client.RootVersion) {
+	fmt.Printf("\n%s %s (%d)\n\n", bold("📜"), bold("Root History"), len(history))
+
+	for _, root := range history {
+		fmt.Printf("  %s %s expires %s\n", green("✅"), bold(fmt.Sprintf("v%d", root.Version)), root.Expires.Format("2006-01-02"))
+		fmt.Printf("      Signed by: %s\n", strings.Join(root.SignedBy, ", "))
+		if root.ConsistentSnapshotChanged {
+			fmt.Printf("      %s consistent_snapshot: %t → %t\n", yellow("~"), !root.ConsistentSnapshot, root.ConsistentSnapshot)
+		}
+		for _, change := range root.Changes {
+			if change.PreviousThreshold != change.Threshold {
+				if change.PreviousThreshold == 0 {
+					fmt.Printf("      %s %s threshold: %d\n", cyan("•"), change.Role, change.Threshold)
+				} else {
+					fmt.Printf("      %s %s threshold: %d → %d\n", yellow("~"), change.Role, change.PreviousThreshold, change.Threshold)
+				}
+			}
+			for _, keyID := range change.KeysAdded {
+				fmt.Printf("      %s %s key %s\n", green("+"), change.Role, keyID)
+			}
+			for _, keyID := range change.KeysRemoved {
+				fmt.Printf("      %s %s key %s\n", red("-"), change.Role, keyID)
+			}
+		}
+		if len(root.Changes) == 0 && !root.ConsistentSnapshotChanged {
+			fmt.Printf("      No key, threshold or consistent_snapshot changes\n")
+		}
+		fmt.Println()
+	}
+}
+
+// ShowDiff displays what changed between two repository states
+func ShowDiff(before, after string, diff *client.RepositoryDiff) {
+	fmt.Printf("\n%s %s\n", bold("🔀"), bold("Repository Diff"))
+	fmt.Printf("  %s %s\n  %s %s\n\n", red("---"), before, green("+++"), after)
+
+	if diff.Empty() {
+		fmt.Printf("  %s No differences\n\n", green("✅"))
+		return
+	}
+
+	if len(diff.Roles) > 0 {
+		fmt.Printf("%s\n", bold("Roles:"))
+		for _, role := range diff.Roles {
+			switch role.Change {
+			case client.ChangeAdded:
+				fmt.Printf("  %s %s v%d, expires %s\n", green("+"), roleLabel(role.Name, role.Role), role.New.Version, role.New.Expires.Format("2006-01-02"))
+			case client.ChangeRemoved:
+				fmt.Printf("  %s %s v%d\n", red("-"), roleLabel(role.Name, role.Role), role.Old.Version)
+			default:
+				expires := "expires " + role.New.Expires.Format("2006-01-02")
+				if !role.Old.Expires.Equal(role.New.Expires) {
+					expires = fmt.Sprintf("expires %s → %s", role.Old.Expires.Format("2006-01-02"), role.New.Expires.Format("2006-01-02"))
+				}
+				fmt.Printf("  %s %s v%d → v%d, %s\n", yellow("~"), roleLabel(role.Name, role.Role), role.Old.Version, role.New.Version, expires)
+			}
+		}
+		fmt.Println()
+	}
+
+	if len(diff.Keys) > 0 {
+		fmt.Printf("%s\n", bold("Keys:"))
+		for _, key := range diff.Keys {
+			switch key.Change {
+			case client.ChangeAdded:
+				fmt.Printf("  %s %s%s trusted by %s\n", green("+"), key.Name, keyOwner(key.New), listOrNone(key.New.Roles))
+			case client.ChangeRemoved:
+				fmt.Printf("  %s %s%s trusted by %s\n", red("-"), key.Name, keyOwner(key.Old), listOrNone(key.Old.Roles))
+			default:
+				fmt.Printf("  %s %s%s trusted by %s → %s\n", yellow("~"), key.Name, keyOwner(key.New), listOrNone(key.Old.Roles), listOrNone(key.New.Roles))
+			}
+		}
+		fmt.Println()
+	}
+
+	if len(diff.Delegations) > 0 {
+		fmt.Printf("%s\n", bold("Delegations:"))
+		for _, delegation := range diff.Delegations {
+			label := fmt.Sprintf("%s → %s", delegation.Role, delegation.Name)
+			switch delegation.Change {
+			case client.ChangeAdded:
+				fmt.Printf("  %s %s threshold %d, paths %s\n", green("+"), label, delegation.New.Threshold, strings.Join(delegation.New.Paths, ", "))
+			case client.ChangeRemoved:
+				fmt.Printf("  %s %s\n", red("-"), label)
+			default:
+				fmt.Printf("  %s %s\n", yellow("~"), label)
+				was, now := delegation.Old, delegation.New
+				if was.Threshold != now.Threshold {
+					fmt.Printf("      threshold: %d → %d\n", was.Threshold, now.Threshold)
+				}
+				if strings.Join(was.KeyIDs, ",") != strings.Join(now.KeyIDs, ",") {
+					fmt.Printf("      keys:      %s → %s\n", listOrNone(was.KeyIDs), listOrNone(now.KeyIDs))
+				}
+				if strings.Join(was.Paths, ",") != strings.Join(now.Paths, ",") {
+					fmt.Printf("      paths:     %s → %s\n", listOrNone(was.Paths), listOrNone(now.Paths))
+				}
+				if strings.Join(was.PathHashPrefixes, ",") != strings.Join(now.PathHashPrefixes, ",") {
+					fmt.Printf("      hash prefixes: %s → %s\n", listOrNone(was.PathHashPrefixes), listOrNone(now.PathHashPrefixes))
+				}
+				if was.Terminating != now.Terminating {
+					fmt.Printf("      terminating: %t → %t\n", was.Terminating, now.Terminating)
+				}
+			}
+		}
+		fmt.Println()
+	}
+
+	if len(diff.Targets) > 0 {
+		fmt.Printf("%s\n", bold("Targets:"))
+		for _, target := range diff.Targets {
+			name := target.Name
+			if target.Role != "targets" {
+				name = fmt.Sprintf("%s (%s)", target.Name, target.Role)
+			}
+			switch target.Change {
+			case client.ChangeAdded:
+				fmt.Printf("  %s %s %s\n", green("+"), name, formatSize(target.New.Length))
+				showHashes("", target.New.Hashes)
+			case client.ChangeRemoved:
+				fmt.Printf("  %s %s %s\n", red("-"), name, formatSize(target.Old.Length))
+				showHashes("", target.Old.Hashes)
+			default:
+				size := formatSize(target.New.Length)
+				if target.Old.Length != target.New.Length {
+					size = formatSize(target.Old.Length) + " → " + size
+				}
+				fmt.Printf("  %s %s %s\n", yellow("~"), name, size)
+				showHashes("old ", target.Old.Hashes)
+				showHashes("new ", target.New.Hashes)
+			}
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("  %s %d role, %d key, %d delegation and %d target change(s)\n\n", bold("Summary:"),
+		len(diff.Roles), len(diff.Keys), len(diff.Delegations), len(diff.Targets))
+}
+
+// ShowWatchEvent displays one change noticed while watching a repository
+func ShowWatchEvent(event client.WatchEvent) {
+	timestamp := event.Time.Local().Format("15:04:05")
+	switch event.Type {
+	case client.WatchRoleAdded:
+		fmt.Printf("%s %s %s v%d\n", timestamp, green("+ role"), roleLabel(event.Name, event.Role), event.NewVersion)
+	case client.WatchRoleRemoved:
+		fmt.Printf("%s %s %s v%d\n", timestamp, red("- role"), roleLabel(event.Name, event.Role), event.OldVersion)
+	case client.WatchRoleUpdated:
+		fmt.Printf("%s %s %s v%d → v%d\n", timestamp, cyan("~ role"), roleLabel(event.Name, event.Role), event.OldVersion, event.NewVersion)
+	case client.WatchTargetAdded:
+		fmt.Printf("%s %s %s (%s) sha256:%s\n", timestamp, green("+ target"), event.Name, event.Role, event.NewTarget.Hashes["sha256"])
+	case client.WatchTargetRemoved:
+		fmt.Printf("%s %s %s (%s)\n", timestamp, red("- target"), event.Name, event.Role)
+	case client.WatchTargetModified:
+		fmt.Printf("%s %s %s (%s) sha256:%s → sha256:%s\n", timestamp, yellow("~ target"), event.Name, event.Role,
+			event.OldTarget.Hashes["sha256"], event.NewTarget.Hashes["sha256"])
+	case client.WatchRefreshFailed:
+		fmt.Printf("%s %s %s\n", timestamp, red("❌ refresh failed:"), event.Error)
+	}
+}
+
+// ShowSyncResult displays what a sync changed in a directory
+func ShowSyncResult(dir string, result *client.SyncResult) {
+	if result.UpToDate {
+		fmt.Printf("\n%s %s is up to date with snapshot v%d (%d file(s))\n\n", green("✅"), bold(dir), result.SnapshotVersion, result.Unchanged)
+		return
+	}
+
+	fmt.Printf("\n%s Synced %s to snapshot v%d\n", green("✅"), bold(dir), result.SnapshotVersion)
+	for _, name := range result.Downloaded {
+		fmt.Printf("  %s %s\n", green("+"), name)
+	}
+	for _, name := range result.Deleted {
+		fmt.Printf("  %s %s\n", red("-"), name)
+	}
+	fmt.Printf("  %s %d downloaded, %d deleted, %d unchanged\n\n", bold("Summary:"),
+		len(result.Downloaded), len(result.Deleted), result.Unchanged)
+}
+
+// ShowJSON prints a value as indented JSON
+func ShowJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
 // Helper functions
 
 func showRoleExpiry(role string, version int64, expires time.Time) {
@@ -193,6 +555,38 @@ func showRoleExpiry(role string, version int64, expires time.Time) {
 		timeUntil)
 }
 
+func roleLabel(name, parent string) string {
+	if parent == "" {
+		return name
+	}
+	return fmt.Sprintf("%s (delegated by %s)", name, parent)
+}
+
+func keyOwner(key *client.KeyInfo) string {
+	if key.Owner == "" {
+		return ""
+	}
+	return " " + bold(key.Owner)
+}
+
+func listOrNone(values []string) string {
+	if len(values) == 0 {
+		return "none"
+	}
+	return strings.Join(values, ", ")
+}
+
+func showHashes(label string, hashes map[string]string) {
+	algorithms := make([]string, 0, len(hashes))
+	for algorithm := range hashes {
+		algorithms = append(algorithms, algorithm)
+	}
+	sort.Strings(algorithms)
+	for _, algorithm := range algorithms {
+		fmt.Printf("      %s%s: %s\n", label, algorithm, hashes[algorithm])
+	}
+}
+
 func formatSize(bytes int64) string {
 	const unit = 1024
 	if bytes < unit {
@@ -0,0 +1,116 @@
+package display
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const (
+	// progressBarWidth is the number of cells in the progress bar
+	progressBarWidth = 30
+	// progressInterval limits how often the progress bar is redrawn
+	progressInterval = 100 * time.Millisecond
+)
+
+// ProgressBar renders download progress on a single terminal line
+type ProgressBar struct {
+	mu         sync.Mutex
+	start      time.Time
+	startBytes int64 // Bytes already downloaded when the bar started (resumed downloads)
+	downloaded int64
+	total      int64
+	lastDraw   time.Time
+	drawn      bool
+}
+
+// ProgressEnabled reports whether stdout is a terminal that can show a progress bar
+func ProgressEnabled() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// NewProgressBar creates a progress bar; nothing is drawn until the first update
+func NewProgressBar() *ProgressBar {
+	return &ProgressBar{startBytes: -1}
+}
+
+// Update records the bytes downloaded so far and redraws the bar at most every progressInterval
+func (p *ProgressBar) Update(downloaded, total int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	if p.startBytes < 0 {
+		p.start, p.startBytes = now, downloaded
+	}
+	p.downloaded, p.total = downloaded, total
+
+	if downloaded < total && now.Sub(p.lastDraw) < progressInterval {
+		return
+	}
+	p.lastDraw = now
+	p.draw(now)
+}
+
+// Finish ends the progress line so following output starts on a new line
+func (p *ProgressBar) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.drawn {
+		fmt.Printf("\n")
+	}
+	p.drawn = false
+}
+
+// draw renders the bar, e.g. "   [=====>    ]  45%  12.3 MB / 27.0 MB  3.1 MB/s  ETA 5s"
+func (p *ProgressBar) draw(now time.Time) {
+	fraction := 1.0
+	if p.total > 0 {
+		fraction = float64(p.downloaded) / float64(p.total)
+	}
+
+	filled := int(fraction * progressBarWidth)
+	bar := strings.Repeat("=", filled)
+	if filled < progressBarWidth {
+		bar += ">" + strings.Repeat(" ", progressBarWidth-filled-1)
+	}
+
+	// Only bytes fetched in this run count towards the rate
+	rate := 0.0
+	if elapsed := now.Sub(p.start).Seconds(); elapsed > 0 {
+		rate = float64(p.downloaded-p.startBytes) / elapsed
+	}
+
+	eta := "--"
+	if p.downloaded >= p.total {
+		eta = "0s"
+	} else if rate > 0 {
+		eta = formatETA(time.Duration(float64(p.total-p.downloaded) / rate * float64(time.Second)))
+	}
+
+	// Pad with spaces to clear leftovers from a longer previous line
+	line := fmt.Sprintf("   [%s] %3.0f%%  %s / %s  %s/s  ETA %s",
+		cyan(bar),
+		fraction*100,
+		formatSize(p.downloaded),
+		formatSize(p.total),
+		formatSize(int64(rate)),
+		eta)
+	fmt.Printf("\r%-80s", line)
+	p.drawn = true
+}
+
+func formatETA(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	if d < time.Hour {
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kipz/tufzy/internal/client"
+)
+
+// HandlerOptions configures NewHandler
+type HandlerOptions struct {
+	// TUFOnCI translates a tuf-on-ci git checkout into the standard layout on the fly
+	TUFOnCI bool
+}
+
+// NewHandler serves the repository at dir over HTTP, with its metadata under
+// /metadata/ and its targets under /targets/, so any TUF client can read it.
+// Only regular files inside those directories are served; everything else,
+// including directory listings and symlinks leading out of them, returns 404.
+//
+// A standard layout is served as it is. With TUFOnCI, a tuf-on-ci checkout is
+// served as LayoutFromTUFOnCI would lay it out: versioned metadata names are
+// mapped with the tuf-on-ci fetcher's rules and hash-prefixed target names to
+// the unprefixed files. Requests for a version or hash the checkout does not
+// currently hold return 404.
+func NewHandler(dir string, options HandlerOptions) http.Handler {
+	return &repositoryHandler{dir: dir, tufOnCI: options.TUFOnCI}
+}
+
+// repositoryHandler serves a repository's metadata and targets directories
+type repositoryHandler struct {
+	dir     string
+	tufOnCI bool // Translate a tuf-on-ci checkout into the standard layout
+}
+
+// hashPrefixed matches target file names prefixed with a sha256 or sha512 hash
+var hashPrefixed = regexp.MustCompile(`^([0-9a-f]{64}|[0-9a-f]{128})\.(.+)$`)
+
+func (h *repositoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var content io.ReadSeeker
+	var err error
+	name := path.Clean("/" + r.URL.Path)
+	switch {
+	case strings.HasPrefix(name, "/metadata/"):
+		var data []byte
+		data, err = h.metadataFile(strings.TrimPrefix(name, "/metadata/"))
+		content = bytes.NewReader(data)
+	case strings.HasPrefix(name, "/targets/"):
+		var file *os.File
+		if file, err = h.targetFile(strings.TrimPrefix(name, "/targets/")); err == nil {
+			defer func() { _ = file.Close() }()
+			content = file
+		}
+	default:
+		err = fs.ErrNotExist
+	}
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		http.NotFound(w, r)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.ServeContent(w, r, name, time.Time{}, content)
+}
+
+// metadataFile reads the file for a requested metadata name, returning
+// fs.ErrNotExist if a tuf-on-ci checkout does not hold the requested version
+func (h *repositoryHandler) metadataFile(name string) ([]byte, error) {
+	metadataDir := filepath.Join(h.dir, "metadata")
+	if !h.tufOnCI {
+		file, err := openLocal(metadataDir, name)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = file.Close() }()
+		return io.ReadAll(file)
+	}
+
+	mapped, version := client.TufOnCiMetadataPath(name)
+	file, err := openLocal(metadataDir, mapped)
+	// tuf-on-ci keeps the initial root with the others in root_history
+	if errors.Is(err, fs.ErrNotExist) && name == "1.root.json" {
+		file, err = openLocal(metadataDir, path.Join("root_history", name))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	data, err := io.ReadAll(file)
+	if err != nil || version == 0 {
+		return data, err
+	}
+
+	var current struct {
+		Signed struct {
+			Version int64 `json:"version"`
+		} `json:"signed"`
+	}
+	if err := json.Unmarshal(data, &current); err != nil {
+		return nil, err
+	}
+	if current.Signed.Version != version {
+		return nil, fs.ErrNotExist
+	}
+	return data, nil
+}
+
+// targetFile opens the file for a requested target name, stripping and
+// checking a hash prefix in a tuf-on-ci checkout
+func (h *repositoryHandler) targetFile(name string) (*os.File, error) {
+	targetsDir := filepath.Join(h.dir, "targets")
+	file, err := openLocal(targetsDir, name)
+	dir, base := path.Split(name)
+	matches := hashPrefixed.FindStringSubmatch(base)
+	if !h.tufOnCI || !errors.Is(err, fs.ErrNotExist) || matches == nil {
+		return file, err
+	}
+
+	digest := matches[1]
+	if file, err = openLocal(targetsDir, dir+matches[2]); err != nil {
+		return nil, err
+	}
+	var hasher hash.Hash = sha256.New()
+	if len(digest) == 128 {
+		hasher = sha512.New()
+	}
+	if _, err = io.Copy(hasher, file); err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err == nil && hex.EncodeToString(hasher.Sum(nil)) != digest {
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// openLocal opens the regular file at the slash-separated name under dir,
+// refusing names and symlinks that would leave it
+func openLocal(dir, name string) (*os.File, error) {
+	local := filepath.FromSlash(name)
+	if !filepath.IsLocal(local) {
+		return nil, fs.ErrNotExist
+	}
+	file, err := os.OpenInRoot(dir, local)
+	if err != nil {
+		// Missing files and symlinks leading out of dir are both not found
+		if !errors.Is(err, fs.ErrPermission) {
+			err = fs.ErrNotExist
+		}
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err == nil && !info.Mode().IsRegular() {
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return file, nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// get requests name from server and returns the status and body
+func get(t *testing.T, server *httptest.Server, name string) (int, []byte) {
+	t.Helper()
+
+	resp, err := http.Get(server.URL + "/" + name)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, body
+}
+
+func TestNewHandler_Standard(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"metadata/1.root.json":     "root",
+		"metadata/sub/team.json":   "team",
+		"targets/dir/abc.file.txt": "target",
+		".git/config":              "secret",
+		"notes.txt":                "notes",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	require.NoError(t, os.Symlink(filepath.Join(dir, ".git", "config"), filepath.Join(dir, "metadata", "escape.json")))
+
+	server := httptest.NewServer(NewHandler(dir, HandlerOptions{}))
+	defer server.Close()
+
+	// Metadata and targets are served as they are
+	for name, want := range map[string]string{
+		"metadata/1.root.json":     "root",
+		"metadata/sub/team.json":   "team",
+		"targets/dir/abc.file.txt": "target",
+	} {
+		status, body := get(t, server, name)
+		assert.Equal(t, http.StatusOK, status, name)
+		assert.Equal(t, want, string(body), name)
+	}
+
+	// Nothing else is, including listings and symlinks leading out of the tree
+	for _, name := range []string{
+		"",
+		".git/config",
+		"notes.txt",
+		"metadata/",
+		"metadata/sub",
+		"metadata/escape.json",
+		"metadata/../.git/config",
+		"targets/missing.txt",
+	} {
+		status, _ := get(t, server, name)
+		assert.Equal(t, http.StatusNotFound, status, name)
+	}
+}
+
+func TestNewHandler_TUFOnCI(t *testing.T) {
+	// Every file of the converted layout is served from the checkout
+	for _, name := range []string{"simple", "delegated", "no-files"} {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(NewHandler(filepath.Join("testdata", name, "tuf-on-ci"), HandlerOptions{TUFOnCI: true}))
+			defer server.Close()
+
+			outputDir := filepath.Join("testdata", name, "output")
+			err := filepath.WalkDir(outputDir, func(file string, entry fs.DirEntry, err error) error {
+				if err != nil || entry.IsDir() {
+					return err
+				}
+				rel, err := filepath.Rel(outputDir, file)
+				require.NoError(t, err)
+				want, err := os.ReadFile(file)
+				require.NoError(t, err)
+
+				status, body := get(t, server, filepath.ToSlash(rel))
+				assert.Equal(t, http.StatusOK, status, rel)
+				assert.Equal(t, string(want), string(body), rel)
+				return nil
+			})
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("not held", func(t *testing.T) {
+		server := httptest.NewServer(NewHandler(filepath.Join("testdata", "delegated", "tuf-on-ci"), HandlerOptions{TUFOnCI: true}))
+		defer server.Close()
+
+		for _, name := range []string{
+			"metadata/3.snapshot.json",
+			"metadata/1.delegated.json",
+			"metadata/9.root.json",
+			"targets/delegated/0000000000000000000000000000000000000000000000000000000000000000.file1.txt",
+			"targets/missing.txt",
+			"metadata/../../server_test.go",
+			"other",
+		} {
+			status, _ := get(t, server, name)
+			assert.Equal(t, http.StatusNotFound, status, name)
+		}
+	})
+}
@@ -0,0 +1,192 @@
+// Package testrepo builds signed TUF repositories on disk for tests.
+package testrepo
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/stretchr/testify/require"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+)
+
+// T is the part of testing.TB a Repo uses, so a repository can also be built
+// outside a test, e.g. in TestMain
+type T interface {
+	require.TestingT
+	Helper()
+}
+
+// Repo is a signed TUF repository written to a directory.
+//
+// It uses consistent snapshots and one ed25519 key per role, so it can be
+// loaded by the go-tuf updater through any of the fetchers. Roles can be
+// changed through the exported metadata and written again with Publish.
+type Repo struct {
+	t         T
+	Dir       string
+	Signers   map[string]signature.Signer
+	Root      *metadata.Metadata[metadata.RootType]
+	Targets   map[string]*metadata.Metadata[metadata.TargetsType]
+	Snapshot  *metadata.Metadata[metadata.SnapshotType]
+	Timestamp *metadata.Metadata[metadata.TimestampType]
+	Files     map[string][]byte // Target path -> content
+}
+
+// New creates a repository in dir with top-level roles that expire in a year.
+// Nothing is written until Publish.
+func New(t T, dir string) *Repo {
+	t.Helper()
+
+	expires := time.Now().UTC().AddDate(1, 0, 0).Truncate(time.Second)
+	r := &Repo{
+		t:         t,
+		Dir:       dir,
+		Signers:   map[string]signature.Signer{},
+		Root:      metadata.Root(expires),
+		Targets:   map[string]*metadata.Metadata[metadata.TargetsType]{metadata.TARGETS: metadata.Targets(expires)},
+		Snapshot:  metadata.Snapshot(expires),
+		Timestamp: metadata.Timestamp(expires),
+		Files:     map[string][]byte{},
+	}
+
+	for _, role := range []string{metadata.ROOT, metadata.TARGETS, metadata.SNAPSHOT, metadata.TIMESTAMP} {
+		key := r.NewKey(role)
+		require.NoError(t, r.Root.Signed.AddKey(key, role))
+	}
+	return r
+}
+
+// NewKey generates a key pair and keeps its signer for the role
+func (r *Repo) NewKey(role string) *metadata.Key {
+	r.t.Helper()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(r.t, err)
+	signer, err := signature.LoadSigner(private, crypto.Hash(0))
+	require.NoError(r.t, err)
+	key, err := metadata.KeyFromPublicKey(public)
+	require.NoError(r.t, err)
+
+	r.Signers[role] = signer
+	return key
+}
+
+// AddTarget adds a target file to the given targets role
+func (r *Repo) AddTarget(role, name string, content []byte) {
+	r.t.Helper()
+
+	targets, ok := r.Targets[role]
+	require.True(r.t, ok, "unknown targets role %s", role)
+	file, err := metadata.TargetFile().FromBytes(name, content, "sha256")
+	require.NoError(r.t, err)
+	targets.Signed.Targets[name] = file
+	r.Files[name] = content
+}
+
+// AddDelegation delegates the given path patterns from targets to a new role
+func (r *Repo) AddDelegation(role string, paths []string) {
+	r.t.Helper()
+	r.AddDelegationFrom(metadata.TARGETS, role, paths)
+}
+
+// AddDelegationFrom delegates the given path patterns from a targets role to a new role
+func (r *Repo) AddDelegationFrom(parentRole, role string, paths []string) {
+	r.t.Helper()
+
+	parent, ok := r.Targets[parentRole]
+	require.True(r.t, ok, "unknown targets role %s", parentRole)
+	if parent.Signed.Delegations == nil {
+		parent.Signed.Delegations = &metadata.Delegations{
+			Keys:  map[string]*metadata.Key{},
+			Roles: []metadata.DelegatedRole{},
+		}
+	}
+	parent.Signed.Delegations.Roles = append(parent.Signed.Delegations.Roles, metadata.DelegatedRole{
+		Name:      role,
+		KeyIDs:    []string{},
+		Threshold: 1,
+		Paths:     paths,
+	})
+	require.NoError(r.t, parent.Signed.AddKey(r.NewKey(role), role))
+
+	r.Targets[role] = metadata.Targets(parent.Signed.Expires)
+}
+
+// Publish signs all metadata and writes the repository to
+// <Dir>/metadata and <Dir>/targets
+func (r *Repo) Publish() {
+	r.t.Helper()
+
+	metadataDir := filepath.Join(r.Dir, "metadata")
+	targetsDir := filepath.Join(r.Dir, "targets")
+	require.NoError(r.t, os.MkdirAll(metadataDir, 0755))
+
+	// Targets are stored under their hash prefix for consistent snapshots
+	for name, content := range r.Files {
+		hash := r.TargetHash(name)
+		path := filepath.Join(targetsDir, filepath.Dir(name), hash+"."+filepath.Base(name))
+		require.NoError(r.t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(r.t, os.WriteFile(path, content, 0644))
+	}
+
+	for role, targets := range r.Targets {
+		r.Sign(targets, role)
+		name := fmt.Sprintf("%d.%s.json", targets.Signed.Version, role)
+		require.NoError(r.t, targets.ToFile(filepath.Join(metadataDir, name), true))
+		r.Snapshot.Signed.Meta[role+".json"] = metadata.MetaFile(targets.Signed.Version)
+	}
+
+	r.Sign(r.Snapshot, metadata.SNAPSHOT)
+	require.NoError(r.t, r.Snapshot.ToFile(filepath.Join(metadataDir, fmt.Sprintf("%d.snapshot.json", r.Snapshot.Signed.Version)), true))
+
+	r.Timestamp.Signed.Meta["snapshot.json"] = metadata.MetaFile(r.Snapshot.Signed.Version)
+	r.Sign(r.Timestamp, metadata.TIMESTAMP)
+	require.NoError(r.t, r.Timestamp.ToFile(filepath.Join(metadataDir, "timestamp.json"), true))
+
+	r.Sign(r.Root, metadata.ROOT)
+	require.NoError(r.t, r.Root.ToFile(filepath.Join(metadataDir, fmt.Sprintf("%d.root.json", r.Root.Signed.Version)), true))
+}
+
+// MetadataFile reads a published metadata file
+func (r *Repo) MetadataFile(name string) []byte {
+	r.t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(r.Dir, "metadata", name))
+	require.NoError(r.t, err)
+	return data
+}
+
+// TargetHash returns the hex sha256 of a target added to any role
+func (r *Repo) TargetHash(name string) string {
+	r.t.Helper()
+
+	for _, targets := range r.Targets {
+		if file, ok := targets.Signed.Targets[name]; ok {
+			return file.Hashes["sha256"].String()
+		}
+	}
+	r.t.Errorf("unknown target %s", name)
+	r.t.FailNow()
+	return ""
+}
+
+// Signable is implemented by every metadata type
+type Signable interface {
+	ClearSignatures()
+	Sign(signer signature.Signer) (*metadata.Signature, error)
+}
+
+// Sign replaces the signatures on md with one from the role's key
+func (r *Repo) Sign(md Signable, role string) {
+	r.t.Helper()
+
+	md.ClearSignatures()
+	_, err := md.Sign(r.Signers[role])
+	require.NoError(r.t, err, "failed to sign %s", role)
+}
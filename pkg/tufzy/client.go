@@ -0,0 +1,281 @@
+package tufzy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/kipz/tufzy/internal/client"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrTargetNotFound is returned when no trusted role lists the requested target
+var ErrTargetNotFound = client.ErrTargetNotFound
+
+// SkipDelegations can be returned by a WalkDelegations callback to skip the
+// roles delegated by the current role
+var SkipDelegations = errors.New("skip delegations")
+
+// Options contains optional configuration for a Client
+type Options struct {
+	// TargetsURL is the targets base URL (default: ../targets next to the
+	// metadata; required for OCI registries and layouts)
+	TargetsURL string
+	// CacheDir holds the trusted metadata and partial downloads
+	// (default: ~/.tufzy/cache/<hash of the metadata URL>)
+	CacheDir string
+	// TufOnCiGit reads a tuf-on-ci git checkout, whose metadata is unversioned.
+	// Local checkouts are detected automatically.
+	TufOnCiGit bool
+	// Progress is called as target downloads make progress
+	Progress func(downloaded, total int64)
+	// Limiter bounds concurrent requests and bandwidth, and may be shared between clients
+	Limiter *Limiter
+	// Logger receives records of layout detection and verified roles at info
+	// level, and of every request and cache lookup at debug level
+	Logger *slog.Logger
+	// TracerProvider records spans for updates, metadata fetches, target
+	// downloads and HTTP requests (default: the global provider)
+	TracerProvider trace.TracerProvider
+	// MeterProvider records downloaded bytes, cache hits and misses, and
+	// verification failures (default: the global provider)
+	MeterProvider metric.MeterProvider
+}
+
+// Limiter bounds the number of concurrent requests and the download bandwidth
+// of every Client it is shared with. Custom fetchers receive it in
+// FetcherOptions and apply it with its Transport method.
+type Limiter = client.Limiter
+
+// NewLimiter creates a limiter allowing maxConcurrency requests in flight and
+// bytesPerSecond of downloads. Zero or negative values mean unlimited.
+func NewLimiter(maxConcurrency int, bytesPerSecond int64) *Limiter {
+	return client.NewLimiter(maxConcurrency, bytesPerSecond)
+}
+
+// Target is a file listed in the repository's trusted targets metadata
+type Target struct {
+	// Name is the target path within the repository
+	Name string `json:"name"`
+	// Length is the size of the target in bytes
+	Length int64 `json:"length"`
+	// Hashes maps each hash algorithm to the hex-encoded digest
+	Hashes map[string]string `json:"hashes"`
+	// Custom is the target's custom metadata, if any
+	Custom json.RawMessage `json:"custom,omitempty"`
+	// Path is the local file a target was downloaded to or verified at
+	Path string `json:"path,omitempty"`
+}
+
+// Delegation is a role trusted to sign some of the repository's targets
+type Delegation struct {
+	Name             string       `json:"name"`
+	Threshold        int          `json:"threshold"`
+	KeyIDs           []string     `json:"keyids"`
+	Paths            []string     `json:"paths,omitempty"`
+	PathHashPrefixes []string     `json:"pathHashPrefixes,omitempty"`
+	Terminating      bool         `json:"terminating"`
+	Children         []Delegation `json:"children,omitempty"`
+}
+
+// RoleInfo is the version and expiry of a trusted top-level role
+type RoleInfo struct {
+	Version int64     `json:"version"`
+	Expires time.Time `json:"expires"`
+}
+
+// RepositoryInfo describes the repository and its trusted top-level metadata
+type RepositoryInfo struct {
+	MetadataURL        string   `json:"metadataURL"`
+	TargetsURL         string   `json:"targetsURL"`
+	ConsistentSnapshot bool     `json:"consistentSnapshot"`
+	Root               RoleInfo `json:"root"`
+	Targets            RoleInfo `json:"targets"`
+	Snapshot           RoleInfo `json:"snapshot"`
+	Timestamp          RoleInfo `json:"timestamp"`
+	// ResolvedDigests maps each OCI tag used by this client to the manifest digest it resolved to
+	ResolvedDigests map[string]string `json:"resolvedDigests,omitempty"`
+}
+
+// Client reads and verifies one TUF repository. It is safe for concurrent use:
+// metadata lookups and updates are serialized, target downloads run in
+// parallel, and downloads of the same target wait for each other.
+type Client struct {
+	client *client.Client
+}
+
+// NewClient creates a client for the repository whose metadata is at
+// metadataURL, which may be an http(s)://, file://, s3://, oci:// or
+// oci-layout:// URL or a local path
+func NewClient(ctx context.Context, metadataURL string) (*Client, error) {
+	return NewClientWithOptions(ctx, metadataURL, Options{})
+}
+
+// NewClientWithOptions creates a client with custom options
+func NewClientWithOptions(ctx context.Context, metadataURL string, options Options) (*Client, error) {
+	clientOptions := client.ClientOptions{
+		TargetsURL:     options.TargetsURL,
+		CacheDir:       options.CacheDir,
+		TufOnCiGit:     options.TufOnCiGit,
+		Progress:       options.Progress,
+		Limiter:        options.Limiter,
+		Logger:         options.Logger,
+		TracerProvider: options.TracerProvider,
+		MeterProvider:  options.MeterProvider,
+	}
+
+	c, err := client.NewClientWithOptionsContext(ctx, metadataURL, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{client: c}, nil
+}
+
+// Update refreshes and verifies the repository's top-level metadata. It must
+// be called before listing, downloading or verifying targets.
+func (c *Client) Update(ctx context.Context) error {
+	return c.client.UpdateContext(ctx)
+}
+
+// Targets returns the targets listed by the top-level targets role, sorted by name
+func (c *Client) Targets(ctx context.Context) ([]Target, error) {
+	infos, err := c.client.GetTargetsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]Target, 0, len(infos))
+	for i := range infos {
+		targets = append(targets, newTarget(&infos[i], ""))
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Name < targets[j].Name })
+	return targets, nil
+}
+
+// Delegations returns the delegation tree below the top-level targets role,
+// loading and verifying the metadata of every delegated role
+func (c *Client) Delegations(ctx context.Context) ([]Delegation, error) {
+	delegations, err := c.client.GetDelegationTreeContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newDelegations(delegations), nil
+}
+
+// WalkDelegations calls fn for every delegated role in pre-order, the order in
+// which TUF searches roles for a target, with the name of the delegating role.
+// If fn returns SkipDelegations the role's own delegations are skipped; any
+// other error stops the walk and is returned.
+func (c *Client) WalkDelegations(ctx context.Context, fn func(parent string, delegation Delegation) error) error {
+	delegations, err := c.Delegations(ctx)
+	if err != nil {
+		return err
+	}
+	return walkDelegations("targets", delegations, fn)
+}
+
+func walkDelegations(parent string, delegations []Delegation, fn func(string, Delegation) error) error {
+	for _, delegation := range delegations {
+		err := fn(parent, delegation)
+		if errors.Is(err, SkipDelegations) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := walkDelegations(delegation.Name, delegation.Children, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Target returns the trusted metadata of a target, searching delegated roles
+// as needed. It returns an error matching ErrTargetNotFound if no role lists it.
+func (c *Client) Target(ctx context.Context, name string) (*Target, error) {
+	info, err := c.client.GetTargetInfoContext(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	target := newTarget(info, "")
+	return &target, nil
+}
+
+// Download downloads a target to destPath and verifies its length and hashes.
+// Nothing is written to destPath unless verification succeeds, and a cancelled
+// download resumes where it stopped on the next call for the same target.
+func (c *Client) Download(ctx context.Context, name, destPath string) (*Target, error) {
+	info, err := c.client.DownloadTargetContext(ctx, name, destPath)
+	if err != nil {
+		return nil, err
+	}
+	target := newTarget(info, info.Name)
+	target.Name = name
+	return &target, nil
+}
+
+// Verify checks that the local file at path matches a target's trusted length
+// and hashes
+func (c *Client) Verify(ctx context.Context, name, path string) (*Target, error) {
+	info, err := c.client.VerifyTargetContext(ctx, name, path)
+	if err != nil {
+		return nil, err
+	}
+	target := newTarget(info, path)
+	return &target, nil
+}
+
+// Info returns the versions and expiry of the trusted top-level metadata
+func (c *Client) Info() (*RepositoryInfo, error) {
+	info, err := c.client.GetRepositoryInfo()
+	if err != nil {
+		return nil, err
+	}
+	return &RepositoryInfo{
+		MetadataURL:        info.MetadataURL,
+		TargetsURL:         info.TargetsURL,
+		ConsistentSnapshot: info.ConsistentSnapshot,
+		Root:               RoleInfo{Version: info.RootVersion, Expires: info.RootExpires},
+		Targets:            RoleInfo{Version: info.TargetsVersion, Expires: info.TargetsExpires},
+		Snapshot:           RoleInfo{Version: info.SnapshotVersion, Expires: info.SnapshotExpires},
+		Timestamp:          RoleInfo{Version: info.TimestampVersion, Expires: info.TimestampExpires},
+		ResolvedDigests:    info.ResolvedDigests,
+	}, nil
+}
+
+func newTarget(info *client.TargetInfo, path string) Target {
+	target := Target{
+		Name:   info.Name,
+		Length: info.Length,
+		Hashes: info.Hashes,
+		Path:   path,
+	}
+	if info.Custom != nil {
+		target.Custom = append(json.RawMessage(nil), *info.Custom...)
+	}
+	return target
+}
+
+func newDelegations(delegations []client.Delegation) []Delegation {
+	if len(delegations) == 0 {
+		return nil
+	}
+
+	converted := make([]Delegation, 0, len(delegations))
+	for _, d := range delegations {
+		converted = append(converted, Delegation{
+			Name:             d.Name,
+			Threshold:        d.Threshold,
+			KeyIDs:           d.KeyIDs,
+			Paths:            d.Paths,
+			PathHashPrefixes: d.PathHashPrefixes,
+			Terminating:      d.Terminating,
+			Children:         newDelegations(d.Children),
+		})
+	}
+	return converted
+}
@@ -0,0 +1,190 @@
+package tufzy_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kipz/tufzy/pkg/tufzy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClient returns an updated client for the shared repository with its own cache
+func newTestClient(t *testing.T) *tufzy.Client {
+	t.Helper()
+
+	c, err := tufzy.NewClientWithOptions(context.Background(), filepath.Join(repoDir, "metadata"), tufzy.Options{CacheDir: t.TempDir()})
+	require.NoError(t, err)
+	require.NoError(t, c.Update(context.Background()))
+	return c
+}
+
+func TestClient_Targets(t *testing.T) {
+	c := newTestClient(t)
+
+	targets, err := c.Targets(context.Background())
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+	assert.Equal(t, "app.txt", targets[0].Name)
+	assert.Equal(t, int64(len("app content\n")), targets[0].Length)
+	assert.Len(t, targets[0].Hashes["sha256"], 64)
+	assert.Equal(t, "docs/readme.txt", targets[1].Name)
+
+	// Delegated targets are found by name but not listed
+	target, err := c.Target(context.Background(), "team/nightly-1.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "team/nightly-1.txt", target.Name)
+	assert.Empty(t, target.Path)
+
+	_, err = c.Target(context.Background(), "team/missing.txt")
+	require.ErrorIs(t, err, tufzy.ErrTargetNotFound)
+}
+
+func TestClient_WalkDelegations(t *testing.T) {
+	c := newTestClient(t)
+
+	delegations, err := c.Delegations(context.Background())
+	require.NoError(t, err)
+	require.Len(t, delegations, 1)
+	assert.Equal(t, "team", delegations[0].Name)
+	assert.Equal(t, 1, delegations[0].Threshold)
+	assert.Len(t, delegations[0].KeyIDs, 1)
+	require.Len(t, delegations[0].Children, 1)
+	assert.Equal(t, []string{"team/nightly-*"}, delegations[0].Children[0].Paths)
+
+	testCases := []struct {
+		name     string
+		result   error
+		expected []string
+		err      error
+	}{{
+		name:     "all",
+		expected: []string{"targets>team", "team>nightly"},
+	}, {
+		name:     "skip",
+		result:   tufzy.SkipDelegations,
+		expected: []string{"targets>team"},
+	}, {
+		name:     "stop",
+		result:   os.ErrPermission,
+		expected: []string{"targets>team"},
+		err:      os.ErrPermission,
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var visited []string
+			err := c.WalkDelegations(context.Background(), func(parent string, d tufzy.Delegation) error {
+				visited = append(visited, parent+">"+d.Name)
+				return tc.result
+			})
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.expected, visited)
+		})
+	}
+}
+
+func TestClient_DownloadAndVerify(t *testing.T) {
+	c := newTestClient(t)
+	dir := t.TempDir()
+
+	destPath := filepath.Join(dir, "nightly.txt")
+	target, err := c.Download(context.Background(), "team/nightly-1.txt", destPath)
+	require.NoError(t, err)
+	assert.Equal(t, "team/nightly-1.txt", target.Name)
+	assert.Equal(t, destPath, target.Path)
+	data, err := os.ReadFile(destPath)
+	require.NoError(t, err)
+	assert.Equal(t, "nightly build 1\n", string(data))
+
+	verified, err := c.Verify(context.Background(), "team/nightly-1.txt", destPath)
+	require.NoError(t, err)
+	assert.Equal(t, target.Hashes, verified.Hashes)
+
+	// A modified file fails verification
+	require.NoError(t, os.WriteFile(destPath, []byte("nightly build 2\n"), 0644))
+	_, err = c.Verify(context.Background(), "team/nightly-1.txt", destPath)
+	require.Error(t, err)
+
+	_, err = c.Download(context.Background(), "missing.txt", filepath.Join(dir, "missing.txt"))
+	require.ErrorIs(t, err, tufzy.ErrTargetNotFound)
+	assert.NoFileExists(t, filepath.Join(dir, "missing.txt"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.Download(ctx, "app.txt", filepath.Join(dir, "app.txt"))
+	require.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestClient_ConcurrentUse(t *testing.T) {
+	c := newTestClient(t)
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Download(ctx, "team/nightly-1.txt", filepath.Join(dir, fmt.Sprintf("%d.txt", i)))
+			assert.NoError(t, err)
+			_, err = c.Info()
+			assert.NoError(t, err)
+			_, err = c.Target(ctx, "app.txt")
+			assert.NoError(t, err)
+			_, err = c.Delegations(ctx)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	for i := range 8 {
+		data, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("%d.txt", i)))
+		require.NoError(t, err)
+		assert.Equal(t, "nightly build 1\n", string(data))
+	}
+}
+
+func TestClient_Info(t *testing.T) {
+	c := newTestClient(t)
+
+	info, err := c.Info()
+	require.NoError(t, err)
+	assert.True(t, info.ConsistentSnapshot)
+	assert.Equal(t, int64(1), info.Root.Version)
+	assert.Equal(t, int64(1), info.Timestamp.Version)
+	assert.False(t, info.Targets.Expires.IsZero())
+}
+
+func TestConvertTUFOnCILayout(t *testing.T) {
+	dst := t.TempDir()
+	require.NoError(t, tufzy.ConvertTUFOnCILayout(filepath.Join("..", "..", "internal", "repository", "testdata", "delegated", "tuf-on-ci"), dst))
+	assert.FileExists(t, filepath.Join(dst, "metadata", "2.delegated.json"))
+
+	require.Error(t, tufzy.ConvertTUFOnCILayout(filepath.Join(t.TempDir(), "missing"), t.TempDir()))
+}
+
+func TestClient_Watch(t *testing.T) {
+	c := newTestClient(t)
+
+	// The shared repository never changes, so watching reports nothing
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	var events []tufzy.Event
+	err := c.Watch(ctx, tufzy.WatchOptions{Interval: 20 * time.Millisecond}, func(event tufzy.Event) error {
+		events = append(events, event)
+		return nil
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, events)
+}
@@ -0,0 +1,35 @@
+// Package tufzy is the supported Go API for tufzy: a client for The Update
+// Framework (TUF) repositories served over HTTP(S), from local directories,
+// S3 buckets, OCI registries or OCI image layouts.
+//
+// A Client verifies repository metadata with go-tuf before anything is listed
+// or downloaded:
+//
+//	c, err := tufzy.NewClient(ctx, "https://example.github.io/repo/metadata")
+//	if err != nil {
+//		return err
+//	}
+//	if err := c.Update(ctx); err != nil {
+//		return err
+//	}
+//	target, err := c.Download(ctx, "app/config.json", "config.json")
+//
+// The first Client for a repository trusts the root metadata it downloads
+// (trust on first use) and caches it, so later clients only accept roots
+// signed by that chain.
+//
+// # Stability
+//
+// This package follows semantic versioning. Within a major version:
+//
+//   - Exported identifiers are not removed or renamed, and function
+//     signatures do not change.
+//   - Fields may be added to structs, so construct them with field names.
+//   - Behaviour covered by the documentation, such as error identities
+//     checked with [errors.Is], does not change.
+//   - Identifiers are deprecated for at least one minor release before a
+//     new major version removes them.
+//
+// Everything under github.com/kipz/tufzy/internal may change at any time
+// and cannot be imported by other modules.
+package tufzy
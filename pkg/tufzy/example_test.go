@@ -0,0 +1,197 @@
+package tufzy_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kipz/tufzy/pkg/tufzy"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+	"github.com/theupdateframework/go-tuf/v2/metadata/fetcher"
+)
+
+// exampleClient returns an updated client for the shared repository and a
+// cleanup function removing its cache
+func exampleClient() (*tufzy.Client, func()) {
+	cacheDir, err := os.MkdirTemp("", "tufzy-example-")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+	c, err := tufzy.NewClientWithOptions(ctx, filepath.Join(repoDir, "metadata"), tufzy.Options{CacheDir: cacheDir})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := c.Update(ctx); err != nil {
+		log.Fatal(err)
+	}
+	return c, func() { _ = os.RemoveAll(cacheDir) }
+}
+
+func ExampleClient_Targets() {
+	c, cleanup := exampleClient()
+	defer cleanup()
+
+	targets, err := c.Targets(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, target := range targets {
+		fmt.Println(target.Name, target.Length)
+	}
+	// Output:
+	// app.txt 12
+	// docs/readme.txt 8
+}
+
+func ExampleClient_WalkDelegations() {
+	c, cleanup := exampleClient()
+	defer cleanup()
+
+	err := c.WalkDelegations(context.Background(), func(parent string, d tufzy.Delegation) error {
+		fmt.Printf("%s -> %s %v\n", parent, d.Name, d.Paths)
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	// targets -> team [team/*]
+	// team -> nightly [team/nightly-*]
+}
+
+func ExampleClient_Download() {
+	c, cleanup := exampleClient()
+	defer cleanup()
+
+	dir, err := os.MkdirTemp("", "tufzy-download-")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	// Delegated targets are downloaded and verified like any other
+	target, err := c.Download(context.Background(), "team/nightly-1.txt", filepath.Join(dir, "nightly-1.txt"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	data, err := os.ReadFile(target.Path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(string(data))
+
+	_, err = c.Download(context.Background(), "team/nightly-2.txt", filepath.Join(dir, "nightly-2.txt"))
+	fmt.Println(errors.Is(err, tufzy.ErrTargetNotFound))
+	// Output:
+	// nightly build 1
+	// true
+}
+
+func ExampleClient_Verify() {
+	c, cleanup := exampleClient()
+	defer cleanup()
+
+	file, err := os.CreateTemp("", "app-*.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = os.Remove(file.Name()) }()
+	_, _ = file.WriteString("app content\n")
+	_ = file.Close()
+
+	if _, err := c.Verify(context.Background(), "app.txt", file.Name()); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("verified")
+	// Output:
+	// verified
+}
+
+func ExampleConvertTUFOnCILayout() {
+	dst, err := os.MkdirTemp("", "tufzy-layout-")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dst) }()
+
+	src := filepath.Join("..", "..", "internal", "repository", "testdata", "simple", "tuf-on-ci")
+	if err := tufzy.ConvertTUFOnCILayout(src, dst); err != nil {
+		log.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dst, "metadata"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, entry := range entries {
+		fmt.Println(entry.Name())
+	}
+}
+
+// mirrorFetcher reads mirror:// URLs from the local filesystem, standing in
+// for a transport tufzy does not provide
+type mirrorFetcher struct{}
+
+func (mirrorFetcher) DownloadFile(urlPath string, maxLength int64, _ time.Duration) ([]byte, error) {
+	data, err := os.ReadFile(strings.TrimPrefix(urlPath, "mirror://"))
+	if errors.Is(err, fs.ErrNotExist) {
+		// The updater stops looking for newer roots at a 404
+		return nil, &metadata.ErrDownloadHTTP{StatusCode: http.StatusNotFound, URL: urlPath}
+	}
+	if err == nil && int64(len(data)) > maxLength {
+		return nil, &metadata.ErrDownloadLengthMismatch{Msg: fmt.Sprintf("%s exceeds %d bytes", urlPath, maxLength)}
+	}
+	return data, err
+}
+
+func ExampleRegisterFetcher() {
+	tufzy.RegisterFetcher("mirror", tufzy.FetcherFactory{
+		DetectLayout: func(metadataURL, targetsURL string) (tufzy.Layout, error) {
+			if targetsURL == "" {
+				targetsURL = strings.TrimSuffix(metadataURL, "/metadata") + "/targets"
+			}
+			return tufzy.Layout{MetadataURL: metadataURL, TargetsURL: targetsURL}, nil
+		},
+		NewFetcher: func(ctx context.Context, layout tufzy.Layout, options tufzy.FetcherOptions) (fetcher.Fetcher, error) {
+			return mirrorFetcher{}, nil
+		},
+	})
+	fmt.Println(tufzy.RegisteredSchemes())
+
+	cacheDir, err := os.MkdirTemp("", "tufzy-example-")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(cacheDir) }()
+
+	// The repository is verified as usual, whatever transport reads it
+	ctx := context.Background()
+	c, err := tufzy.NewClientWithOptions(ctx, "mirror://"+repoDir+"/metadata", tufzy.Options{CacheDir: cacheDir})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := c.Update(ctx); err != nil {
+		log.Fatal(err)
+	}
+	target, err := c.Download(ctx, "app.txt", filepath.Join(cacheDir, "app.txt"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	data, err := os.ReadFile(target.Path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(string(data))
+	// Output:
+	// [file http https mirror oci oci-layout s3]
+	// app content
+}
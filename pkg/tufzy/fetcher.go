@@ -0,0 +1,43 @@
+package tufzy
+
+import "github.com/kipz/tufzy/internal/client"
+
+// Layout describes where a repository keeps its metadata and targets
+type Layout = client.Layout
+
+// LayoutDetector normalizes a metadata URL and detects the repository layout.
+// targetsURL is the targets URL given by the caller, or empty to use the default.
+type LayoutDetector = client.LayoutDetector
+
+// FetcherConstructor creates the go-tuf fetcher for a repository layout
+type FetcherConstructor = client.FetcherConstructor
+
+// FetcherOptions contains the client settings passed to fetcher constructors.
+// Fetchers should apply the limiter and report to the logger and providers so
+// they behave like the built-in ones.
+type FetcherOptions = client.FetcherOptions
+
+// FetcherFactory handles repositories for one URL scheme
+type FetcherFactory = client.FetcherFactory
+
+// TargetStreamer is implemented by fetchers that can stream targets to disk.
+// Their target downloads resume after interruptions instead of being held in
+// memory.
+type TargetStreamer = client.TargetStreamer
+
+// ContextFetcher is implemented by fetchers whose metadata downloads can be
+// cancelled. Other fetchers are only checked for cancellation between downloads.
+type ContextFetcher = client.ContextFetcher
+
+// RegisterFetcher registers the factory for URLs with the given scheme
+// (without "://"), replacing any existing registration, so a Client can read
+// repositories over transports tufzy does not provide. Local paths without a
+// scheme use "file". It panics if the factory is incomplete.
+func RegisterFetcher(scheme string, factory FetcherFactory) {
+	client.RegisterFetcher(scheme, factory)
+}
+
+// RegisteredSchemes returns the schemes with a registered fetcher, sorted
+func RegisteredSchemes() []string {
+	return client.RegisteredSchemes()
+}
@@ -0,0 +1,10 @@
+package tufzy
+
+import "github.com/kipz/tufzy/internal/repository"
+
+// ConvertTUFOnCILayout copies the metadata and targets of a tuf-on-ci checkout
+// at src into a standard TUF repository layout at dst, with consistent
+// snapshot file names, that any TUF client can serve or read
+func ConvertTUFOnCILayout(src, dst string) error {
+	return repository.LayoutFromTUFOnCI(src, dst)
+}
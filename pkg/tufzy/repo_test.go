@@ -0,0 +1,55 @@
+package tufzy_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/kipz/tufzy/internal/testrepo"
+	"github.com/theupdateframework/go-tuf/v2/metadata"
+)
+
+// repoDir is a signed repository shared by the tests and examples, laid out as
+// <repoDir>/metadata and <repoDir>/targets:
+//
+//	targets       app.txt, docs/readme.txt
+//	└── team      team/*
+//	    └── nightly  team/nightly-*  team/nightly-1.txt
+var repoDir string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "tufzy-sdk-")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	repoDir = dir
+
+	repo := testrepo.New(mainT{dir: dir}, dir)
+	repo.AddTarget(metadata.TARGETS, "app.txt", []byte("app content\n"))
+	repo.AddTarget(metadata.TARGETS, "docs/readme.txt", []byte("read me\n"))
+	repo.AddDelegation("team", []string{"team/*"})
+	repo.AddDelegationFrom("team", "nightly", []string{"team/nightly-*"})
+	repo.AddTarget("nightly", "team/nightly-1.txt", []byte("nightly build 1\n"))
+	repo.Publish()
+
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// mainT stops TestMain when the shared repository cannot be written
+type mainT struct {
+	dir string
+}
+
+func (t mainT) Errorf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "failed to write test repository: "+format+"\n", args...)
+}
+
+func (t mainT) FailNow() {
+	_ = os.RemoveAll(t.dir)
+	os.Exit(1)
+}
+
+func (t mainT) Helper() {}
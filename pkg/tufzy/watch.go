@@ -0,0 +1,77 @@
+package tufzy
+
+import (
+	"context"
+	"time"
+
+	"github.com/kipz/tufzy/internal/client"
+)
+
+// EventType is the kind of change an Event reports
+type EventType string
+
+const (
+	RoleAdded      EventType = EventType(client.WatchRoleAdded)
+	RoleRemoved    EventType = EventType(client.WatchRoleRemoved)
+	RoleUpdated    EventType = EventType(client.WatchRoleUpdated)
+	TargetAdded    EventType = EventType(client.WatchTargetAdded)
+	TargetRemoved  EventType = EventType(client.WatchTargetRemoved)
+	TargetModified EventType = EventType(client.WatchTargetModified)
+	// RefreshFailed is reported when a refresh fails; watching continues
+	RefreshFailed EventType = EventType(client.WatchRefreshFailed)
+)
+
+// Event is a change noticed by Watch
+type Event struct {
+	Type EventType `json:"type"`
+	Time time.Time `json:"time"`
+	// Name is the role or target that changed
+	Name string `json:"name,omitempty"`
+	// Role is the parent of a delegated role, or the role that lists a target
+	Role       string `json:"role,omitempty"`
+	OldVersion int64  `json:"oldVersion,omitempty"`
+	NewVersion int64  `json:"newVersion,omitempty"`
+	// OldTarget and NewTarget are set for target events
+	OldTarget *Target `json:"oldTarget,omitempty"`
+	NewTarget *Target `json:"newTarget,omitempty"`
+	// Error is set for refresh failures
+	Error string `json:"error,omitempty"`
+}
+
+// WatchOptions contains optional configuration for Watch
+type WatchOptions struct {
+	// Interval is the time between refreshes (default: one minute)
+	Interval time.Duration
+}
+
+// Watch refreshes the repository every interval until ctx is done, calling fn
+// for each role whose metadata changed and each target added, removed or
+// modified in any role. The first refresh sets the baseline and reports
+// nothing. A failed refresh, including one rejected by verification, is
+// reported as a RefreshFailed event and watching continues from the last
+// trusted state. After each refresh the client's other methods see the new
+// metadata.
+//
+// Watch returns ctx's error when ctx is done, or the first error fn returns.
+func (c *Client) Watch(ctx context.Context, options WatchOptions, fn func(Event) error) error {
+	return c.client.Watch(ctx, client.WatchOptions{Interval: options.Interval}, func(event client.WatchEvent) error {
+		converted := Event{
+			Type:       EventType(event.Type),
+			Time:       event.Time,
+			Name:       event.Name,
+			Role:       event.Role,
+			OldVersion: event.OldVersion,
+			NewVersion: event.NewVersion,
+			Error:      event.Error,
+		}
+		if event.OldTarget != nil {
+			target := newTarget(event.OldTarget, "")
+			converted.OldTarget = &target
+		}
+		if event.NewTarget != nil {
+			target := newTarget(event.NewTarget, "")
+			converted.NewTarget = &target
+		}
+		return fn(converted)
+	})
+}